@@ -0,0 +1,79 @@
+package backup
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tucker-study/01-Go-Start/confdiff"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// StageStore는 가져온 설정을 저장하다 실패한 단계입니다.
+const StageStore runner.Stage = "store"
+
+// Normalize는 running config에서 매번 달라지는 줄(confdiff.DefaultIgnore)을 빼고 줄 끝 공백을 정리합니다.
+// 바뀌었는지 판단하거나 차이를 보여줄 때만 씁니다. ntp clock-period처럼 빼는 줄도 실제 설정이므로
+// 저장은 가져온 그대로 합니다.
+func Normalize(config string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(config, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if !confdiff.Ignored(strings.TrimSpace(line), confdiff.DefaultIgnore) {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// Save는 config를 그대로 store에 저장합니다. 마지막 버전과 Normalize한 내용이 같으면
+// 저장하지 않고 마지막 버전과 false를 돌려줍니다.
+func Save(store Store, host string, config []byte, at time.Time) (Version, bool, error) {
+	versions, err := store.List(host)
+	if err != nil {
+		return Version{}, false, err
+	}
+	if n := len(versions); n > 0 {
+		last, err := store.Show(host, versions[n-1].ID)
+		if err != nil {
+			return Version{}, false, err
+		}
+		if Normalize(string(last)) == Normalize(string(config)) {
+			return versions[n-1], false, nil
+		}
+	}
+	return store.Save(host, config, at)
+}
+
+// Result는 장비 한 대의 백업 결과입니다.
+type Result struct {
+	Version Version
+	// Changed는 새 버전이 저장되었는지입니다. 마지막 버전과 같으면 false입니다.
+	Changed bool
+}
+
+// Job은 running config를 가져와 store에 저장하는 Job을 만듭니다.
+// running config를 가져오다 실패하면 ctx의 재시도 정책(device.WithRetry)에 따라 다시 가져옵니다.
+func Job(store Store) runner.Job[Result] {
+	return device.Retry(func(ctx context.Context, r inventory.Router) (Result, error) {
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return Result{}, err
+		}
+		defer s.Close()
+
+		config, err := s.RunningConfig()
+		if err != nil {
+			return Result{}, err
+		}
+
+		v, changed, err := Save(store, r.Hostname, []byte(config), time.Now())
+		if err != nil {
+			return Result{}, runner.Fail(StageStore, err)
+		}
+		return Result{Version: v, Changed: changed}, nil
+	})
+}
@@ -0,0 +1,106 @@
+package backup_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/backup"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// configure는 r에 설정 줄 하나를 넣습니다.
+func configure(ctx context.Context, t *testing.T, r inventory.Router, line string) {
+	t.Helper()
+	s, err := device.Open(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Configure([]string{line}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJob(t *testing.T) {
+	srv, err := fakedevice.Start(fakedevice.Device{
+		Hostname: "rtr1",
+		Platform: "cisco_iosxe",
+		Config:   "hostname rtr1\nntp clock-period 17179869\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	store, err := backup.OpenDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	r := srv.Router()
+	job := backup.Job(store)
+
+	res, err := job(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed {
+		t.Error("first backup not saved")
+	}
+	saved, err := store.Show("rtr1", res.Version.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 무시하는 줄(ntp clock-period)도 저장은 그대로 합니다.
+	if want := srv.RunningConfig(); string(saved) != want {
+		t.Errorf("saved config:\n%s\nwant:\n%s", saved, want)
+	}
+
+	// 설정이 그대로이면 새 버전을 만들지 않습니다.
+	res, err = job(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed {
+		t.Error("unchanged config saved again")
+	}
+
+	// 무시하는 줄만 바뀌어도 새 버전을 만들지 않습니다.
+	configure(ctx, t, r, "ntp clock-period 17179870")
+	res, err = job(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Changed {
+		t.Error("config saved again for an ignored line")
+	}
+
+	// 설정을 바꾸면 새 버전이 생깁니다.
+	configure(ctx, t, r, "ntp server 192.0.2.123")
+
+	res, err = job(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Changed {
+		t.Error("changed config not saved")
+	}
+	saved, err = store.Show("rtr1", res.Version.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(saved), "ntp server 192.0.2.123") {
+		t.Errorf("new version lacks the change:\n%s", saved)
+	}
+	versions, err := store.List("rtr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("got %d versions, want 2", len(versions))
+	}
+}
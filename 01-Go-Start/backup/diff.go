@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Unified는 두 설정의 줄 단위 차이를 unified diff 형식(앞뒤 문맥 3줄)으로 돌려줍니다.
+// 차이가 없으면 빈 문자열입니다.
+func Unified(oldName, newName, a, b string) string {
+	x, y := splitLines(a), splitLines(b)
+	ops := diffLines(x, y)
+
+	const context = 3
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// 다음 변경 위치를 찾습니다.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// 문맥 3줄 안에 이어지는 변경은 한 hunk로 묶습니다.
+		first := max(start-context, 0)
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i
+			} else if i-end > 2*context {
+				break
+			}
+		}
+		last := min(end+context+1, len(ops))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		hunk := ops[first:last]
+		oldStart, newStart := hunk[0].x+1, hunk[0].y+1
+		var oldLen, newLen int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		if oldLen == 0 {
+			oldStart--
+		}
+		if newLen == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, op := range hunk {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		start = last
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffOp은 diff 결과의 한 줄입니다. x, y는 각각 이전/이후 설정에서의 줄 위치입니다.
+type diffOp struct {
+	kind byte // ' ', '-', '+'
+	text string
+	x, y int
+}
+
+// diffLines는 Myers 알고리즘으로 x를 y로 바꾸는 최소 편집을 구합니다.
+func diffLines(x, y []string) []diffOp {
+	n, m := len(x), len(y)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+	// 각 d 단계를 시작할 때 대각선 k마다 도달한 가장 먼 x 위치를 기록해 둡니다.
+search:
+	for d := 0; d <= offset; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var i int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				i = v[offset+k+1]
+			} else {
+				i = v[offset+k-1] + 1
+			}
+			j := i - k
+			for i < n && j < m && x[i] == y[j] {
+				i++
+				j++
+			}
+			v[offset+k] = i
+			if i >= n && j >= m {
+				break search
+			}
+		}
+	}
+
+	// 기록을 거꾸로 따라가며 편집 순서를 만듭니다.
+	var ops []diffOp
+	i, j := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := i - j
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevI := v[offset+prevK]
+		prevJ := prevI - prevK
+		for i > prevI && j > prevJ {
+			i--
+			j--
+			ops = append(ops, diffOp{' ', x[i], i, j})
+		}
+		if d > 0 {
+			if i == prevI {
+				j--
+				ops = append(ops, diffOp{'+', y[j], i, j})
+			} else {
+				i--
+				ops = append(ops, diffOp{'-', x[i], i, j})
+			}
+		}
+	}
+
+	for l, r := 0, len(ops)-1; l < r; l, r = l+1, r-1 {
+		ops[l], ops[r] = ops[r], ops[l]
+	}
+	return ops
+}
@@ -0,0 +1,147 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirStore는 설정 내용을 SHA-256 이름의 파일로 저장하는 content-addressed 저장소입니다.
+//
+//	root/objects/ab/abcdef...     설정 내용 (같은 내용은 한 번만 저장)
+//	root/hosts/rtr1.log           "시각 해시" 한 줄이 한 버전
+type DirStore struct {
+	root string
+	mu   sync.Mutex
+}
+
+// OpenDir은 root의 디렉터리 저장소를 엽니다. 없으면 만듭니다.
+func OpenDir(root string) (*DirStore, error) {
+	for _, dir := range []string{"objects", "hosts"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &DirStore{root: root}, nil
+}
+
+func (s *DirStore) objectPath(id string) string {
+	return filepath.Join(s.root, "objects", id[:2], id)
+}
+
+func (s *DirStore) logPath(host string) string {
+	return filepath.Join(s.root, "hosts", host+".log")
+}
+
+func (s *DirStore) Save(host string, config []byte, at time.Time) (Version, bool, error) {
+	if err := checkHost(host); err != nil {
+		return Version{}, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := sha256.Sum256(config)
+	v := Version{Host: host, ID: hex.EncodeToString(sum[:]), Time: at.UTC()}
+
+	versions, err := s.list(host)
+	if err != nil {
+		return v, false, err
+	}
+	if n := len(versions); n > 0 && versions[n-1].ID == v.ID {
+		return versions[n-1], false, nil
+	}
+
+	obj := s.objectPath(v.ID)
+	if _, err := os.Stat(obj); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(obj), 0o755); err != nil {
+			return v, false, err
+		}
+		// 쓰다가 중단되어도 깨진 object가 남지 않도록 임시 파일에 쓴 뒤 옮깁니다.
+		tmp := obj + ".tmp"
+		if err := os.WriteFile(tmp, config, 0o644); err != nil {
+			return v, false, err
+		}
+		if err := os.Rename(tmp, obj); err != nil {
+			return v, false, err
+		}
+	}
+
+	f, err := os.OpenFile(s.logPath(host), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return v, false, err
+	}
+	if _, err := fmt.Fprintf(f, "%s %s\n", v.Time.Format(time.RFC3339Nano), v.ID); err != nil {
+		f.Close()
+		return v, false, err
+	}
+	return v, true, f.Close()
+}
+
+func (s *DirStore) List(host string) ([]Version, error) {
+	if err := checkHost(host); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(host)
+}
+
+func (s *DirStore) list(host string) ([]Version, error) {
+	f, err := os.Open(s.logPath(host))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var versions []Version
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		ts, id, ok := strings.Cut(sc.Text(), " ")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", s.logPath(host), err)
+		}
+		versions = append(versions, Version{Host: host, ID: id, Time: t})
+	}
+	return versions, sc.Err()
+}
+
+func (s *DirStore) Show(host, id string) ([]byte, error) {
+	versions, err := s.List(host)
+	if err != nil {
+		return nil, err
+	}
+	v, err := find(host, versions, id)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(s.objectPath(v.ID))
+}
+
+func (s *DirStore) Hosts() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.root, "hosts", "*.log"))
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, f := range files {
+		hosts = append(hosts, strings.TrimSuffix(filepath.Base(f), ".log"))
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
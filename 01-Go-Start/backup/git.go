@@ -0,0 +1,170 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GitStore는 장비마다 <host>.cfg 파일을 두고 바뀔 때마다 커밋하는 git 저장소입니다.
+// git 명령이 PATH에 있어야 합니다.
+type GitStore struct {
+	root string
+	mu   sync.Mutex
+}
+
+// InitGit은 root에 git 저장소를 엽니다. 없으면 git init으로 만듭니다.
+func InitGit(root string) (*GitStore, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	s := &GitStore{root: root}
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		return s, nil
+	}
+	if _, err := s.git(nil, "init", "-q"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenGit은 이미 있는 git 저장소를 엽니다.
+func OpenGit(root string) (*GitStore, error) {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return nil, err
+	}
+	return &GitStore{root: root}, nil
+}
+
+// git은 저장소에서 git 명령을 실행하고 표준 출력을 돌려줍니다.
+// 사용자 git 설정에 영향을 받지 않도록 커밋 작성자를 직접 지정합니다.
+func (s *GitStore) git(env []string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", s.root}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=backup", "GIT_AUTHOR_EMAIL=backup@localhost",
+		"GIT_COMMITTER_NAME=backup", "GIT_COMMITTER_EMAIL=backup@localhost",
+	)
+	cmd.Env = append(cmd.Env, env...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return out, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func (s *GitStore) Save(host string, config []byte, at time.Time) (Version, bool, error) {
+	if err := checkHost(host); err != nil {
+		return Version{}, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file := host + ".cfg"
+	if err := os.WriteFile(filepath.Join(s.root, file), config, 0o644); err != nil {
+		return Version{}, false, err
+	}
+
+	status, err := s.git(nil, "status", "--porcelain", "--", file)
+	if err != nil {
+		return Version{}, false, err
+	}
+	if len(bytes.TrimSpace(status)) == 0 {
+		versions, err := s.list(host)
+		if err != nil || len(versions) == 0 {
+			return Version{}, false, err
+		}
+		return versions[len(versions)-1], false, nil
+	}
+
+	if _, err := s.git(nil, "add", "--", file); err != nil {
+		return Version{}, false, err
+	}
+	date := at.UTC().Format(time.RFC3339)
+	msg := fmt.Sprintf("%s: backup at %s", host, date)
+	env := []string{"GIT_AUTHOR_DATE=" + date, "GIT_COMMITTER_DATE=" + date}
+	if _, err := s.git(env, "commit", "-q", "-m", msg, "--", file); err != nil {
+		return Version{}, false, err
+	}
+
+	out, err := s.git(nil, "rev-parse", "HEAD")
+	if err != nil {
+		return Version{}, false, err
+	}
+	return Version{Host: host, ID: strings.TrimSpace(string(out)), Time: at.UTC().Truncate(time.Second)}, true, nil
+}
+
+func (s *GitStore) List(host string) ([]Version, error) {
+	if err := checkHost(host); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list(host)
+}
+
+func (s *GitStore) list(host string) ([]Version, error) {
+	out, err := s.git(nil, "log", "--reverse", "--format=%H %aI", "--", host+".cfg")
+	if err != nil {
+		// 아직 커밋이 하나도 없는 저장소입니다.
+		if _, headErr := s.git(nil, "rev-parse", "--verify", "-q", "HEAD"); headErr != nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []Version
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		id, ts, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, Version{Host: host, ID: id, Time: t.UTC()})
+	}
+	return versions, nil
+}
+
+func (s *GitStore) Show(host, id string) ([]byte, error) {
+	versions, err := s.List(host)
+	if err != nil {
+		return nil, err
+	}
+	v, err := find(host, versions, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.git(nil, "show", v.ID+":"+host+".cfg")
+}
+
+func (s *GitStore) Hosts() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out, err := s.git(nil, "ls-files", "--", "*.cfg")
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, f := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if f == "" {
+			continue
+		}
+		hosts = append(hosts, strings.TrimSuffix(f, ".cfg"))
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
@@ -0,0 +1,91 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound는 장비나 버전을 찾지 못했을 때의 에러입니다.
+var ErrNotFound = errors.New("backup not found")
+
+// Version은 저장된 설정 하나입니다.
+type Version struct {
+	Host string
+	// ID는 저장소 안에서 버전을 구분하는 값입니다. (디렉터리 저장소는 내용의 SHA-256, git 저장소는 커밋 해시)
+	ID   string
+	Time time.Time
+}
+
+// Short는 화면에 보여줄 짧은 ID입니다. Show, Diff에는 짧은 ID도 쓸 수 있습니다.
+func (v Version) Short() string {
+	if len(v.ID) > 12 {
+		return v.ID[:12]
+	}
+	return v.ID
+}
+
+// Store는 장비별 설정을 버전으로 쌓아두는 저장소입니다. 여러 고루틴에서 함께 써도 됩니다.
+type Store interface {
+	// Save는 설정을 저장합니다. 마지막 버전과 내용이 같으면 저장하지 않고 false를 돌려줍니다.
+	Save(host string, config []byte, at time.Time) (Version, bool, error)
+	// List는 장비의 버전을 오래된 것부터 돌려줍니다.
+	List(host string) ([]Version, error)
+	// Show는 버전의 내용을 돌려줍니다.
+	Show(host, id string) ([]byte, error)
+	// Hosts는 백업이 있는 장비 목록입니다.
+	Hosts() ([]string, error)
+}
+
+// 저장소 종류입니다.
+const (
+	KindDir = "dir"
+	KindGit = "git"
+)
+
+// Open은 root의 저장소를 엽니다. root에 .git이 있으면 git 저장소입니다.
+func Open(root string) (Store, error) {
+	if _, err := os.Stat(filepath.Join(root, ".git")); err == nil {
+		return OpenGit(root)
+	}
+	return OpenDir(root)
+}
+
+// Create는 root에 kind 종류의 저장소를 엽니다. 없으면 새로 만듭니다.
+func Create(kind, root string) (Store, error) {
+	switch kind {
+	case KindDir:
+		return OpenDir(root)
+	case KindGit:
+		return InitGit(root)
+	}
+	return nil, fmt.Errorf("unknown backup store %q (want %s or %s)", kind, KindDir, KindGit)
+}
+
+// find는 versions에서 id(짧은 ID 포함)에 맞는 버전을 찾습니다.
+func find(host string, versions []Version, id string) (Version, error) {
+	var found []Version
+	for _, v := range versions {
+		if strings.HasPrefix(v.ID, id) {
+			found = append(found, v)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Version{}, fmt.Errorf("%s %s: %w", host, id, ErrNotFound)
+	case 1:
+		return found[0], nil
+	}
+	return Version{}, fmt.Errorf("%s %s: ambiguous version id", host, id)
+}
+
+// checkHost는 장비 이름을 파일 이름으로 써도 되는지 확인합니다.
+func checkHost(host string) error {
+	if host == "" || host == "." || host == ".." || strings.ContainsAny(host, `/\`) {
+		return fmt.Errorf("invalid host name %q", host)
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"tucker-study/01-Go-Start/backup"
+	"tucker-study/01-Go-Start/confdiff"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 장비의 running config를 버전별로 백업하고 비교하는 명령입니다.
+//
+//	$ backup run -store backups all                  한 번 백업
+//	$ backup run -kind git -every 1h edge            1시간마다 백업 (Ctrl+C로 종료)
+//	$ backup list [host...]
+//	$ backup show rtr1 [version]                     버전을 주지 않으면 마지막 버전
+//	$ backup diff rtr1 [old [new]]                   버전을 주지 않으면 마지막 두 버전
+//	$ backup diff -format json rtr1                  섹션 단위 비교 (text, json)
+//
+// 설정은 가져온 그대로 저장하고, 매번 달라지는 줄(시각, ntp clock-period 등)만 다른 경우는 새 버전을 만들지 않습니다.
+// -store 디렉터리에 .git이 있으면 git 저장소로, 아니면 content-addressed 디렉터리로 엽니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: backup <run|list|show|diff> [flags] [args]\n")
+	os.Exit(2)
+}
+
+func printResult(res runner.Result[backup.Result]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n", res.Host.Hostname, res.Err)
+		return
+	}
+	state := "unchanged"
+	if res.Value.Changed {
+		state = "saved"
+	}
+	fmt.Printf("%s: %s %s\n", res.Host.Hostname, state, res.Value.Version.Short())
+}
+
+func run(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	var invFlags inventory.Flags
+	invFlags.Register(fs, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(fs)
+	var replayFlags replay.Flags
+	replayFlags.Register(fs)
+	var devFlags device.Flags
+	devFlags.Register(fs)
+	root := fs.String("store", "backups", "backup directory")
+	kind := fs.String("kind", backup.KindDir, "store kind when creating a new store [dir, git]")
+	every := fs.Duration("every", 0, "run repeatedly at this interval until interrupted")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: backup run [flags] <pattern>\n")
+		os.Exit(2)
+	}
+	invFlags.Limit = fs.Arg(0)
+
+	var store backup.Store
+	var err error
+	if _, statErr := os.Stat(*root); statErr == nil {
+		store, err = backup.Open(*root)
+	} else {
+		store, err = backup.Create(*kind, *root)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 진행 중인 백업을 멈추고 주기 실행도 끝냅니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err = replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 주기 실행 중에 인벤토리가 바뀔 수 있으므로 매번 다시 가져옵니다.
+	once := func() (*runner.Report, error) {
+		hosts, err := invFlags.Hosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rep := runner.Collect(runner.Run(ctx, hosts, backup.Job(store), runOpts), printResult)
+		rep.WriteTable(os.Stdout)
+		return rep, nil
+	}
+
+	rep, err := once()
+	if *every <= 0 {
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(rep.ExitCode())
+	}
+	if err != nil {
+		log.Print(err)
+	}
+
+	ticker := time.NewTicker(*every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			fmt.Println("Run at", t.Local())
+			if _, err := once(); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
+
+// openStore는 조회용으로 이미 있는 저장소를 엽니다.
+func openStore(fs *flag.FlagSet, args []string) backup.Store {
+	root := fs.String("store", "backups", "backup directory")
+	fs.Parse(args)
+	if _, err := os.Stat(*root); err != nil {
+		log.Fatal(err)
+	}
+	store, err := backup.Open(*root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return store
+}
+
+func list(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	store := openStore(fs, args)
+
+	hosts := fs.Args()
+	if len(hosts) == 0 {
+		var err error
+		if hosts, err = store.Hosts(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tVERSION\tTIME")
+	for _, h := range hosts {
+		versions, err := store.List(h)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, v := range versions {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Host, v.Short(), v.Time.Local().Format(time.DateTime))
+		}
+	}
+	tw.Flush()
+}
+
+// version은 id가 비어 있으면 끝에서 back번째 버전의 ID를 돌려줍니다. (0이면 마지막 버전)
+func version(store backup.Store, host, id string, back int) string {
+	if id != "" {
+		return id
+	}
+	versions, err := store.List(host)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(versions) <= back {
+		log.Fatalf("%s: only %d versions", host, len(versions))
+	}
+	return versions[len(versions)-1-back].ID
+}
+
+func show(args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	store := openStore(fs, args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fmt.Fprintf(os.Stderr, "usage: backup show [-store dir] <host> [version]\n")
+		os.Exit(2)
+	}
+
+	host := fs.Arg(0)
+	b, err := store.Show(host, version(store, host, fs.Arg(1), 0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(b)
+}
+
+func diff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "unified", "output format [unified, text, json]")
+	var opts confdiff.Options
+	opts.Register(fs)
+	store := openStore(fs, args)
+	if fs.NArg() < 1 || fs.NArg() > 3 {
+		fmt.Fprintf(os.Stderr, "usage: backup diff [-store dir] [-format f] <host> [old [new]]\n")
+		os.Exit(2)
+	}
+
+	host := fs.Arg(0)
+	oldID := fs.Arg(1)
+	if oldID == "" {
+		oldID = version(store, host, "", 1)
+	}
+	newID := version(store, host, fs.Arg(2), 0)
+
+	a, err := store.Show(host, oldID)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := store.Show(host, newID)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch *format {
+	case "unified":
+		short := func(id string) string { return backup.Version{ID: id}.Short() }
+		fmt.Print(backup.Unified(host+"@"+short(oldID), host+"@"+short(newID), backup.Normalize(string(a)), backup.Normalize(string(b))))
+	case "text":
+		err = confdiff.Compare(string(a), string(b), opts).WriteText(os.Stdout)
+	case "json":
+		err = confdiff.Compare(string(a), string(b), opts).WriteJSON(os.Stdout)
+	default:
+		log.Fatalf("unknown diff format %q", *format)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "run":
+		run(os.Args[2:])
+	case "list":
+		list(os.Args[2:])
+	case "show":
+		show(os.Args[2:])
+	case "diff":
+		diff(os.Args[2:])
+	default:
+		usage()
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"tucker-study/01-Go-Start/compliance"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 인벤토리 장비들이 규칙 파일을 지키는지 검사하는 명령입니다.
+//
+//	$ compliance -rules rules.yml all
+//	$ compliance -rules rules.yml -report report.html -report report.json edge
+//
+// 규칙을 지키지 않거나 접속하지 못한 장비가 있으면 종료 코드 1로 끝납니다.
+
+type reportsValue []string
+
+func (r *reportsValue) String() string { return fmt.Sprint(*r) }
+
+func (r *reportsValue) Set(v string) error {
+	*r = append(*r, v)
+	return nil
+}
+
+func printResult(res runner.Result[compliance.Result]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n", res.Host.Hostname, res.Err)
+		return
+	}
+	for _, rr := range res.Value.Rules {
+		if rr.Status != compliance.Pass {
+			fmt.Printf("%s: %s %s: %s\n", res.Host.Hostname, rr.Rule, rr.Status, rr.Message)
+		}
+	}
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	rulesPath := flag.String("rules", "rules.yml", "compliance rules file")
+	var reports reportsValue
+	flag.Var(&reports, "report", "write the report to a .json or .html file (repeatable)")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: compliance [-rules file] [-report file] [flags] <pattern>")
+		os.Exit(2)
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	rules, err := compliance.Load(*rulesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err = replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rep := compliance.Collect(rules, runner.Run(ctx, hosts, compliance.Job(rules), runOpts), printResult)
+
+	rep.WriteTable(os.Stdout)
+	for _, path := range reports {
+		if err := rep.Save(path); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"tucker-study/01-Go-Start/confdiff"
+)
+
+// 두 설정 파일을 섹션 단위로 비교하는 명령입니다.
+//
+//	$ confdiff old.cfg new.cfg
+//	$ confdiff -json -ignore '^snmp-server location' old.cfg new.cfg
+//
+// 줄 순서와 들여쓰기 폭의 차이, 시각처럼 매번 바뀌는 줄은 무시합니다.
+// diff처럼 차이가 없으면 종료 코드 0, 있으면 1입니다.
+func main() {
+	var opts confdiff.Options
+	opts.Register(flag.CommandLine)
+	asJSON := flag.Bool("json", false, "print the changes as JSON")
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "usage: confdiff [-json] [-ignore regexp] <old> <new>")
+		os.Exit(2)
+	}
+
+	a, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := os.ReadFile(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	d := confdiff.Compare(string(a), string(b), opts)
+	if *asJSON {
+		err = d.WriteJSON(os.Stdout)
+	} else {
+		err = d.WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !d.Empty() {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/facts"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 장비마다 버전, 인터페이스, 인벤토리, LLDP 이웃, 라우팅 요약을 모아 벤더와 상관없는 형태로 캐시하는 명령입니다.
+//
+//	$ facts all                              모아서 facts/<hostname>.json에 저장
+//	$ facts -max-age 1h edge                 1시간 안에 모은 장비는 접속하지 않고 캐시를 씀
+//	$ facts -json rtr1                       모은 사실을 JSON으로 출력
+//
+// 일부 명령이 실패해도(템플릿이나 명령이 없는 플랫폼) 나머지 사실은 저장하고, 실패한 항목은 errors에 남습니다.
+// 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰입니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: facts [flags] <pattern>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func printResult(res runner.Result[facts.Result]) {
+	f := res.Value.Facts
+	if res.Err != nil || f == nil {
+		fmt.Printf("%s: %+v\n", res.Host.Hostname, res.Err)
+		return
+	}
+
+	routes := "-"
+	if f.Routes != nil {
+		routes = fmt.Sprint(f.Routes.Total)
+	}
+	fmt.Printf("%s: %s %s %s, %d/%d interfaces up, %d modules, %d neighbors, %s routes",
+		res.Host.Hostname, f.Vendor, f.Model, f.Version, f.Up(), len(f.Interfaces), len(f.Modules), len(f.Neighbors), routes)
+	if res.Value.Cached {
+		fmt.Printf(" (cached %s)", f.Collected.Local().Format("2006-01-02 15:04"))
+	}
+	if len(f.Errors) > 0 {
+		var failed []string
+		for section := range f.Errors {
+			failed = append(failed, section)
+		}
+		slices.Sort(failed)
+		fmt.Printf(" (missing: %s)", strings.Join(failed, ", "))
+	}
+	fmt.Println()
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	cacheDir := flag.String("cache", facts.DefaultDir, "directory for the per-host facts cache")
+	maxAge := flag.Duration("max-age", 0, "reuse cached facts younger than this instead of connecting (0 = always collect)")
+	asJSON := flag.Bool("json", false, "print the collected facts as JSON")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cache := &facts.Cache{Dir: *cacheDir}
+	var collected []*facts.Facts
+	rep := runner.Collect(runner.Run(ctx, hosts, facts.Job(cache, *maxAge), runOpts), func(res runner.Result[facts.Result]) {
+		if *asJSON {
+			if res.Value.Facts != nil {
+				collected = append(collected, res.Value.Facts)
+			}
+			return
+		}
+		printResult(res)
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(collected); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		rep.WriteTable(os.Stdout)
+	}
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
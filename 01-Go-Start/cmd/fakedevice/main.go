@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/netip"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// 인벤토리의 장비마다 가짜 SSH 장비(fakedevice)를 띄우고, 그 장비들에 접속하는 인벤토리를 쓰는 명령입니다.
+// 실제 장비 없이 다른 명령들을 실행해 볼 수 있습니다. Ctrl+C를 누를 때까지 실행됩니다.
+//
+//	$ fakedevice -o fake.yml all &
+//	$ netrun -i fake.yml -parse all 'show version'
+//	$ push -i fake.yml -config-dir configs/ -check 'show ip int brief ~ Loopback0' edge
+//
+// -responses 디렉터리의 <hostname>/<명령의 공백을 _로>.txt 파일이 플랫폼 기본 응답보다 먼저 쓰이고,
+// -configs 디렉터리에 <hostname>.cfg가 있으면 처음 running config가, <hostname>.xml이 있으면 처음 NETCONF 데이터스토어가,
+// <hostname>.json이 있으면 처음 gNMI 상태 트리가 됩니다.
+// 장비는 같은 포트에서 NETCONF도 받으므로 인벤토리에서 mode: netconf인 장비는 그대로 netconf 명령으로 쓸 수 있습니다.
+// mode: gnmi인 장비는 SSH 대신 가짜 gNMI 서버를 띄우므로 gnmi 명령으로 쓸 수 있습니다. (플랫폼은 상관없습니다)
+// 쓰는 인벤토리의 계정은 admin/admin입니다. 흉내 낼 수 없는 플랫폼의 장비는 건너뜁니다.
+
+// server는 가짜 SSH 장비(fakedevice.Server)와 가짜 gNMI 장비(fakedevice.GNMIServer)입니다.
+type server interface {
+	Addr() netip.AddrPort
+	Router() inventory.Router
+	History() []string
+	Close() error
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: fakedevice [flags] <pattern>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+type rejectValue []*regexp.Regexp
+
+func (v *rejectValue) String() string {
+	var s []string
+	for _, re := range *v {
+		s = append(s, re.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func (v *rejectValue) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	*v = append(*v, re)
+	return nil
+}
+
+// readResponses는 dir/<hostname>/ 아래의 응답 파일들을 읽습니다. 디렉터리가 없으면 nil입니다.
+func readResponses(dir, host string) (map[string]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, host))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	responses := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".txt") {
+			continue
+		}
+		b, err := os.ReadFile(filepath.Join(dir, host, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		cmd := strings.ReplaceAll(strings.TrimSuffix(e.Name(), ".txt"), "_", " ")
+		responses[cmd] = string(b)
+	}
+	return responses, nil
+}
+
+// readConfig는 dir/<hostname><ext>를 읽습니다. 없으면 빈 문자열(플랫폼 기본 설정)입니다.
+func readConfig(dir, host, ext string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+	b, err := os.ReadFile(filepath.Join(dir, host+ext))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(b), err
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	out := flag.String("o", "fake.yml", "write an inventory pointing at the fake devices to this file")
+	respDir := flag.String("responses", "", "directory with <hostname>/<command>.txt responses")
+	configDir := flag.String("configs", "", "directory with <hostname>.cfg start configs, <hostname>.xml NETCONF datastores and <hostname>.json gNMI state")
+	paging := flag.Int("paging", 0, "page output every n lines until 'terminal length 0'")
+	latency := flag.Duration("latency", 0, "delay before each command output")
+	var reject rejectValue
+	flag.Var(&reject, "reject", "reject config lines matching this regexp (repeatable)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	hosts, err := invFlags.Hosts(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var (
+		servers []server
+		fake    inventory.Inventory
+		groups  []string
+	)
+	for _, h := range hosts {
+		useGNMI := h.ConnMode() == inventory.ModeGNMI
+		if !useGNMI && !slices.Contains(fakedevice.Platforms(), h.Platform) {
+			log.Printf("%s: skipping unsupported platform %q", h.Hostname, h.Platform)
+			continue
+		}
+		d := fakedevice.Device{
+			Hostname: h.Hostname,
+			Platform: h.Platform,
+			Paging:   *paging,
+			Latency:  *latency,
+			Reject:   reject,
+		}
+		if d.Responses, err = readResponses(*respDir, h.Hostname); err != nil {
+			log.Fatal(err)
+		}
+		if d.Config, err = readConfig(*configDir, h.Hostname, ".cfg"); err != nil {
+			log.Fatal(err)
+		}
+		if d.NetconfConfig, err = readConfig(*configDir, h.Hostname, ".xml"); err != nil {
+			log.Fatal(err)
+		}
+		if d.GNMIState, err = readConfig(*configDir, h.Hostname, ".json"); err != nil {
+			log.Fatal(err)
+		}
+
+		var srv server
+		if useGNMI {
+			srv, err = fakedevice.StartGNMI(d)
+		} else {
+			srv, err = fakedevice.Start(d)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer srv.Close()
+		servers = append(servers, srv)
+
+		r := srv.Router()
+		r.Groups = h.Groups
+		r.ASN = h.ASN
+		r.Custom = h.Custom
+		r.Mode = h.Mode
+		fake.Routers = append(fake.Routers, r)
+		for _, g := range h.Groups {
+			if !slices.Contains(groups, g) && g != inventory.AllGroup {
+				groups = append(groups, g)
+			}
+		}
+		fmt.Printf("%-20s %-12s %s\n", h.Hostname, h.Platform, srv.Addr())
+	}
+	if len(servers) == 0 {
+		log.Fatal("no hosts to fake")
+	}
+	// 장비의 그룹 패턴이 그대로 동작하도록 그룹 이름만 남깁니다. (변수는 장비에 이미 합쳐져 있습니다)
+	for _, g := range groups {
+		fake.Groups = append(fake.Groups, inventory.Group{Name: g})
+	}
+
+	if err := inventory.Save(*out, &fake); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s, press Ctrl+C to stop", *out)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	<-ctx.Done()
+
+	// 종료할 때 장비마다 받은 명령 수를 보여줍니다. (접속 시 scrapligo가 보내는 terminal 명령 제외)
+	for _, srv := range servers {
+		n := 0
+		for _, l := range srv.History() {
+			if !strings.HasPrefix(l, "terminal ") {
+				n++
+			}
+		}
+		log.Printf("%s: %d commands", srv.Router().Hostname, n)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"tucker-study/01-Go-Start/gnmi"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 인벤토리에서 mode: gnmi인 장비들에 gNMI Get, Set, Subscribe를 보내는 명령입니다.
+//
+//	$ gnmi edge get /interfaces/interface[name=Ethernet1]/state
+//	$ gnmi -update '/interfaces/interface[name=Ethernet1]/config/description=uplink' edge set
+//	$ gnmi -mode sample -interval 5s -o counters.jsonl edge subscribe /interfaces/interface/state/counters
+//	$ gnmi -prometheus :9273 edge subscribe /interfaces                 http://localhost:9273/metrics
+//
+// 받은 알림은 잎 값마다 {"host","path","value","timestamp"} 한 줄(JSON Lines)로 표준 출력에, -o를 주면 파일에 씁니다.
+// subscribe는 -duration이 지나거나 Ctrl+C를 누를 때까지 모든 장비를 함께 구독하고, 그렇게 끝난 구독은 성공으로 봅니다.
+// -prometheus를 주면 구독하는 동안 각 잎의 마지막 숫자 값을 그 주소의 /metrics로 보여줍니다.
+// 장비의 port를 지정하지 않았으면 57400번 포트로 접속하고, mode가 gnmi가 아닌 장비는 건너뜁니다.
+// 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰입니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: gnmi [flags] <pattern> get|set|subscribe [path...]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// listValue는 여러 번 줄 수 있는 문자열 플래그입니다.
+type listValue []string
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ", ")
+}
+
+func (v *listValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+// data는 장비 한 대의 결과입니다.
+type data struct {
+	recs    []gnmi.Record
+	results []gnmi.SetResult
+	// updates는 구독에서 받은 잎 값의 수입니다.
+	updates int
+
+	runner.Output
+}
+
+// get은 paths의 값을 가져오는 Job을 만듭니다.
+func get(opts gnmi.Options, paths []string) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (out data, err error) {
+		c, err := gnmi.Dial(ctx, r, opts)
+		if err != nil {
+			return out, err
+		}
+		defer c.Close()
+		out.recs, err = c.Get(ctx, paths...)
+		return out, err
+	}
+}
+
+// set은 deletes, replaces, updates를 한 번의 Set으로 보내는 Job을 만듭니다.
+func set(opts gnmi.Options, deletes []string, replaces, updates []gnmi.Update) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (out data, err error) {
+		c, err := gnmi.Dial(ctx, r, opts)
+		if err != nil {
+			return out, err
+		}
+		defer c.Close()
+		out.results, err = c.Set(ctx, deletes, replaces, updates)
+		for _, res := range out.results {
+			out.Raw += res.Op + " " + res.Path + "\n"
+		}
+		return out, err
+	}
+}
+
+// subscribe는 sub를 구독해서 받은 값을 sinks에 쓰는 Job을 만듭니다. duration이 0이면 ctx가 끝날 때까지입니다.
+func subscribe(opts gnmi.Options, sub gnmi.Subscription, duration time.Duration, sinks []gnmi.Sink) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (out data, err error) {
+		c, err := gnmi.Dial(ctx, r, opts)
+		if err != nil {
+			return out, err
+		}
+		defer c.Close()
+
+		if duration > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, duration)
+			defer cancel()
+		}
+		var n atomic.Int64
+		err = c.Subscribe(ctx, sub, func(recs []gnmi.Record) error {
+			n.Add(int64(len(recs)))
+			for _, s := range sinks {
+				if err := s.Write(recs); err != nil {
+					return err
+				}
+			}
+			return nil
+		}, func() {
+			log.Printf("%s: synced", r.Hostname)
+		})
+		out.updates = int(n.Load())
+		out.Raw = fmt.Sprintf("%d updates\n", out.updates)
+		return out, err
+	}
+}
+
+// gnmiHosts는 mode가 gnmi인 장비만 남깁니다.
+func gnmiHosts(hosts []inventory.Router) []inventory.Router {
+	var g []inventory.Router
+	for _, h := range hosts {
+		if h.ConnMode() != inventory.ModeGNMI {
+			log.Printf("%s: skipping %s mode host", h.Hostname, h.ConnMode())
+			continue
+		}
+		g = append(g, h)
+	}
+	return g
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var gnmiOpts gnmi.Options
+	gnmiOpts.Register(flag.CommandLine)
+	var updateFlags, replaceFlags, deletes listValue
+	flag.Var(&updateFlags, "update", "path=value to update with set (repeatable, value is JSON or a string)")
+	flag.Var(&replaceFlags, "replace", "path=value to replace with set (repeatable)")
+	flag.Var(&deletes, "delete", "path to delete with set (repeatable)")
+	mode := flag.String("mode", gnmi.OnChange, "subscription mode: on_change or sample")
+	interval := flag.Duration("interval", 10*time.Second, "sample interval for -mode sample")
+	updatesOnly := flag.Bool("updates-only", false, "skip the initial values when subscribing")
+	duration := flag.Duration("duration", 0, "stop subscribing after this long (0 = until Ctrl+C)")
+	outPath := flag.String("o", "", "write records to this file (JSON Lines) instead of stdout")
+	promAddr := flag.String("prometheus", "", "serve the latest subscribed values at http://<addr>/metrics")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+	op, paths := flag.Arg(1), flag.Args()[2:]
+
+	if *promAddr != "" && op != "subscribe" {
+		log.Fatal("-prometheus only works with subscribe")
+	}
+
+	out := os.Stdout
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	sinks := []gnmi.Sink{gnmi.NewJSONSink(out)}
+
+	var job runner.Job[data]
+	switch op {
+	case "get":
+		job = get(gnmiOpts, paths)
+	case "set":
+		var updates, replaces []gnmi.Update
+		for _, flags := range []struct {
+			in  []string
+			out *[]gnmi.Update
+		}{{updateFlags, &updates}, {replaceFlags, &replaces}} {
+			for _, s := range flags.in {
+				u, err := gnmi.ParseUpdate(s)
+				if err != nil {
+					log.Fatal(err)
+				}
+				*flags.out = append(*flags.out, u)
+			}
+		}
+		if len(updates)+len(replaces)+len(deletes) == 0 {
+			log.Fatal("set needs -update, -replace or -delete")
+		}
+		job = set(gnmiOpts, deletes, replaces, updates)
+	case "subscribe":
+		if len(paths) == 0 {
+			log.Fatal("subscribe needs at least one path")
+		}
+		if *promAddr != "" {
+			exp := gnmi.NewExporter()
+			sinks = append(sinks, exp)
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", exp)
+			go func() {
+				if err := http.ListenAndServe(*promAddr, mux); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					log.Fatal(err)
+				}
+			}()
+		}
+		sub := gnmi.Subscription{Paths: paths, Mode: *mode, Interval: *interval, UpdatesOnly: *updatesOnly}
+		job = subscribe(gnmiOpts, sub, *duration, sinks)
+	default:
+		usage()
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 구독을 끝냅니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hosts = gnmiHosts(hosts)
+	if len(hosts) == 0 {
+		log.Fatal("no gnmi hosts match ", invFlags.Limit)
+	}
+	// 구독은 끝나지 않으므로 모든 장비를 한꺼번에 시작합니다.
+	if op == "subscribe" && runOpts.Limit < len(hosts) {
+		runOpts.Limit = len(hosts)
+	}
+
+	results := runner.Run(ctx, hosts, job, runOpts)
+	rep := runner.Collect(results, func(res runner.Result[data]) {
+		if len(res.Value.recs) > 0 {
+			if err := sinks[0].Write(res.Value.recs); err != nil {
+				log.Print(err)
+			}
+		}
+		for _, r := range res.Value.results {
+			fmt.Printf("### %s: %s %s\n", res.Host.Hostname, r.Op, r.Path)
+		}
+		if op == "subscribe" {
+			log.Printf("%s: %d updates", res.Host.Hostname, res.Value.updates)
+		}
+		if res.Err != nil {
+			fmt.Printf("### %s: %+v\n\n", res.Host.Hostname, res.Err)
+		}
+	})
+
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
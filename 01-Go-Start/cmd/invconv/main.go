@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// 인벤토리를 JSON, XML, YAML, CSV 사이에서 변환하고 검사하는 명령입니다.
+// 입력/출력 형식은 확장자로 정하고, 표준 출력으로 쓸 때는 -to로 형식을 지정합니다.
+//
+//	$ invconv -o input.yml input.json
+//	$ invconv -to csv input.xml
+//	$ invconv -check input.yml
+//
+// 검사에 실패하면 "파일:줄:열: 메시지" 형태로 모두 출력하고 종료 코드 1로 끝납니다.
+// vault로 암호화된 값은 복호화하지 않고 그대로 옮깁니다.
+func main() {
+	out := flag.String("o", "", "output file (format from extension)")
+	to := flag.String("to", "", "output format when writing to stdout [json, xml, yaml, csv]")
+	check := flag.Bool("check", false, "validate only")
+	force := flag.Bool("force", false, "convert even if validation fails")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: invconv [-o file | -to format] [-check] [-force] <input>")
+		os.Exit(2)
+	}
+
+	inv, err := inventory.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	errs := inv.Validate()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if *check || (len(errs) > 0 && !*force) {
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *out != "" {
+		if err := inventory.Save(*out, inv); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *to == "" {
+		log.Fatal("Please provide -o or -to flag")
+	}
+	if err := inventory.Encode(os.Stdout, inv, inventory.Format(*to)); err != nil {
+		log.Fatal(err)
+	}
+}
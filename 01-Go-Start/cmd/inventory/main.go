@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/vault"
+)
+
+// 인벤토리 파일의 비밀번호를 vault 형식으로 암호화/복호화하는 명령입니다.
+//
+//	$ inventory encrypt -key-file ~/.vault_key input.yml
+//	$ inventory encrypt -key-file ~/.vault_key -string 'C1sco12345'
+//	$ inventory decrypt input.yml
+//	$ inventory rekey -key-file old.key -new-key-file new.key input.yml
+//
+// -key-file을 주지 않으면 INVENTORY_VAULT_PASSWORD_FILE, INVENTORY_VAULT_PASSWORD 환경 변수를 사용합니다.
+// 파일은 같은 형식으로 다시 쓰므로 YAML 주석은 남지 않습니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: inventory <encrypt|decrypt|rekey> [flags] <file>...\n")
+	os.Exit(2)
+}
+
+func loadVault(keyFile string) *vault.Vault {
+	var v *vault.Vault
+	var err error
+	if keyFile != "" {
+		v, err = vault.FromKeyFile(keyFile)
+	} else {
+		v, err = vault.FromEnv()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return v
+}
+
+// rewrite는 파일을 복호화하지 않은 채로 읽어 f를 적용한 뒤 같은 자리에 다시 씁니다.
+func rewrite(files []string, f func(inv *inventory.Inventory) error) {
+	for _, path := range files {
+		inv, err := inventory.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := f(inv); err != nil {
+			log.Fatalf("%s: %s", path, err)
+		}
+		if err := inventory.Save(path, inv); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s updated", path)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	fs := flag.NewFlagSet(os.Args[1], flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "vault key file")
+
+	switch os.Args[1] {
+	case "encrypt":
+		str := fs.String("string", "", "encrypt a single value and print it")
+		fs.Parse(os.Args[2:])
+
+		v := loadVault(*keyFile)
+		if *str != "" {
+			enc, err := v.Encrypt(*str)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println(enc)
+			return
+		}
+		rewrite(fs.Args(), func(inv *inventory.Inventory) error { return inv.Encrypt(v) })
+
+	case "decrypt":
+		fs.Parse(os.Args[2:])
+
+		v := loadVault(*keyFile)
+		rewrite(fs.Args(), func(inv *inventory.Inventory) error { return inv.Decrypt(v) })
+
+	case "rekey":
+		newKeyFile := fs.String("new-key-file", "", "new vault key file")
+		fs.Parse(os.Args[2:])
+
+		if *newKeyFile == "" {
+			log.Fatal("Please provide -new-key-file flag")
+		}
+		old := loadVault(*keyFile)
+		v := loadVault(*newKeyFile)
+		rewrite(fs.Args(), func(inv *inventory.Inventory) error { return inv.Rekey(old, v) })
+
+	default:
+		usage()
+	}
+}
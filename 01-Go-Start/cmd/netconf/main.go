@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/scrapli/scrapligo/response"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 인벤토리에서 mode: netconf인 장비들에 NETCONF RPC를 보내는 명령입니다.
+//
+//	$ netconf edge get-config
+//	$ netconf -filter '<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"/>' -o out/ edge get
+//	$ netconf -config ntp.xml edge edit-config
+//	$ netconf -config ntp.xml -target running edge edit-config
+//
+// edit-config는 target을 잠근 뒤 설정을 넣고, candidate이면 validate와 commit까지 한 다음 잠금을 풉니다.
+// 중간에 실패하면 candidate의 변경은 discard-changes로 버립니다. (running에 바로 넣은 경우는 되돌리지 않습니다)
+// 장비의 port를 지정하지 않았으면 830번 포트로 접속하고, mode가 netconf가 아닌 장비는 건너뜁니다.
+// -o를 주면 장비마다 <dir>/<hostname>.xml에 응답을 저장합니다. 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰입니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: netconf [flags] <pattern> get|get-config|edit-config\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// data는 장비 한 대에서 받은 RPC 응답입니다. Report의 원본 출력에는 마지막 응답이 남습니다.
+type data struct {
+	// steps는 edit-config에서 보낸 RPC들입니다.
+	steps []string
+
+	runner.Output
+}
+
+// get은 get 또는 get-config를 보내는 Job을 만듭니다.
+func get(op, source, filter string) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (data, error) {
+		var out data
+		s, err := device.OpenNetconf(ctx, r)
+		if err != nil {
+			return out, err
+		}
+		defer s.Close()
+
+		var rs *response.NetconfResponse
+		if op == "get" {
+			rs, err = s.Get(filter)
+		} else {
+			rs, err = s.GetConfig(source, filter)
+		}
+		if err != nil {
+			return out, err
+		}
+		out.Raw = rs.Result
+		return out, nil
+	}
+}
+
+// editConfig는 설정을 넣는 Job을 만듭니다. target을 잠그고 edit-config, (candidate이면) validate, commit 뒤 잠금을 풉니다.
+func editConfig(target, config string) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (out data, err error) {
+		s, err := device.OpenNetconf(ctx, r)
+		if err != nil {
+			return out, err
+		}
+		defer s.Close()
+
+		if _, err := s.Lock(target); err != nil {
+			return out, fmt.Errorf("lock %s: %w", target, err)
+		}
+		out.steps = append(out.steps, "lock "+target)
+		defer func() {
+			if err != nil && target == device.Candidate {
+				if _, derr := s.Discard(); derr == nil {
+					out.steps = append(out.steps, "discard-changes")
+				}
+			}
+			if _, uerr := s.Unlock(target); uerr == nil {
+				out.steps = append(out.steps, "unlock "+target)
+			} else if err == nil {
+				err = fmt.Errorf("unlock %s: %w", target, uerr)
+			}
+		}()
+
+		rs, err := s.EditConfig(target, config)
+		if err != nil {
+			return out, fmt.Errorf("edit-config: %w", err)
+		}
+		out.steps = append(out.steps, "edit-config "+target)
+		out.Raw = rs.Result
+		if target != device.Candidate {
+			return out, nil
+		}
+
+		if rs, err = s.Validate(target); err != nil {
+			return out, fmt.Errorf("validate: %w", err)
+		}
+		out.steps = append(out.steps, "validate "+target)
+		if rs, err = s.Commit(); err != nil {
+			return out, fmt.Errorf("commit: %w", err)
+		}
+		out.steps = append(out.steps, "commit")
+		out.Raw = rs.Result
+		return out, nil
+	}
+}
+
+// netconfHosts는 mode가 netconf인 장비만 남깁니다.
+func netconfHosts(hosts []inventory.Router) []inventory.Router {
+	var nc []inventory.Router
+	for _, h := range hosts {
+		if h.ConnMode() != inventory.ModeNETCONF {
+			log.Printf("%s: skipping %s mode host", h.Hostname, h.ConnMode())
+			continue
+		}
+		nc = append(nc, h)
+	}
+	return nc
+}
+
+func printResult(op string, res runner.Result[data]) {
+	for _, step := range res.Value.steps {
+		fmt.Printf("### %s: %s\n", res.Host.Hostname, step)
+	}
+	if op != "edit-config" && res.Value.Raw != "" {
+		fmt.Printf("### %s: %s\n%s\n\n", res.Host.Hostname, op, res.Value.Raw)
+	}
+	if res.Err != nil {
+		fmt.Printf("### %s: %+v\n\n", res.Host.Hostname, res.Err)
+	}
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	source := flag.String("source", device.Running, "datastore for get-config")
+	target := flag.String("target", device.Candidate, "datastore for edit-config")
+	filter := flag.String("filter", "", "subtree filter XML for get and get-config")
+	configPath := flag.String("config", "", "file with the <config> XML for edit-config")
+	outDir := flag.String("o", "", "save each host's reply to <dir>/<hostname>.xml")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+	op := flag.Arg(1)
+
+	var job runner.Job[data]
+	switch op {
+	case "get", "get-config":
+		job = get(op, *source, *filter)
+	case "edit-config":
+		if *configPath == "" {
+			log.Fatal("edit-config needs -config")
+		}
+		b, err := os.ReadFile(*configPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		job = editConfig(*target, string(b))
+	default:
+		usage()
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	hosts = netconfHosts(hosts)
+	if len(hosts) == 0 {
+		log.Fatal("no netconf hosts match ", invFlags.Limit)
+	}
+
+	results := runner.Run(ctx, hosts, job, runOpts)
+	rep := runner.Collect(results, func(res runner.Result[data]) {
+		printResult(op, res)
+		if *outDir != "" && res.Value.Raw != "" {
+			if err := os.WriteFile(filepath.Join(*outDir, res.Host.Hostname+".xml"), []byte(res.Value.Raw), 0o644); err != nil {
+				log.Print(err)
+			}
+		}
+	})
+
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 인벤토리에서 패턴에 맞는 장비들에 임의의 명령을 실행하는 명령입니다.
+//
+//	$ netrun edge 'show version' 'show ip int brief'
+//	$ netrun -f commands.txt -o out/ 'site-a:!rtr3'
+//	$ netrun -textfsm cisco_iosxe_show_version.textfsm -report result.json all 'show version'
+//	$ netrun -parse -report result.json all 'show version' 'show ip int brief'
+//	$ netrun -record fixtures/ edge 'show version' && netrun -replay fixtures/ -parse edge 'show version'
+//
+// 명령 파일은 한 줄에 명령 하나이고, 빈 줄과 #으로 시작하는 줄은 무시합니다.
+// -textfsm을 주면 모든 명령의 출력을 그 템플릿으로 파싱합니다.
+// -parse를 주면 장비 플랫폼과 명령에 맞는 내장 템플릿으로 파싱합니다. (NET_TEXTFSM 디렉터리가 먼저)
+// 맞는 템플릿이 없는 명령은 파싱하지 않고 출력만 남깁니다.
+// -record를 주면 세션을 <dir>/<hostname>.json에 녹화하고, -replay를 주면 장비 대신 녹화를 재생합니다. (replay 패키지)
+// -o를 주면 장비마다 <dir>/<hostname>.txt에 출력을 저장합니다.
+// 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰입니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: netrun [flags] <pattern> [command...]\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// readCommands는 명령 파일에서 명령 목록을 읽습니다.
+func readCommands(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var cmds []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cmds = append(cmds, line)
+	}
+	return cmds, sc.Err()
+}
+
+// commandOutput은 명령 하나의 결과입니다.
+type commandOutput struct {
+	command string
+	raw     string
+	parsed  []map[string]interface{}
+}
+
+// data는 장비 한 대에서 실행한 모든 명령의 결과입니다.
+// Report에는 명령별 출력을 이어 붙인 원본과 모든 파싱 레코드가 남습니다.
+type data struct {
+	outputs []commandOutput
+
+	runner.Output
+}
+
+func (d *data) add(out commandOutput) {
+	d.outputs = append(d.outputs, out)
+	d.Raw += fmt.Sprintf("### %s\n%s\n", out.command, out.raw)
+	d.Parsed = append(d.Parsed, out.parsed...)
+}
+
+// runCommands는 명령을 차례로 실행하는 Job을 만듭니다.
+// template이 있으면 그 템플릿으로, auto면 내장 템플릿으로 파싱합니다.
+// 명령이 실패하면 나머지 명령은 실행하지 않고, 그때까지의 출력은 결과에 남깁니다.
+func runCommands(cmds []string, template string, auto bool) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (data, error) {
+		var out data
+
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return out, err
+		}
+		defer s.Close()
+
+		for _, cmd := range cmds {
+			rs, err := s.Send(cmd)
+			if err != nil {
+				return out, fmt.Errorf("%s: %w", cmd, err)
+			}
+
+			co := commandOutput{command: cmd, raw: rs.Result}
+			switch {
+			case template != "":
+				co.parsed, err = device.Parse(rs, template)
+			case auto:
+				co.parsed, err = s.Parse(rs)
+				if errors.Is(err, textfsm.ErrNoTemplate) {
+					err = nil
+				}
+			}
+			out.add(co)
+			if err != nil {
+				return out, fmt.Errorf("%s: %w", cmd, err)
+			}
+		}
+		return out, nil
+	}
+}
+
+func printResult(res runner.Result[data]) {
+	for _, out := range res.Value.outputs {
+		fmt.Printf("### %s: %s\n%s\n", res.Host.Hostname, out.command, out.raw)
+		for _, rec := range out.parsed {
+			fmt.Printf("%v\n", rec)
+		}
+		fmt.Println()
+	}
+	if res.Err != nil {
+		fmt.Printf("### %s: %+v\n\n", res.Host.Hostname, res.Err)
+	}
+}
+
+// saveOutput은 장비의 출력을 dir/<hostname>.txt에 씁니다.
+func saveOutput(dir string, res runner.Result[data]) error {
+	if res.Value.Raw == "" {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(dir, res.Host.Hostname+".txt"), []byte(res.Value.Raw), 0o644)
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	cmdFile := flag.String("f", "", "file with one command per line")
+	template := flag.String("textfsm", "", "parse every output with this TextFSM template")
+	auto := flag.Bool("parse", false, "parse outputs with the bundled TextFSM template for the platform and command")
+	outDir := flag.String("o", "", "save each host's output to <dir>/<hostname>.txt")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	cmds := flag.Args()[1:]
+	if *cmdFile != "" {
+		more, err := readCommands(*cmdFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		cmds = append(cmds, more...)
+	}
+	if len(cmds) == 0 {
+		log.Fatal("Please provide commands or -f flag")
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	results := runner.Run(ctx, hosts, runCommands(cmds, *template, *auto), runOpts)
+	rep := runner.Collect(results, func(res runner.Result[data]) {
+		printResult(res)
+		if *outDir != "" {
+			if err := saveOutput(*outDir, res); err != nil {
+				log.Print(err)
+			}
+		}
+	})
+
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/deploy"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/render"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 후보 설정을 장비에 보내는 명령입니다.
+//
+//	$ push -config ntp.cfg -dry-run edge
+//	$ push -config ntp.cfg -check 'show ntp status ~ synchronized' edge
+//	$ push -config-dir configs/ -check 'show ip bgp summary ~ Established' all
+//	$ push -templates templates -dry-run edge
+//
+// -dry-run이면 running config에 없는 줄만 보여주고 끝납니다.
+// 장비가 설정을 거부하거나 -check가 실패하면 그 장비만 push 전 상태로 되돌립니다.
+// 체크포인트로 되돌릴 수 없는 플랫폼(iosxr, junos 등)에는 -dry-run만 됩니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: push (-config file | -config-dir dir | -templates dir) [flags] <pattern>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+type checksValue struct{ checks *[]deploy.Check }
+
+func (c checksValue) String() string {
+	if c.checks == nil {
+		return ""
+	}
+	var s []string
+	for _, ch := range *c.checks {
+		s = append(s, ch.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func (c checksValue) Set(v string) error {
+	ch, err := deploy.ParseCheck(v)
+	if err != nil {
+		return err
+	}
+	*c.checks = append(*c.checks, ch)
+	return nil
+}
+
+func printResult(res runner.Result[deploy.Result]) {
+	out := res.Value
+	state := "unchanged"
+	switch {
+	case out.RolledBack:
+		state = "rolled back"
+	case out.Applied:
+		state = "applied"
+	case out.Diff != "":
+		state = "pending"
+	}
+	fmt.Printf("### %s: %s\n%s", res.Host.Hostname, state, out.Diff)
+	if res.Err != nil {
+		fmt.Printf("%+v\n", res.Err)
+	}
+	fmt.Println()
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	var opts deploy.Options
+	config := flag.String("config", "", "candidate config sent to every host")
+	configDir := flag.String("config-dir", "", "directory with one <hostname>.cfg per host")
+	templates := flag.String("templates", "", "render each host's config from this template directory")
+	entry := flag.String("entry", render.DefaultEntry, "template to render with -templates")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "only show the lines that would be added")
+	flag.Var(checksValue{&opts.Checks}, "check", "post-check command, optionally 'command ~ regexp' (repeatable)")
+	flag.DurationVar(&opts.Confirm, "confirm", deploy.DefaultConfirm, "time allowed for reconnecting and post-checks before rolling back")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	var src deploy.Source
+	for _, s := range []struct {
+		set bool
+		src func() deploy.Source
+	}{
+		{*config != "", func() deploy.Source { return deploy.File(*config) }},
+		{*configDir != "", func() deploy.Source { return deploy.Dir(*configDir) }},
+		{*templates != "", func() deploy.Source {
+			r := render.New(*templates)
+			r.Entry = *entry
+			return deploy.Template(r)
+		}},
+	} {
+		if !s.set {
+			continue
+		}
+		if src != nil {
+			usage()
+		}
+		src = s.src()
+	}
+	if src == nil {
+		usage()
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뜁니다.
+	// 이미 설정을 보낸 장비는 되돌린 뒤 끝납니다. (-deadline이 지난 경우도 같음)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 설정을 보낸 장비는 ctx가 끝나도 되돌리는 중일 수 있으므로 Job을 버리지 않고(-grace 플래그가 없어 Grace는 0)
+	// 모든 장비가 돌아온 뒤에 끝냅니다.
+	rep := runner.Collect(runner.Run(ctx, hosts, deploy.Push(src, opts), runOpts), printResult)
+
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
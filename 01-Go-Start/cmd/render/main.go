@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/render"
+)
+
+// 인벤토리 변수로 장비마다 설정 파일을 만드는 명령입니다.
+//
+//	$ render -templates templates -o configs/ edge
+//	$ render -entry ntp.tmpl rtr1
+//
+// -o를 주면 <dir>/<hostname>.cfg로 쓰고(push -config-dir로 바로 보낼 수 있습니다), 아니면 표준 출력에 씁니다.
+// 템플릿이 인벤토리에 없는 변수를 쓰는 장비가 하나라도 있으면 종료 코드 1로 끝납니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: render [flags] <pattern>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	dir := flag.String("templates", "templates", "template directory with one subdirectory per platform")
+	entry := flag.String("entry", render.DefaultEntry, "template to render")
+	outDir := flag.String("o", "", "write each config to <dir>/<hostname>.cfg")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	hosts, err := invFlags.Hosts(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	r := render.New(*dir)
+	r.Entry = *entry
+
+	failed := 0
+	for _, h := range hosts {
+		out, err := r.Render(h)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+			continue
+		}
+
+		if *outDir == "" {
+			fmt.Printf("### %s\n%s\n", h.Hostname, out)
+			continue
+		}
+		path := filepath.Join(*outDir, h.Hostname+".cfg")
+		if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s written", path)
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d hosts failed\n", failed, len(hosts))
+		os.Exit(1)
+	}
+}
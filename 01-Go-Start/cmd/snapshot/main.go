@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/snapshot"
+)
+
+// 작업 전후에 장비마다 show 명령의 스냅숏을 찍고, 두 스냅숏을 비교해 달라진 상태를 찾는 명령입니다.
+//
+//	$ snapshot edge take pre                     snapshots/pre/<hostname>.json에 저장
+//	$ snapshot edge take post
+//	$ snapshot edge compare pre post             장비에 접속하지 않고 저장된 스냅숏을 비교
+//	$ snapshot -compare pre edge take post       찍고 바로 pre와 비교
+//	$ snapshot -route-threshold 5 -route-min 10 edge compare pre post
+//
+// 기본으로 show version, show ip interface brief, show ip bgp summary, show ip route summary를 찍고,
+// -command를 주면 그 명령들만 찍습니다. 명령의 출력은 getVersion처럼 TextFSM 템플릿으로 파싱해 저장합니다.
+// 맺어져 있던 BGP 이웃이 내려가거나, up/up이던 인터페이스가 내려가거나, 경로 수가 기준보다 많이 바뀐
+// 장비는 verify 단계에서 실패하고, 새로 올라온 이웃처럼 문제가 아닌 차이는 info로 보여줍니다.
+// 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰입니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: snapshot [flags] <pattern> take <name>\n")
+	fmt.Fprintf(os.Stderr, "       snapshot [flags] <pattern> compare <pre> <post>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// listValue는 여러 번 줄 수 있는 문자열 플래그입니다.
+type listValue []string
+
+func (v *listValue) String() string {
+	return strings.Join(*v, ", ")
+}
+
+func (v *listValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+func printResult(res runner.Result[snapshot.Result]) {
+	if snap := res.Value.Snapshot; snap != nil {
+		failed := 0
+		for _, c := range snap.Commands {
+			if c.Error != "" {
+				failed++
+			}
+		}
+		fmt.Printf("%s: %s taken, %d commands", res.Host.Hostname, snap.Name, len(snap.Commands))
+		if failed > 0 {
+			fmt.Printf(" (%d failed)", failed)
+		}
+		fmt.Println()
+	}
+	if res.Value.Findings != nil {
+		fmt.Printf("%s: %d differences\n", res.Host.Hostname, len(res.Value.Findings))
+	}
+	for _, f := range res.Value.Findings {
+		fmt.Printf("  %s\n", f)
+	}
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n", res.Host.Hostname, res.Err)
+	}
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	dir := flag.String("dir", snapshot.DefaultDir, "directory for the snapshots")
+	var commands listValue
+	flag.Var(&commands, "command", "command to capture with take (repeatable, default: version, interfaces, BGP and route summary)")
+	compareTo := flag.String("compare", "", "with take, compare the new snapshot against this one")
+	th := snapshot.DefaultThresholds
+	flag.Float64Var(&th.RoutePercent, "route-threshold", th.RoutePercent, "fail when a route or prefix count changes by more than this percent")
+	flag.IntVar(&th.RouteMin, "route-min", th.RouteMin, "ignore route or prefix count changes of this many or fewer")
+	asJSON := flag.Bool("json", false, "print the findings as JSON")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() < 3 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	store := &snapshot.Store{Dir: *dir}
+	var job runner.Job[snapshot.Result]
+	switch args := flag.Args()[1:]; args[0] {
+	case "take":
+		if len(args) != 2 {
+			usage()
+		}
+		if len(commands) == 0 {
+			commands = snapshot.DefaultCommands
+		}
+		job = snapshot.TakeJob(store, args[1], commands, *compareTo, th)
+	case "compare":
+		if len(args) != 3 {
+			usage()
+		}
+		job = snapshot.CompareJob(store, args[1], args[2], th)
+	default:
+		usage()
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	hosts, err := invFlags.Hosts(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	findings := map[string][]snapshot.Finding{}
+	rep := runner.Collect(runner.Run(ctx, hosts, job, runOpts), func(res runner.Result[snapshot.Result]) {
+		if *asJSON {
+			if res.Value.Findings != nil {
+				findings[res.Host.Hostname] = res.Value.Findings
+			}
+			return
+		}
+		printResult(res)
+	})
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		rep.WriteTable(os.Stdout)
+	}
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/facts"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/topology"
+)
+
+// 인벤토리의 시작 장비에서 LLDP/CDP 이웃을 따라가며 토폴로지를 찾아 그래프로 내보내는 명령입니다.
+//
+//	$ topology core | dot -Tsvg > core.svg        core 그룹에서 시작해 DOT으로 출력
+//	$ topology -depth 2 -o topo.graphml rtr1      2홉까지, 확장자로 형식을 정해 파일로 저장
+//	$ topology -discover -format json rtr1        인벤토리에 없는 이웃도 rtr1의 계정으로 접속
+//
+// 접속하지 못한 장비와 접속할 방법을 모르는 이웃은 잎 노드로 남습니다. 접속하지 못한 장비가 있으면 종료 코드가 1입니다.
+// 첫 번째 인자인 호스트 패턴이 -limit 대신 쓰이고, 이웃을 인벤토리 장비로 알아볼 때는 인벤토리 전체를 봅니다.
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: topology [flags] <seed pattern>\n")
+	flag.PrintDefaults()
+	os.Exit(2)
+}
+
+// writeGraph는 그래프를 path에 씁니다. path가 비어 있으면 표준 출력입니다.
+func writeGraph(g *topology.Graph, path, format string) error {
+	if path == "" {
+		return g.Write(os.Stdout, format)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := g.Write(f, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var replayFlags replay.Flags
+	replayFlags.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	depth := flag.Int("depth", 0, "maximum hops to crawl from the seeds (0 = no limit)")
+	discover := flag.Bool("discover", false, "also log in to neighbors missing from the inventory, using the credentials of the device that found them")
+	protocols := flag.String("protocols", strings.Join(facts.Protocols, ","), "comma-separated neighbor protocols to use")
+	format := flag.String("format", "", "output format: dot, json or graphml (default: from -o extension, else dot)")
+	outPath := flag.String("o", "", "write the graph to this file instead of stdout")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+	}
+	invFlags.Limit = flag.Arg(0)
+
+	if *format == "" {
+		*format = topology.FormatDOT
+		if *outPath != "" {
+			f, err := topology.FormatFromPath(*outPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			*format = f
+		}
+	}
+
+	// Ctrl+C를 누르면 ctx가 끝나 다음 홉을 찾지 않고 지금까지 찾은 그래프를 내보냅니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	ctx, err := replayFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, err = devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	inv, err := invFlags.Inventory(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	all, err := inv.Hosts()
+	if err != nil {
+		log.Fatal(err)
+	}
+	seeds, err := inv.Select(invFlags.Limit)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	g := topology.Crawl(ctx, all, seeds, topology.Options{
+		Depth:     *depth,
+		Discover:  *discover,
+		Protocols: strings.Split(*protocols, ","),
+		Run:       runOpts,
+	})
+
+	if err := writeGraph(g, *outPath, *format); err != nil {
+		log.Fatal(err)
+	}
+
+	counts := map[topology.State]int{}
+	for _, n := range g.Nodes {
+		counts[n.State]++
+		if n.State == topology.StateUnreachable {
+			log.Printf("%s: %s", n.ID, n.Error)
+		}
+	}
+	log.Printf("%d nodes (%d crawled, %d unreachable, %d unknown), %d links",
+		len(g.Nodes), counts[topology.StateCrawled], counts[topology.StateUnreachable], counts[topology.StateUnknown], len(g.Links))
+	if counts[topology.StateUnreachable] > 0 {
+		os.Exit(1)
+	}
+}
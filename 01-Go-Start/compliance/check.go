@@ -0,0 +1,245 @@
+package compliance
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tucker-study/01-Go-Start/confdiff"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// Status는 규칙 하나의 검사 결과입니다.
+type Status string
+
+const (
+	Pass Status = "pass"
+	Fail Status = "fail"
+	// Error는 명령 실행이나 파싱에 실패해서 검사하지 못한 경우입니다.
+	Error Status = "error"
+)
+
+// RuleResult는 장비 한 대에 대한 규칙 하나의 결과입니다.
+type RuleResult struct {
+	Rule    string `json:"rule"`
+	Status  Status `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// Result는 장비 한 대의 검사 결과입니다. 적용되지 않는 규칙은 들어 있지 않습니다.
+type Result struct {
+	Rules []RuleResult `json:"rules"`
+}
+
+// Passed는 모든 규칙을 통과했는지 확인합니다.
+func (r Result) Passed() bool {
+	for _, rr := range r.Rules {
+		if rr.Status != Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// checker는 장비 한 대를 검사하는 동안 running config와 명령 결과를 한 번만 가져오도록 보관합니다.
+type checker struct {
+	s      *device.Session
+	config *confdiff.Node
+	parsed map[string][]map[string]interface{}
+}
+
+// Job은 장비마다 rules를 검사하는 Job을 만듭니다.
+// 접속에 실패하면 에러를, 규칙을 지키지 않으면 Result에 fail을 돌려줍니다.
+// 검사 중에 세션이 끊기면 에러를 돌려주고, ctx의 재시도 정책(device.WithRetry)에 따라 처음부터 다시 검사합니다.
+func Job(rules *Rules) runner.Job[Result] {
+	return device.Retry(func(ctx context.Context, h inventory.Router) (Result, error) {
+		var res Result
+
+		s, err := device.Open(ctx, h)
+		if err != nil {
+			return res, err
+		}
+		defer s.Close()
+
+		c := &checker{s: s, parsed: map[string][]map[string]interface{}{}}
+		for _, r := range rules.Rules {
+			if !r.Applies(h) {
+				continue
+			}
+			rr := RuleResult{Rule: r.Name, Status: Pass}
+			var err error
+			if r.isCommand() {
+				err = c.command(r)
+			} else {
+				err = c.configRule(r)
+			}
+			if err != nil {
+				rr.Status, rr.Message = Fail, err.Error()
+				var ce checkError
+				if errors.As(err, &ce) {
+					rr.Status = Error
+					if device.Classify(ce.error) == device.ClassClosed {
+						return res, ce.error
+					}
+				}
+			}
+			// 세션이 끊겼으면 나머지 규칙도 검사할 수 없습니다.
+			if ctx.Err() != nil {
+				return res, ctx.Err()
+			}
+			res.Rules = append(res.Rules, rr)
+		}
+		return res, nil
+	})
+}
+
+// checkError는 규칙 위반이 아니라 검사 자체에 실패한 경우입니다.
+type checkError struct{ error }
+
+func (c *checker) runningConfig() (*confdiff.Node, error) {
+	if c.config != nil {
+		return c.config, nil
+	}
+	config, err := c.s.RunningConfig()
+	if err != nil {
+		return nil, checkError{err}
+	}
+	c.config = confdiff.Parse(config, confdiff.DefaultIgnore)
+	return c.config, nil
+}
+
+// sections는 path의 정규식에 차례로 맞는 섹션들을 찾습니다.
+func sections(root *confdiff.Node, path Patterns) []*confdiff.Node {
+	nodes := []*confdiff.Node{root}
+	for _, re := range path {
+		var next []*confdiff.Node
+		for _, n := range nodes {
+			for _, c := range n.Children {
+				if re.MatchString(c.Text) {
+					next = append(next, c)
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (c *checker) configRule(r *Rule) error {
+	root, err := c.runningConfig()
+	if err != nil {
+		return err
+	}
+
+	secs := sections(root, r.Section)
+	if len(secs) == 0 {
+		return fmt.Errorf("no section matching %s", sectionName(r.Section))
+	}
+
+	var problems []string
+	for _, sec := range secs {
+		name := sec.Text
+		if name == "" {
+			name = "(top)"
+		}
+		if r.Contains != "" && sec.Child(strings.TrimSpace(r.Contains)) == nil {
+			problems = append(problems, fmt.Sprintf("%s: missing %q", name, r.Contains))
+		}
+		if r.NotMatch != nil {
+			for _, l := range sec.Lines(0) {
+				if r.NotMatch.MatchString(strings.TrimSpace(l)) {
+					problems = append(problems, fmt.Sprintf("%s: found %q", name, strings.TrimSpace(l)))
+				}
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func sectionName(p Patterns) string {
+	var s []string
+	for _, re := range p {
+		s = append(s, re.String())
+	}
+	return strings.Join(s, " / ")
+}
+
+func (c *checker) command(r *Rule) error {
+	key := r.Command + "\x00" + r.Template
+	records, ok := c.parsed[key]
+	if !ok {
+		rs, err := c.s.Send(r.Command)
+		if err != nil {
+			return checkError{err}
+		}
+		if r.Template != "" {
+			records, err = device.Parse(rs, r.Template)
+		} else {
+			records, err = c.s.Parse(rs)
+		}
+		if err != nil {
+			return checkError{err}
+		}
+		c.parsed[key] = records
+	}
+
+	for _, rec := range records {
+		v, ok := rec[r.Field]
+		if !ok {
+			return checkError{fmt.Errorf("no field %s in %q output", r.Field, r.Command)}
+		}
+		if err := r.checkValue(fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("%s %q %w", r.Field, fmt.Sprint(v), err)
+		}
+	}
+	return nil
+}
+
+// checkValue는 값이 min/max/in/match 조건을 모두 만족하는지 확인합니다.
+func (r *Rule) checkValue(v string) error {
+	if r.Min != "" && compareVersion(v, r.Min) < 0 {
+		return fmt.Errorf("is lower than %s", r.Min)
+	}
+	if r.Max != "" && compareVersion(v, r.Max) > 0 {
+		return fmt.Errorf("is higher than %s", r.Max)
+	}
+	if r.In != nil && !slices.Contains(r.In, v) {
+		return fmt.Errorf("is not one of %s", strings.Join(r.In, ", "))
+	}
+	if r.Match != nil && !r.Match.MatchString(v) {
+		return fmt.Errorf("does not match %s", r.Match)
+	}
+	return nil
+}
+
+var versionParts = regexp.MustCompile(`\d+|[^\d]+`)
+
+// compareVersion은 "17.3.4a"처럼 숫자와 문자가 섞인 값을 비교합니다.
+// 숫자 부분은 숫자로 비교하므로 "17.12"가 "17.3"보다 큽니다. 앞부분이 같으면 긴 쪽이 큽니다.
+func compareVersion(a, b string) int {
+	pa, pb := versionParts.FindAllString(a, -1), versionParts.FindAllString(b, -1)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.ParseUint(pa[i], 10, 64)
+		nb, errB := strconv.ParseUint(pb[i], 10, 64)
+		if errA == nil && errB == nil {
+			if c := cmp.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := strings.Compare(pa[i], pb[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(pa), len(pb))
+}
@@ -0,0 +1,136 @@
+package compliance_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/compliance"
+	"tucker-study/01-Go-Start/fakedevice"
+)
+
+const rules = `rules:
+- name: ntp-server
+  contains: ntp server 192.0.2.1
+- name: vty-ssh-only
+  section: ^line vty
+  contains: transport input ssh
+- name: bgp-log
+  section: [^router bgp]
+  contains: bgp log-neighbor-changes
+- name: no-http-server
+  not_match: ^ip http (secure-)?server
+- name: ospf
+  section: ^router ospf
+  contains: passive-interface default
+- name: ios-version
+  platforms: [cisco_iosxe]
+  command: show version
+  field: VERSION
+  min: "17.3"
+  max: "17.12.99"
+- name: ios-too-old
+  platforms: [cisco_iosxe]
+  command: show version
+  field: VERSION
+  min: "17.12"
+- name: eos-version
+  platforms: [arista_eos]
+  command: show version
+  field: VERSION
+  min: "4.30"
+- name: no-such-field
+  command: show version
+  field: NOPE
+  in: [x]
+`
+
+// loadRules는 text를 규칙 파일로 저장하고 읽습니다.
+func loadRules(t *testing.T, text string) (*compliance.Rules, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yml")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return compliance.Load(path)
+}
+
+func TestJob(t *testing.T) {
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	rs, err := loadRules(t, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := compliance.Job(rs)(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 다른 플랫폼의 규칙(eos-version)은 결과에 없습니다.
+	want := []struct {
+		rule    string
+		status  compliance.Status
+		message string
+	}{
+		{"ntp-server", compliance.Fail, `(top): missing "ntp server 192.0.2.1"`},
+		{"vty-ssh-only", compliance.Pass, ""},
+		{"bgp-log", compliance.Pass, ""},
+		{"no-http-server", compliance.Fail, `(top): found "ip http server"; (top): found "ip http secure-server"`},
+		{"ospf", compliance.Fail, "no section matching ^router ospf"},
+		{"ios-version", compliance.Pass, ""},
+		{"ios-too-old", compliance.Fail, "is lower than 17.12"},
+		{"no-such-field", compliance.Error, "no field NOPE"},
+	}
+	if len(res.Rules) != len(want) {
+		t.Fatalf("results = %+v, want %d rules", res.Rules, len(want))
+	}
+	for i, w := range want {
+		got := res.Rules[i]
+		if got.Rule != w.rule || got.Status != w.status || !strings.Contains(got.Message, w.message) {
+			t.Errorf("result %d = %+v, want %s %s %q", i, got, w.rule, w.status, w.message)
+		}
+	}
+	if res.Passed() {
+		t.Error("Passed() with failing rules")
+	}
+
+	// running config와 명령 출력은 장비마다 한 번씩만 가져옵니다.
+	var shows int
+	for _, cmd := range srv.History() {
+		if strings.HasPrefix(cmd, "show ") {
+			shows++
+		}
+	}
+	if shows != 2 {
+		t.Errorf("%d show commands sent, want 2: %q", shows, srv.History())
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"rules:\n- contains: x\n", "rule without name"},
+		{"rules:\n- name: a\n  contains: x\n- name: a\n  contains: y\n", "duplicate rule a"},
+		{"rules:\n- name: a\n", "needs contains, not_match or command"},
+		{"rules:\n- name: a\n  command: show version\n  min: \"1\"\n", "command rule needs field"},
+		{"rules:\n- name: a\n  command: show version\n  field: VERSION\n", "needs min, max, in or match"},
+		{"rules:\n- name: a\n  not_match: \"(\"\n", "line 3"},
+		{"rules:\n- name: a\n  contians: x\n", "contians"},
+	}
+	for _, tt := range tests {
+		if _, err := loadRules(t, tt.text); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%q) = %v, want %q", tt.text, err, tt.want)
+		}
+	}
+}
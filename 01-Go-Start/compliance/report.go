@@ -0,0 +1,177 @@
+package compliance
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tucker-study/01-Go-Start/runner"
+)
+
+// HostReport는 장비 한 대의 검사 결과입니다. 접속하지 못한 장비는 Error만 있습니다.
+type HostReport struct {
+	Host   string       `json:"host"`
+	Passed bool         `json:"passed"`
+	Error  string       `json:"error,omitempty"`
+	Rules  []RuleResult `json:"rules,omitempty"`
+}
+
+// Report는 전체 검사 결과입니다.
+type Report struct {
+	Time  time.Time    `json:"time"`
+	Rules []*Rule      `json:"-"`
+	Hosts []HostReport `json:"hosts"`
+}
+
+// Collect는 결과 채널을 끝까지 읽어 Report를 만듭니다.
+// each가 nil이 아니면 결과가 도착할 때마다 호출합니다.
+func Collect(rules *Rules, in <-chan runner.Result[Result], each func(runner.Result[Result])) *Report {
+	rep := &Report{Time: time.Now(), Rules: rules.Rules}
+	for res := range in {
+		if each != nil {
+			each(res)
+		}
+		hr := HostReport{Host: res.Host.Hostname, Rules: res.Value.Rules}
+		if res.Err != nil {
+			hr.Error = res.Err.Error()
+		} else {
+			hr.Passed = res.Value.Passed()
+		}
+		rep.Hosts = append(rep.Hosts, hr)
+	}
+	return rep
+}
+
+// Failed는 통과하지 못한 장비 수입니다.
+func (r *Report) Failed() int {
+	n := 0
+	for _, h := range r.Hosts {
+		if !h.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode는 통과하지 못한 장비가 있으면 1, 아니면 0입니다.
+func (r *Report) ExitCode() int {
+	if r.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (h HostReport) count(s Status) int {
+	n := 0
+	for _, rr := range h.Rules {
+		if rr.Status == s {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTable은 장비별 요약표를 씁니다.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tRESULT\tPASS\tFAIL\tERROR")
+	for _, h := range r.Hosts {
+		result := "pass"
+		switch {
+		case h.Error != "":
+			result = "unreachable"
+		case !h.Passed:
+			result = "fail"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\n", h.Host, result, h.count(Pass), h.count(Fail), h.count(Error))
+	}
+	fmt.Fprintf(tw, "\n%d hosts, %d compliant, %d not compliant\n", len(r.Hosts), len(r.Hosts)-r.Failed(), r.Failed())
+	return tw.Flush()
+}
+
+// WriteJSON은 결과를 JSON으로 씁니다.
+func (r *Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(r)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"result": func(h HostReport, rule string) *RuleResult {
+		for i := range h.Rules {
+			if h.Rules[i].Rule == rule {
+				return &h.Rules[i]
+			}
+		}
+		return nil
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Compliance report</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+.pass { background: #d4edda; }
+.fail { background: #f8d7da; }
+.error, .unreachable { background: #fff3cd; }
+.na { color: #999; }
+</style>
+</head>
+<body>
+<h1>Compliance report</h1>
+<p>{{ .Time.Format "2006-01-02 15:04:05" }} &middot; {{ len .Hosts }} hosts, {{ .Failed }} not compliant</p>
+<table>
+<tr><th>Host</th>{{ range .Rules }}<th title="{{ .Description }}">{{ .Name }}</th>{{ end }}</tr>
+{{- range $h := .Hosts }}
+<tr><th>{{ $h.Host }}</th>
+{{- if $h.Error }}<td class="unreachable" colspan="{{ len $.Rules }}">{{ $h.Error }}</td>
+{{- else }}{{ range $.Rules }}{{ with result $h .Name }}<td class="{{ .Status }}" title="{{ .Message }}">{{ .Status }}</td>{{ else }}<td class="na">-</td>{{ end }}{{ end }}
+{{- end }}</tr>
+{{- end }}
+</table>
+<h2>Failures</h2>
+<ul>
+{{- range $h := .Hosts }}{{ range $h.Rules }}{{ if ne .Status "pass" }}
+<li><b>{{ $h.Host }}</b> {{ .Rule }} ({{ .Status }}): {{ .Message }}</li>
+{{- end }}{{ end }}{{ end }}
+</ul>
+</body>
+</html>
+`))
+
+// WriteHTML은 장비 × 규칙 표로 된 HTML 보고서를 씁니다.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlReport.Execute(w, r)
+}
+
+// Save는 확장자(.json, .html)에 맞는 형식으로 파일에 씁니다.
+func (r *Report) Save(path string) error {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		write = r.WriteJSON
+	case ".html", ".htm":
+		write = r.WriteHTML
+	default:
+		return fmt.Errorf("unknown report format: %s", path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,169 @@
+package compliance
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// 설정과 명령 출력이 규칙을 지키는지 검사하는 패키지입니다. 규칙은 YAML로 적습니다.
+//
+//	rules:
+//	- name: ntp-server
+//	  contains: ntp server 192.0.2.1            # 최상위에 이 줄이 있어야 함
+//	- name: vty-ssh-only
+//	  section: ^line vty                        # 맞는 섹션마다 검사 (목록이면 하위 섹션)
+//	  contains: transport input ssh
+//	- name: no-http-server
+//	  not_match: ^ip http (secure-)?server      # 이 정규식에 맞는 줄이 없어야 함
+//	- name: ios-version
+//	  platforms: [cisco_iosxe]
+//	  command: show version                     # template이 없으면 내장 템플릿으로 파싱
+//	  field: VERSION
+//	  min: "17.3"                               # 버전 비교 (숫자 부분은 숫자로 비교)
+//	  max: "17.12.99"
+
+// Patterns는 정규식 하나 또는 목록입니다.
+type Patterns []*regexp.Regexp
+
+func (p *Patterns) UnmarshalYAML(node *yaml.Node) error {
+	var list []string
+	if node.Kind == yaml.ScalarNode {
+		list = []string{node.Value}
+	} else if err := node.Decode(&list); err != nil {
+		return err
+	}
+	for _, s := range list {
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*p = append(*p, re)
+	}
+	return nil
+}
+
+func (p Patterns) MarshalYAML() (interface{}, error) {
+	var list []string
+	for _, re := range p {
+		list = append(list, re.String())
+	}
+	return list, nil
+}
+
+// Rule은 규칙 하나입니다. 설정 규칙(Contains, NotMatch)과 명령 출력 규칙(Command, Field)이 있습니다.
+type Rule struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+
+	// Platforms, Groups가 있으면 맞는 장비에만 적용합니다.
+	Platforms []string `yaml:"platforms,omitempty"`
+	Groups    []string `yaml:"groups,omitempty"`
+
+	// Section은 검사할 섹션 줄입니다. 비어 있으면 최상위입니다.
+	// 목록이면 차례로 하위 섹션입니다. (예: [^router bgp, ^address-family ipv4])
+	Section Patterns `yaml:"section,omitempty"`
+	// Contains는 섹션 바로 아래에 있어야 하는 줄입니다.
+	Contains string `yaml:"contains,omitempty"`
+	// NotMatch에 맞는 줄은 섹션 아래 어디에도 없어야 합니다.
+	NotMatch *Pattern `yaml:"not_match,omitempty"`
+
+	// Command의 출력을 Template으로 파싱해 Field 값을 검사합니다. 레코드가 여러 개면 모두 통과해야 합니다.
+	// Template이 비어 있으면 장비 플랫폼과 명령에 맞는 내장 템플릿(textfsm 패키지)을 씁니다.
+	Command  string   `yaml:"command,omitempty"`
+	Template string   `yaml:"template,omitempty"`
+	Field    string   `yaml:"field,omitempty"`
+	Min      string   `yaml:"min,omitempty"`
+	Max      string   `yaml:"max,omitempty"`
+	In       []string `yaml:"in,omitempty"`
+	Match    *Pattern `yaml:"match,omitempty"`
+}
+
+// Pattern은 YAML에서 읽는 정규식 하나입니다.
+type Pattern struct {
+	*regexp.Regexp
+}
+
+func (p *Pattern) UnmarshalYAML(node *yaml.Node) error {
+	re, err := regexp.Compile(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	p.Regexp = re
+	return nil
+}
+
+func (p Pattern) MarshalYAML() (interface{}, error) {
+	return p.String(), nil
+}
+
+// isCommand는 명령 출력 규칙인지 확인합니다.
+func (r *Rule) isCommand() bool {
+	return r.Command != ""
+}
+
+// Applies는 규칙이 장비에 적용되는지 확인합니다.
+func (r *Rule) Applies(h inventory.Router) bool {
+	if len(r.Platforms) > 0 && !slices.Contains(r.Platforms, h.Platform) {
+		return false
+	}
+	if len(r.Groups) > 0 && !slices.ContainsFunc(r.Groups, func(g string) bool { return slices.Contains(h.Groups, g) }) {
+		return false
+	}
+	return true
+}
+
+func (r *Rule) validate() error {
+	switch {
+	case r.Name == "":
+		return fmt.Errorf("rule without name")
+	case r.isCommand() && r.Field == "":
+		return fmt.Errorf("rule %s: command rule needs field", r.Name)
+	case r.isCommand() && r.Min == "" && r.Max == "" && r.In == nil && r.Match == nil:
+		return fmt.Errorf("rule %s: command rule needs min, max, in or match", r.Name)
+	case !r.isCommand() && r.Contains == "" && r.NotMatch == nil:
+		return fmt.Errorf("rule %s: needs contains, not_match or command", r.Name)
+	}
+	return nil
+}
+
+// Rules는 규칙 파일입니다.
+type Rules struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Load는 규칙 파일을 읽습니다. 상대 경로의 template은 규칙 파일 기준으로 찾습니다.
+func Load(path string) (*Rules, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs Rules
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&rs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for _, r := range rs.Rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if seen[r.Name] {
+			return nil, fmt.Errorf("%s: duplicate rule %s", path, r.Name)
+		}
+		seen[r.Name] = true
+		if r.Template != "" && !filepath.IsAbs(r.Template) {
+			r.Template = filepath.Join(filepath.Dir(path), r.Template)
+		}
+	}
+	return &rs, nil
+}
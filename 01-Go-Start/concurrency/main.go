@@ -1,117 +1,119 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
 	"os"
-	"sync"
-
-	"github.com/scrapli/scrapligo/driver/options"
-	"github.com/scrapli/scrapligo/platform"
-	"gopkg.in/yaml.v2"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
 )
 
-type Router struct {
-	Hostname  string `yaml:"hostname"`
-	Platform  string `yaml:"platform"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	StrictKey bool   `yaml:"strictkey"`
-}
-
-type Inventory struct {
-	Routers []Router `yaml:"router"`
-}
-
-func getVersion(r Router, out chan data, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	p, err := platform.NewPlatform(
-		r.Platform,
-		r.Hostname,
-		options.WithAuthNoStrictKey(),
-		options.WithAuthUsername(r.Username),
-		options.WithAuthPassword(r.Password),
-		options.WithSSHConfigFile("ssh_config"),
-	)
-
+func getVersion(ctx context.Context, r inventory.Router) (data, error) {
+	s, err := device.Open(ctx, r)
 	if err != nil {
-		fmt.Printf("failed to create platform for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
+	defer s.Close()
 
-	d, err := p.GetNetworkDriver()
+	rs, err := s.Send("show version")
 	if err != nil {
-		fmt.Printf("failed to create driver for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
 
-	err = d.Open()
+	// 파싱에 실패해도 원본 출력은 결과에 남깁니다.
+	out := data{host: r.Hostname, Output: runner.Output{Raw: rs.Result}}
+	out.Parsed, err = s.Parse(rs)
 	if err != nil {
-		fmt.Printf("failed to open driver for %s: %+v\n", r.Hostname, err)
-		return
+		return out, err
 	}
-	defer d.Close()
 
-	rs, err := d.SendCommand("show version")
+	out.info, err = textfsm.DecodeOne[textfsm.VersionInfo](out.Parsed)
 	if err != nil {
-		fmt.Printf("failed to send command for %s: %+v\n", r.Hostname, err)
-		return
+		return out, runner.Fail(device.StageParse, err)
 	}
-
-	parsedOut, err := rs.TextFsmParse(r.Platform + "_show_version.textfsm")
-	if err != nil {
-		fmt.Printf("failed to parse command for %s: %+v\n", r.Hostname, err)
-		return
-	}
-
-	out <- data{
-		host:    r.Hostname,
-		hw:      fmt.Sprintf("%s", parsedOut[0]["HARDWARE"]),
-		version: fmt.Sprintf("%s", parsedOut[0]["VERSION"]),
-		uptime:  fmt.Sprintf("%s", parsedOut[0]["UPTIME"]),
-	}
-
+	return out, nil
 }
 
 type data struct {
-	host    string
-	hw      string
-	version string
-	uptime  string
+	host string
+	info textfsm.VersionInfo
+
+	runner.Output
 }
 
-func printer(in chan data) {
-	for out := range in {
-		fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
-			out.host, out.hw, out.version, out.uptime)
+func printResult(res runner.Result[data]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n\n", res.Host.Hostname, res.Err)
+		return
 	}
+	out := res.Value
+	fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
+		out.host, strings.Join(out.info.Hardware, ", "), out.info.Version, out.info.Uptime)
 }
 
 func main() {
-	src, err := os.Open("input.yml")
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	repeat := flag.Int("repeat", 1, "run show version this many times per host")
+	usePool := flag.Bool("pool", false, "reuse logged-in sessions across jobs instead of logging in for every job")
+	var poolOpts device.PoolOptions
+	poolOpts.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	flag.Parse()
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// 실패한 로그인과 명령은 -retries번까지 다시 시도하고, 새 로그인은 모든 장비를 합쳐 초당 -login-rate번으로 제한합니다.
+	devFlags.Retry.OnRetry = func(r inventory.Router, attempt int, err error, delay time.Duration) {
+		log.Printf("%s: attempt %d failed (%s): %v, retrying in %s", r.Hostname, attempt, device.Classify(err), err, delay.Round(time.Millisecond))
+	}
+	ctx, err := devFlags.Context(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer src.Close()
-
-	d := yaml.NewDecoder(src)
 
-	var inv Inventory
-	err = d.Decode(&inv)
+	hosts, err := invFlags.Hosts(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
+	hosts = slices.Repeat(hosts, max(*repeat, 1))
 
-	ch := make(chan data)
+	// -pool이면 같은 장비에 대한 Job들이 로그인한 세션을 돌려 씁니다. getVersion은 그대로입니다.
+	var pool *device.Pool
+	if *usePool {
+		pool = device.NewPool(poolOpts)
+		ctx = device.WithPool(ctx, pool)
+	}
 
-	go printer(ch)
+	// 동시에 최대 -workers개의 세션만 열고, 실패한 장비도 결과에 남깁니다.
+	rep := runner.Collect(runner.Run(ctx, hosts, device.Retry(getVersion), runOpts), printResult)
 
-	var wg sync.WaitGroup
-	for _, v := range inv.Routers {
-		wg.Add(1)
-		go getVersion(v, ch, &wg)
+	rep.WriteTable(os.Stdout)
+	if pool != nil {
+		pool.Close()
+		st := pool.Stats()
+		fmt.Printf("sessions: %d opened, %d reused, %d discarded, %d expired\n", st.Opened, st.Reused, st.Discarded, st.Expired)
 	}
-
-	wg.Wait()
-	close(ch)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(rep.ExitCode())
 }
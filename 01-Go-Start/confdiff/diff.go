@@ -0,0 +1,248 @@
+package confdiff
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Kind는 바뀐 종류입니다.
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change는 섹션 안에서 바뀐 줄(stanza) 하나입니다.
+type Change struct {
+	Kind Kind `json:"kind"`
+	// Section은 바뀐 줄이 속한 상위 섹션 줄들입니다. 최상위면 비어 있습니다.
+	Section []string `json:"section,omitempty"`
+	Old     string   `json:"old,omitempty"`
+	New     string   `json:"new,omitempty"`
+	// Children은 추가/삭제된 섹션의 하위 줄들입니다. (한 단계마다 한 칸 들여쓰기)
+	Children []string `json:"children,omitempty"`
+}
+
+// Diff는 두 설정의 차이입니다.
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Options는 비교 방법을 정합니다.
+type Options struct {
+	// Ignore에 맞는 줄은 비교하지 않습니다. nil이면 DefaultIgnore를 씁니다.
+	Ignore []*regexp.Regexp
+}
+
+type ignoreValue struct{ o *Options }
+
+func (v ignoreValue) String() string {
+	if v.o == nil {
+		return ""
+	}
+	var s []string
+	for _, re := range v.o.Ignore {
+		s = append(s, re.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+// Set은 DefaultIgnore에 정규식을 더합니다.
+func (v ignoreValue) Set(s string) error {
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return err
+	}
+	if v.o.Ignore == nil {
+		v.o.Ignore = append([]*regexp.Regexp{}, DefaultIgnore...)
+	}
+	v.o.Ignore = append(v.o.Ignore, re)
+	return nil
+}
+
+// Register는 Options를 명령행 플래그로 등록합니다.
+func (o *Options) Register(fs *flag.FlagSet) {
+	fs.Var(ignoreValue{o}, "ignore", "also ignore lines matching this regexp (repeatable)")
+}
+
+func (o Options) ignore() []*regexp.Regexp {
+	if o.Ignore == nil {
+		return DefaultIgnore
+	}
+	return o.Ignore
+}
+
+// Compare는 두 설정을 섹션 단위로 비교합니다. 줄 순서와 들여쓰기 폭의 차이는 무시합니다.
+func Compare(old, new string, opts Options) *Diff {
+	d := &Diff{Changes: []Change{}}
+	d.compare(nil, Parse(old, opts.ignore()), Parse(new, opts.ignore()))
+	return d
+}
+
+// CompareTrees는 이미 파싱한 두 트리를 비교합니다.
+func CompareTrees(old, new *Node) *Diff {
+	d := &Diff{Changes: []Change{}}
+	d.compare(nil, old, new)
+	return d
+}
+
+func (d *Diff) compare(section []string, a, b *Node) {
+	var removed, added []*Node
+	for _, c := range a.Children {
+		if b.Child(c.Text) == nil {
+			removed = append(removed, c)
+		}
+	}
+	for _, c := range b.Children {
+		other := a.Child(c.Text)
+		if other == nil {
+			added = append(added, c)
+			continue
+		}
+		d.compare(append(section[:len(section):len(section)], c.Text), other, c)
+	}
+
+	// 값만 바뀐 줄(예: "hostname r1" → "hostname r2")은 삭제+추가 대신 변경으로 보여줍니다.
+	// 앞의 단어가 같은 줄이 양쪽에 하나씩만 있을 때 짝을 짓되, 긴 key부터 맞춰 봅니다.
+	// 섹션 줄(예: "interface Gi2" → "interface Gi3")과 no 줄은 다른 대상이므로 짝을 짓지 않고,
+	// 대상을 가리키는 단어까지 같아야 하는 줄(neighbor, ip route)은 그 단어 수보다 짧게 맞추지 않습니다.
+	changed, paired := map[*Node]*Node{}, map[*Node]bool{}
+	for words := maxKeyWords; words >= 1; words-- {
+		pair(removed, added, words, changed, paired)
+	}
+
+	for _, c := range removed {
+		if n, ok := changed[c]; ok {
+			d.Changes = append(d.Changes, Change{Kind: Changed, Section: section, Old: c.Text, New: n.Text})
+			continue
+		}
+		d.Changes = append(d.Changes, Change{Kind: Removed, Section: section, Old: c.Text, Children: c.Lines(1)})
+	}
+	for _, c := range added {
+		if paired[c] {
+			continue
+		}
+		d.Changes = append(d.Changes, Change{Kind: Added, Section: section, New: c.Text, Children: c.Lines(1)})
+	}
+}
+
+// maxKeyWords는 짝을 지을 때 맞춰 보는 가장 긴 key의 단어 수입니다.
+const maxKeyWords = 4
+
+// keyWords는 줄이 가리키는 대상을 정하는 앞 단어 수입니다. 이보다 짧은 key로는 짝을 짓지 않습니다.
+// 0이면 짝을 짓지 않습니다.
+//
+//	neighbor 10.0.0.1 remote-as 65001        neighbor 10.0.0.1 remote-as
+//	ip route 10.0.0.0 255.0.0.0 192.0.2.1    ip route 10.0.0.0 255.0.0.0
+func keyWords(f []string) int {
+	switch {
+	case f[0] == "no":
+		return 0
+	case f[0] == "neighbor":
+		return 3
+	case len(f) > 1 && f[0] == "ip" && f[1] == "route":
+		return 4
+	case len(f) > 1 && f[0] == "ipv6" && f[1] == "route":
+		return 3
+	}
+	return 1
+}
+
+// pair는 앞의 words 단어가 같은 삭제/추가 줄이 하나씩뿐이면 짝을 지어 changed에 넣습니다.
+func pair(removed, added []*Node, words int, changed map[*Node]*Node, paired map[*Node]bool) {
+	key := func(text string) string {
+		f := strings.Fields(text)
+		if len(f) <= words {
+			return ""
+		}
+		if n := keyWords(f); n == 0 || words < n {
+			return ""
+		}
+		return strings.Join(f[:words], " ")
+	}
+	count := func(nodes []*Node) map[string][]*Node {
+		m := map[string][]*Node{}
+		for _, n := range nodes {
+			if _, ok := changed[n]; ok || paired[n] || len(n.Children) > 0 {
+				continue
+			}
+			if k := key(n.Text); k != "" {
+				m[k] = append(m[k], n)
+			}
+		}
+		return m
+	}
+
+	rm, ad := count(removed), count(added)
+	for k, r := range rm {
+		if a := ad[k]; len(r) == 1 && len(a) == 1 {
+			changed[r[0]] = a[0]
+			paired[a[0]] = true
+		}
+	}
+}
+
+// Empty는 차이가 없는지 확인합니다.
+func (d *Diff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// WriteText는 섹션별로 묶어 사람이 읽을 형태로 씁니다.
+//
+//	interface GigabitEthernet1
+//	  - shutdown
+//	  ~ description old
+//	    => description new
+//	(top)
+//	  + ntp server 192.0.2.1
+func (d *Diff) WriteText(w io.Writer) error {
+	last := "\x00"
+	for _, c := range d.Changes {
+		if s := strings.Join(c.Section, " / "); s != last {
+			last = s
+			if s == "" {
+				s = "(top)"
+			}
+			if _, err := fmt.Fprintln(w, s); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch c.Kind {
+		case Added:
+			_, err = fmt.Fprintf(w, "  + %s\n", c.New)
+		case Removed:
+			_, err = fmt.Fprintf(w, "  - %s\n", c.Old)
+		case Changed:
+			_, err = fmt.Fprintf(w, "  ~ %s\n    => %s\n", c.Old, c.New)
+		}
+		if err != nil {
+			return err
+		}
+
+		sign := "+"
+		if c.Kind == Removed {
+			sign = "-"
+		}
+		for _, l := range c.Children {
+			if _, err := fmt.Fprintf(w, "  %s %s\n", sign, l); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON은 차이를 JSON으로 씁니다.
+func (d *Diff) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(d)
+}
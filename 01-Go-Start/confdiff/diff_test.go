@@ -0,0 +1,134 @@
+package confdiff
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// changes는 Diff를 "kind section: old => new" 문자열로 바꿔 비교하기 쉽게 합니다.
+func changes(d *Diff) []string {
+	var out []string
+	for _, c := range d.Changes {
+		s := string(c.Kind) + " " + strings.Join(c.Section, " / ") + ": "
+		switch c.Kind {
+		case Added:
+			s += c.New
+		case Removed:
+			s += c.Old
+		case Changed:
+			s += c.Old + " => " + c.New
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{
+			name: "value changed",
+			old:  "hostname r1\n",
+			new:  "hostname r2\n",
+			want: []string{"changed : hostname r1 => hostname r2"},
+		},
+		{
+			name: "order and indentation ignored",
+			old:  "interface Gi1\n description a\n shutdown\nhostname r1\n",
+			new:  "hostname r1\ninterface Gi1\n    shutdown\n    description a\n",
+		},
+		{
+			name: "ignored lines",
+			old:  "Building configuration...\nntp clock-period 1\nhostname r1\n",
+			new:  "ntp clock-period 2\nhostname r1\n",
+		},
+		{
+			name: "line in section",
+			old:  "interface Gi1\n description old\n ip address 10.0.0.1 255.255.255.0\n",
+			new:  "interface Gi1\n description new uplink\n ip address 10.0.0.2 255.255.255.0\n",
+			want: []string{
+				"changed interface Gi1: description old => description new uplink",
+				"changed interface Gi1: ip address 10.0.0.1 255.255.255.0 => ip address 10.0.0.2 255.255.255.0",
+			},
+		},
+		{
+			name: "section renamed",
+			old:  "interface Gi2\n shutdown\n",
+			new:  "interface Gi3\n shutdown\n",
+			want: []string{"removed : interface Gi2", "added : interface Gi3"},
+		},
+		{
+			// 다른 이웃은 같은 이웃의 값이 바뀐 것이 아닙니다.
+			name: "different neighbor",
+			old:  "router bgp 1\n neighbor 10.0.0.1 remote-as 1\n",
+			new:  "router bgp 1\n neighbor 10.0.0.2 remote-as 2\n",
+			want: []string{
+				"removed router bgp 1: neighbor 10.0.0.1 remote-as 1",
+				"added router bgp 1: neighbor 10.0.0.2 remote-as 2",
+			},
+		},
+		{
+			name: "same neighbor",
+			old:  "router bgp 1\n neighbor 10.0.0.1 remote-as 1\n",
+			new:  "router bgp 1\n neighbor 10.0.0.1 remote-as 2\n",
+			want: []string{"changed router bgp 1: neighbor 10.0.0.1 remote-as 1 => neighbor 10.0.0.1 remote-as 2"},
+		},
+		{
+			name: "different static route",
+			old:  "ip route 10.0.0.0 255.0.0.0 192.0.2.1\n",
+			new:  "ip route 10.1.0.0 255.255.0.0 192.0.2.1\n",
+			want: []string{
+				"removed : ip route 10.0.0.0 255.0.0.0 192.0.2.1",
+				"added : ip route 10.1.0.0 255.255.0.0 192.0.2.1",
+			},
+		},
+		{
+			name: "next hop changed",
+			old:  "ip route 10.0.0.0 255.0.0.0 192.0.2.1\n",
+			new:  "ip route 10.0.0.0 255.0.0.0 192.0.2.2\n",
+			want: []string{"changed : ip route 10.0.0.0 255.0.0.0 192.0.2.1 => ip route 10.0.0.0 255.0.0.0 192.0.2.2"},
+		},
+		{
+			name: "no lines",
+			old:  "no ip domain-lookup\n",
+			new:  "no ip http server\n",
+			want: []string{"removed : no ip domain-lookup", "added : no ip http server"},
+		},
+		{
+			// 첫 단어가 같은 줄이 여러 개면 어느 것이 바뀌었는지 알 수 없으므로 짝을 짓지 않습니다.
+			name: "ambiguous",
+			old:  "logging host 192.0.2.1\nlogging host 192.0.2.2\n",
+			new:  "logging host 192.0.2.3\nlogging host 192.0.2.4\n",
+			want: []string{
+				"removed : logging host 192.0.2.1",
+				"removed : logging host 192.0.2.2",
+				"added : logging host 192.0.2.3",
+				"added : logging host 192.0.2.4",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changes(Compare(tt.old, tt.new, Options{}))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	d := Compare("interface Gi1\n shutdown\n", "interface Gi1\n description uplink\ninterface Gi2\n shutdown\n", Options{})
+	var b strings.Builder
+	if err := d.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := "interface Gi1\n  - shutdown\n  + description uplink\n(top)\n  + interface Gi2\n  +  shutdown\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,114 @@
+package confdiff
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultIgnore는 설정이 바뀌지 않아도 달라지는 줄입니다. (시각, 크기, NTP가 계속 고치는 값)
+var DefaultIgnore = []*regexp.Regexp{
+	regexp.MustCompile(`^Building configuration\.\.\.`),
+	regexp.MustCompile(`^Current configuration : \d+ bytes`),
+	regexp.MustCompile(`^! ?(Last configuration change|NVRAM config last updated) at `),
+	regexp.MustCompile(`^! ?Time: `),
+	regexp.MustCompile(`^ntp clock-period `),
+}
+
+// Ignored는 줄(앞뒤 공백 제외)이 ignore 중 하나에 맞는지 확인합니다.
+func Ignored(line string, ignore []*regexp.Regexp) bool {
+	for _, re := range ignore {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Node는 들여쓰기로 표현된 설정의 한 줄과 그 하위 줄들입니다.
+//
+//	interface GigabitEthernet1        Node{Text: "interface GigabitEthernet1"}
+//	 description uplink                 └ Children[0]
+//	 ip address 10.0.0.1 255.255.255.0  └ Children[1]
+type Node struct {
+	Text     string
+	Children []*Node
+
+	index map[string]*Node
+}
+
+// Child는 Text가 text인 하위 줄을 찾습니다.
+func (n *Node) Child(text string) *Node {
+	if len(n.index) != len(n.Children) {
+		n.index = make(map[string]*Node, len(n.Children))
+		for _, c := range n.Children {
+			n.index[c.Text] = c
+		}
+	}
+	return n.index[text]
+}
+
+func (n *Node) add(text string) *Node {
+	c := &Node{Text: text}
+	n.Children = append(n.Children, c)
+	if n.index != nil {
+		n.index[text] = c
+	}
+	return c
+}
+
+// Add는 Text가 text인 하위 줄을 찾고, 없으면 끝에 추가합니다.
+func (n *Node) Add(text string) *Node {
+	if c := n.Child(text); c != nil {
+		return c
+	}
+	return n.add(text)
+}
+
+// RemoveFunc는 del이 true를 돌려주는 하위 줄(과 그 아래 줄들)을 지웁니다.
+func (n *Node) RemoveFunc(del func(*Node) bool) {
+	n.Children = slices.DeleteFunc(n.Children, del)
+	n.index = nil
+}
+
+// Lines는 하위 줄들을 depth만큼 한 칸씩 들여써서 돌려줍니다.
+func (n *Node) Lines(depth int) []string {
+	var lines []string
+	for _, c := range n.Children {
+		lines = append(lines, strings.Repeat(" ", depth)+c.Text)
+		lines = append(lines, c.Lines(depth+1)...)
+	}
+	return lines
+}
+
+// Parse는 Cisco/Arista 스타일 설정을 트리로 바꿉니다. 돌려주는 Node는 Text가 빈 최상위입니다.
+// 빈 줄, "!" 주석, "end", ignore에 맞는 줄은 건너뜁니다. 같은 섹션에 같은 줄이 여러 번 나오면
+// 하나로 합칩니다.
+func Parse(config string, ignore []*regexp.Regexp) *Node {
+	type frame struct {
+		indent int
+		node   *Node
+	}
+	root := &Node{}
+	stack := []frame{{-1, root}}
+
+	for _, raw := range strings.Split(config, "\n") {
+		raw = strings.TrimRight(raw, " \t\r")
+		text := strings.TrimLeft(raw, " \t")
+		if text == "" || strings.HasPrefix(text, "!") || text == "end" || Ignored(text, ignore) {
+			continue
+		}
+		indent := len(raw) - len(text)
+
+		for stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		n := parent.Child(text)
+		if n == nil {
+			n = parent.add(text)
+		}
+		stack = append(stack, frame{indent, n})
+	}
+	return root
+}
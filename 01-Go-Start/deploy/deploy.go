@@ -0,0 +1,245 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/render"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 후보 설정(candidate config)을 장비에 밀어 넣는 패키지입니다.
+//
+//  1. running config를 가져와 후보 설정과 비교합니다. (DryRun이면 여기서 끝)
+//  2. 체크포인트를 만들고 설정을 보냅니다. 장비가 거부한 줄이 있으면 바로 되돌립니다.
+//     체크포인트를 지원하지 않는 플랫폼(ErrNoCheckpoint)에는 보내지 않습니다.
+//  3. 새 세션으로 다시 접속해 사후 점검(Check)을 하고, 실패하면 되돌립니다. (commit confirmed 방식)
+const (
+	StageCheckpoint runner.Stage = "checkpoint"
+	StageCheck      runner.Stage = "check"
+	StageRollback   runner.Stage = "rollback"
+)
+
+// DefaultConfirm은 Options.Confirm을 지정하지 않았을 때 사후 점검에 주는 시간입니다.
+const DefaultConfirm = 2 * time.Minute
+
+// Check는 설정을 보낸 뒤 실행하는 사후 점검 명령입니다.
+// 명령이 성공하고, Expect가 있으면 출력이 Expect에 맞아야 통과입니다.
+type Check struct {
+	Command string
+	Expect  *regexp.Regexp
+}
+
+// ParseCheck는 "명령" 또는 "명령 ~ 정규식" 형태의 문자열을 Check로 바꿉니다.
+//
+//	show ip bgp summary ~ Established
+func ParseCheck(s string) (Check, error) {
+	cmd, expect, ok := strings.Cut(s, " ~ ")
+	c := Check{Command: strings.TrimSpace(cmd)}
+	if c.Command == "" {
+		return c, fmt.Errorf("empty check command: %q", s)
+	}
+	if ok {
+		re, err := regexp.Compile(strings.TrimSpace(expect))
+		if err != nil {
+			return c, fmt.Errorf("check %q: %w", s, err)
+		}
+		c.Expect = re
+	}
+	return c, nil
+}
+
+func (c Check) String() string {
+	if c.Expect == nil {
+		return c.Command
+	}
+	return c.Command + " ~ " + c.Expect.String()
+}
+
+// run은 점검 명령을 실행해 통과하지 못하면 에러를 돌려줍니다.
+func (c Check) run(s *device.Session) (string, error) {
+	rs, err := s.Send(c.Command)
+	if err != nil {
+		return "", err
+	}
+	if c.Expect != nil && !c.Expect.MatchString(rs.Result) {
+		return rs.Result, fmt.Errorf("%q: output does not match %q", c.Command, c.Expect)
+	}
+	return rs.Result, nil
+}
+
+// Source는 장비마다 보낼 후보 설정을 돌려줍니다.
+type Source func(r inventory.Router) (string, error)
+
+// File은 모든 장비에 같은 설정 파일을 보냅니다.
+func File(path string) Source {
+	return func(inventory.Router) (string, error) {
+		b, err := os.ReadFile(path)
+		return string(b), err
+	}
+}
+
+// Dir은 장비마다 dir/<hostname>.cfg 파일을 보냅니다.
+func Dir(dir string) Source {
+	return func(r inventory.Router) (string, error) {
+		b, err := os.ReadFile(filepath.Join(dir, r.Hostname+".cfg"))
+		return string(b), err
+	}
+}
+
+// Template은 장비마다 템플릿으로 렌더링한 설정을 보냅니다.
+func Template(r *render.Renderer) Source {
+	return r.Render
+}
+
+// Options는 Push의 동작을 정합니다.
+type Options struct {
+	// DryRun이 true이면 running config와의 차이만 보여주고 설정은 바꾸지 않습니다.
+	DryRun bool
+	// Checks는 설정을 보낸 뒤 실행할 사후 점검입니다.
+	Checks []Check
+	// Confirm은 사후 점검(재접속 포함)에 주는 시간입니다. 0이면 DefaultConfirm입니다.
+	Confirm time.Duration
+}
+
+// Result는 장비 한 대에 대한 push 결과입니다.
+// Raw에는 차이(diff)와 장비 응답이 남습니다.
+type Result struct {
+	Diff       string
+	Applied    bool
+	RolledBack bool
+
+	runner.Output
+}
+
+// Push는 장비마다 src의 설정을 보내는 Job을 만듭니다.
+// ctx가 끝나도 설정을 보낸 장비는 되돌린 뒤 돌아오므로, runner.Options.Grace 없이 실행해 결과를 기다려야 합니다.
+func Push(src Source, opts Options) runner.Job[Result] {
+	return func(ctx context.Context, r inventory.Router) (Result, error) {
+		var res Result
+
+		candidate, err := src(r)
+		if err != nil {
+			return res, err
+		}
+		// 되돌릴 방법이 없는 장비에는 접속하기 전에 거절합니다.
+		rb, err := newRollback(r.Platform)
+		if err != nil && !opts.DryRun {
+			return res, runner.Fail(StageCheckpoint, err)
+		}
+
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return res, err
+		}
+		defer s.Close()
+
+		running, err := s.RunningConfig()
+		if err != nil {
+			return res, err
+		}
+
+		changes := Missing(running, candidate)
+		res.Diff = FormatDiff(changes)
+		res.Raw = res.Diff
+		if opts.DryRun || len(changes) == 0 {
+			return res, nil
+		}
+
+		if err := rb.save(s); err != nil {
+			return res, runner.Fail(StageCheckpoint, err)
+		}
+
+		mr, err := s.Configure(configLines(candidate))
+		if mr != nil {
+			for _, rs := range mr.Responses {
+				res.Raw += rs.Input + "\n" + rs.Result + "\n"
+			}
+		}
+		if err != nil {
+			return res, res.rollback(ctx, s, rb, err)
+		}
+		res.Applied = true
+
+		if err := confirm(ctx, r, opts, &res); err != nil {
+			return res, res.rollback(ctx, s, rb, err)
+		}
+
+		// 체크포인트를 지우지 못해도 설정은 이미 적용되었으므로 실패로 보지 않습니다.
+		rb.discard(s)
+		return res, nil
+	}
+}
+
+// configLines는 설정 파일에서 장비에 보낼 줄을 고릅니다. 들여쓰기는 그대로 둡니다.
+func configLines(config string) []string {
+	var lines []string
+	for _, l := range strings.Split(config, "\n") {
+		l = strings.TrimRight(l, " \t\r")
+		text := strings.TrimSpace(l)
+		if text == "" || strings.HasPrefix(text, "!") || text == "end" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// confirm은 새 세션으로 다시 접속해 사후 점검을 실행합니다.
+// 설정 때문에 접속이 끊기거나 로그인이 안 되는 경우도 여기서 실패합니다.
+func confirm(ctx context.Context, r inventory.Router, opts Options, res *Result) error {
+	if len(opts.Checks) == 0 {
+		return nil
+	}
+	timeout := opts.Confirm
+	if timeout <= 0 {
+		timeout = DefaultConfirm
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	s, err := device.Open(ctx, r)
+	if err != nil {
+		return runner.Fail(StageCheck, err)
+	}
+	defer s.Close()
+
+	for _, c := range opts.Checks {
+		out, err := c.run(s)
+		res.Raw += "### " + c.Command + "\n" + out + "\n"
+		if err != nil {
+			return runner.Fail(StageCheck, err)
+		}
+	}
+	return nil
+}
+
+// rollback은 push 전 상태로 되돌리고, 원래 에러에 되돌린 결과를 붙여 돌려줍니다.
+// ctx가 끝났으면 원래 세션은 이미 닫혔으므로 ctx와 상관없이 새로 접속해 되돌립니다.
+func (res *Result) rollback(ctx context.Context, s *device.Session, rb *rollback, cause error) error {
+	if ctx.Err() != nil {
+		rctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), DefaultConfirm)
+		defer cancel()
+
+		var err error
+		s, err = device.Open(rctx, s.Host)
+		if err != nil {
+			return runner.Fail(StageRollback, errors.Join(err, cause))
+		}
+		defer s.Close()
+	}
+
+	if err := rb.restore(s); err != nil {
+		return runner.Fail(StageRollback, errors.Join(err, cause))
+	}
+	res.RolledBack = true
+	return fmt.Errorf("rolled back: %w", cause)
+}
@@ -0,0 +1,166 @@
+package deploy_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/deploy"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+const ntpLine = "ntp server 192.0.2.123"
+
+func source(config string) deploy.Source {
+	return func(inventory.Router) (string, error) { return config, nil }
+}
+
+func mustCheck(t *testing.T, s string) deploy.Check {
+	t.Helper()
+	c, err := deploy.ParseCheck(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestPush(t *testing.T) {
+	tests := []struct {
+		name   string
+		reject string
+		checks []string
+		dryRun bool
+
+		wantApplied    bool
+		wantRolledBack bool
+		wantStage      runner.Stage
+		wantConfig     bool // push 뒤 running config에 ntpLine이 있어야 하는지
+	}{
+		{name: "apply", wantApplied: true, wantConfig: true},
+		{name: "dry run", dryRun: true},
+		{name: "check passes", checks: []string{"show version ~ Version"}, wantApplied: true, wantConfig: true},
+		// 장비가 설정 줄을 거부하면 보내지 못했으므로 Applied는 false이고 바로 되돌립니다.
+		{name: "rejected", reject: "^ntp server", wantRolledBack: true, wantStage: device.StageConfig},
+		{name: "check fails", checks: []string{"show version ~ no-such-version"},
+			wantApplied: true, wantRolledBack: true, wantStage: deploy.StageCheck},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"}
+			if tt.reject != "" {
+				d.Reject = []*regexp.Regexp{regexp.MustCompile(tt.reject)}
+			}
+			srv, err := fakedevice.Start(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			before := srv.RunningConfig()
+
+			opts := deploy.Options{DryRun: tt.dryRun}
+			for _, c := range tt.checks {
+				opts.Checks = append(opts.Checks, mustCheck(t, c))
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			res, err := deploy.Push(source(ntpLine+"\n"), opts)(ctx, srv.Router())
+			if tt.wantStage == "" && err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantStage != "" {
+				if err == nil {
+					t.Fatal("push succeeded")
+				}
+				if stage := runner.StageOf(err); stage != tt.wantStage {
+					t.Errorf("stage = %q, want %q (%v)", stage, tt.wantStage, err)
+				}
+			}
+			if !strings.Contains(res.Diff, "+ "+ntpLine) {
+				t.Errorf("diff = %q, want the ntp line", res.Diff)
+			}
+			if res.Applied != tt.wantApplied {
+				t.Errorf("applied = %t, want %t", res.Applied, tt.wantApplied)
+			}
+			if res.RolledBack != tt.wantRolledBack {
+				t.Errorf("rolled back = %t, want %t", res.RolledBack, tt.wantRolledBack)
+			}
+
+			after := srv.RunningConfig()
+			if got := strings.Contains(after, ntpLine); got != tt.wantConfig {
+				t.Errorf("ntp line in running config = %t, want %t:\n%s", got, tt.wantConfig, after)
+			}
+			if !tt.wantConfig && after != before {
+				t.Errorf("running config changed:\n%s\nwant:\n%s", after, before)
+			}
+		})
+	}
+}
+
+func TestPushUnchanged(t *testing.T) {
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	push := deploy.Push(source(ntpLine+"\n"), deploy.Options{})
+	if _, err := push(ctx, srv.Router()); err != nil {
+		t.Fatal(err)
+	}
+	// 이미 들어간 설정을 다시 보내면 보낼 줄이 없습니다.
+	res, err := push(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Diff != "" || res.Applied {
+		t.Errorf("second push: diff = %q, applied = %t", res.Diff, res.Applied)
+	}
+}
+
+func TestPushDeadline(t *testing.T) {
+	// 장비가 느려서 Run의 Deadline이 push 도중에 지나도, Run이 끝났을 때 장비는 push 전 설정이어야 합니다.
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe", Latency: 300 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	before := srv.RunningConfig()
+
+	opts := deploy.Options{Checks: []deploy.Check{mustCheck(t, "show version")}}
+	hosts := []inventory.Router{srv.Router()}
+	job := deploy.Push(source(ntpLine+"\n"), opts)
+	for res := range runner.Run(context.Background(), hosts, job, runner.Options{Deadline: 2 * time.Second}) {
+		if res.Err == nil {
+			t.Fatal("push finished before the deadline")
+		}
+		if res.Value.Applied && !res.Value.RolledBack {
+			t.Errorf("applied but not rolled back: %v", res.Err)
+		}
+	}
+	if after := srv.RunningConfig(); after != before {
+		t.Errorf("running config changed:\n%s\nwant:\n%s", after, before)
+	}
+}
+
+func TestPushNoCheckpoint(t *testing.T) {
+	// 체크포인트가 없는 플랫폼은 되돌릴 수 없으므로 접속하기 전에 거절합니다.
+	r := inventory.Router{Hostname: "xr1", IP: inventory.MustParseMgmtAddr("192.0.2.1")}
+	r.Platform = "cisco_iosxr"
+
+	_, err := deploy.Push(source(ntpLine+"\n"), deploy.Options{})(context.Background(), r)
+	if !errors.Is(err, deploy.ErrNoCheckpoint) {
+		t.Fatalf("err = %v, want ErrNoCheckpoint", err)
+	}
+	if stage := runner.StageOf(err); stage != deploy.StageCheckpoint {
+		t.Errorf("stage = %q, want %q", stage, deploy.StageCheckpoint)
+	}
+}
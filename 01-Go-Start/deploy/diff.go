@@ -0,0 +1,58 @@
+package deploy
+
+import (
+	"strings"
+
+	"tucker-study/01-Go-Start/confdiff"
+)
+
+// Line은 들여쓰기로 표현된 설정의 한 줄입니다. Parents는 상위 섹션 줄들입니다.
+// (예: "interface Gi1" 아래의 " description uplink")
+type Line struct {
+	Parents []string
+	Text    string
+}
+
+// Missing은 후보 설정 중 running config에 아직 없는 줄을 돌려줍니다.
+// 후보 설정은 running config에 합쳐지므로(merge) 이 줄들이 실제로 바뀌는 부분입니다.
+// 섹션 단위로 비교하므로 줄 순서와 들여쓰기 폭은 상관없습니다.
+func Missing(running, candidate string) []Line {
+	var missing []Line
+	var walk func(parents []string, have, want *confdiff.Node)
+	walk = func(parents []string, have, want *confdiff.Node) {
+		for _, c := range want.Children {
+			var h *confdiff.Node
+			if have != nil {
+				h = have.Child(c.Text)
+			}
+			if h == nil {
+				missing = append(missing, Line{Parents: parents, Text: c.Text})
+			}
+			walk(append(parents[:len(parents):len(parents)], c.Text), h, c)
+		}
+	}
+	walk(nil, confdiff.Parse(running, confdiff.DefaultIgnore), confdiff.Parse(candidate, confdiff.DefaultIgnore))
+	return missing
+}
+
+// FormatDiff는 Missing의 결과를 상위 섹션을 문맥으로 붙여 보여줍니다.
+//
+//	 interface Gi1
+//	+  description uplink
+func FormatDiff(lines []Line) string {
+	var b strings.Builder
+	var shown []string
+	for _, l := range lines {
+		// 바로 앞 줄과 공유하지 않는 상위 섹션만 문맥으로 출력합니다.
+		common := 0
+		for common < len(shown) && common < len(l.Parents) && shown[common] == l.Parents[common] {
+			common++
+		}
+		for i := common; i < len(l.Parents); i++ {
+			b.WriteString("  " + strings.Repeat(" ", i) + l.Parents[i] + "\n")
+		}
+		b.WriteString("+ " + strings.Repeat(" ", len(l.Parents)) + l.Text + "\n")
+		shown = append(append([]string{}, l.Parents...), l.Text)
+	}
+	return b.String()
+}
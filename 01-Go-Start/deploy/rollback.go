@@ -0,0 +1,90 @@
+package deploy
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/scrapli/scrapligo/channel"
+	"github.com/scrapli/scrapligo/platform"
+	"tucker-study/01-Go-Start/device"
+)
+
+// checkpointCommands는 설정을 바꾸기 전 running config를 장비에 저장해 두고
+// 실패하면 그대로 되돌리는 명령들입니다. %s 자리에 체크포인트 이름이 들어갑니다.
+type checkpointCommands struct {
+	save, restore, discard string
+	// confirm이 있으면 save 뒤에 이 정규식에 맞는 확인 프롬프트가 나오므로 엔터를 보냅니다.
+	confirm string
+}
+
+var checkpoints = map[string]checkpointCommands{
+	platform.CiscoIosxe: {
+		save:    "copy running-config flash:%s",
+		restore: "configure replace flash:%s force",
+		discard: "delete /force flash:%s",
+		confirm: `\?\s*$`,
+	},
+	platform.CiscoNxos: {
+		save:    "checkpoint %s",
+		restore: "rollback running-config checkpoint %s",
+		discard: "no checkpoint %s",
+	},
+	platform.AristaEos: {
+		save:    "configure checkpoint save %s",
+		restore: "configure replace checkpoint:%s",
+		discard: "delete checkpoint:%s",
+	},
+}
+
+// ErrNoCheckpoint는 체크포인트로 되돌릴 수 없는 플랫폼입니다. (commit 방식인 iosxr, junos 등)
+// 추가한 줄을 지우는 것만으로는 바뀐 값(ip address, hostname, description 등)이 원래대로 돌아오지 않으므로
+// 이런 장비에는 설정을 보내지 않습니다. DryRun으로 차이를 보는 것은 됩니다.
+var ErrNoCheckpoint = errors.New("no configuration checkpoint to roll back to")
+
+// rollback은 push 전에 만든 체크포인트입니다.
+type rollback struct {
+	name string
+	cmds checkpointCommands
+}
+
+func newRollback(platformName string) (*rollback, error) {
+	cmds, ok := checkpoints[platformName]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", platformName, ErrNoCheckpoint)
+	}
+	return &rollback{
+		name: "push-" + time.Now().UTC().Format("20060102-150405") + ".cfg",
+		cmds: cmds,
+	}, nil
+}
+
+// save는 체크포인트를 만듭니다.
+func (rb *rollback) save(s *device.Session) error {
+	cmd := fmt.Sprintf(rb.cmds.save, rb.name)
+	if rb.cmds.confirm == "" {
+		_, err := s.Send(cmd)
+		return err
+	}
+
+	rs, err := s.SendInteractive([]*channel.SendInteractiveEvent{
+		{ChannelInput: cmd, ChannelResponse: rb.cmds.confirm},
+		{ChannelInput: ""},
+	})
+	if err != nil {
+		return err
+	}
+	return rs.Failed
+}
+
+// restore는 push 전 상태로 되돌립니다.
+func (rb *rollback) restore(s *device.Session) error {
+	_, err := s.Send(fmt.Sprintf(rb.cmds.restore, rb.name))
+	return err
+}
+
+// discard는 더 이상 필요 없는 체크포인트를 지웁니다.
+func (rb *rollback) discard(s *device.Session) error {
+	_, err := s.Send(fmt.Sprintf(rb.cmds.discard, rb.name))
+	return err
+}
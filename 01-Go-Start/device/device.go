@@ -0,0 +1,320 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scrapli/scrapligo/driver/network"
+	"github.com/scrapli/scrapligo/driver/opoptions"
+	"github.com/scrapli/scrapligo/driver/options"
+	"github.com/scrapli/scrapligo/platform"
+	"github.com/scrapli/scrapligo/response"
+	"github.com/scrapli/scrapligo/transport"
+	"github.com/scrapli/scrapligo/util"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 예제마다 반복하던 scrapligo 접속 과정(NewPlatform → GetNetworkDriver → Open → SendCommand → TextFsmParse)을
+// 모은 패키지입니다. 실패하면 어느 단계에서 실패했는지 runner.StageError로 돌려줍니다.
+const (
+	StagePlatform runner.Stage = "platform"
+	StageDriver   runner.Stage = "driver"
+	StageOpen     runner.Stage = "open"
+	StageSend     runner.Stage = "send"
+	StageParse    runner.Stage = "parse"
+	StageConfig   runner.Stage = "config"
+)
+
+// Options는 인벤토리 장비에 접속할 때 쓰는 scrapligo 옵션입니다.
+// strictkey가 true인 장비는 known_hosts(ssh_config의 UserKnownHostsFile)에 없는 호스트 키를 거부합니다.
+// ctx에 기한이 있으면 남은 시간을 소켓/명령 타임아웃으로 넘깁니다. (scrapligo는 context를 받지 않습니다)
+func Options(ctx context.Context, r inventory.Router) []util.Option {
+	opts := []util.Option{
+		options.WithAuthUsername(r.Username),
+		options.WithAuthPassword(r.Password),
+		options.WithSSHConfigFile(r.SSHConfigFile()),
+	}
+	if !r.Strict() {
+		opts = append(opts, options.WithAuthNoStrictKey())
+	}
+	if r.Port != 0 {
+		opts = append(opts, options.WithPort(r.Port))
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		opts = append(opts,
+			options.WithTimeoutSocket(time.Until(deadline)),
+			options.WithTimeoutOps(time.Until(deadline)),
+		)
+	}
+	return opts
+}
+
+// Interceptor는 scrapligo가 만든 transport를 다른 transport로 바꾸거나 감쌉니다. (세션 녹화/재생용)
+type Interceptor func(r inventory.Router, t transport.Implementation) (transport.Implementation, error)
+
+type interceptorKey struct{}
+
+// WithInterceptor는 ctx로 여는 세션마다 ic로 transport를 바꾸도록 합니다.
+// runner의 Job처럼 ctx만 넘겨받는 코드도 그대로 녹화/재생할 수 있습니다.
+func WithInterceptor(ctx context.Context, ic Interceptor) context.Context {
+	return context.WithValue(ctx, interceptorKey{}, ic)
+}
+
+// Session은 열려 있는 장비 세션입니다.
+type Session struct {
+	*network.Driver
+	Host inventory.Router
+
+	ctx   context.Context
+	close func()
+	stop  func() bool
+	// conn은 풀에서 빌린 세션입니다. 풀에서 빌리지 않았으면 nil입니다.
+	conn *pooled
+}
+
+// Open은 장비에 접속합니다. ctx가 끝나면 세션을 닫아 진행 중인 명령도 바로 중단됩니다.
+// opts는 기본 옵션 뒤에 붙으므로 기본값을 덮어쓸 수 있습니다.
+// ctx에 WithPool로 풀을 넣었으면 풀에서 세션을 빌립니다. (opts를 준 경우는 옵션이 달라지므로 빌리지 않습니다)
+func Open(ctx context.Context, r inventory.Router, opts ...util.Option) (*Session, error) {
+	if p, ok := ctx.Value(poolKey{}).(*Pool); ok && len(opts) == 0 {
+		return p.Get(ctx, r)
+	}
+
+	d, err := open(ctx, r, append(Options(ctx, r), opts...))
+	if err != nil {
+		return nil, err
+	}
+	s := &Session{Driver: d, Host: r, ctx: ctx}
+	s.close = sync.OnceFunc(func() { closeDriver(d) })
+	s.stop = context.AfterFunc(ctx, s.close)
+	return s, nil
+}
+
+// open은 scrapligo 드라이버를 만들어 로그인합니다. 로그인하는 동안 ctx가 끝나면 닫아서 멈춥니다.
+// ctx에 WithLoginLimiter, WithRetry를 넣었으면 차례를 기다려 로그인하고, 실패하면 정책에 따라 다시 로그인합니다.
+func open(ctx context.Context, r inventory.Router, opts []util.Option) (*network.Driver, error) {
+	if mode := r.ConnMode(); mode != inventory.ModeCLI {
+		return nil, runner.Fail(StageDriver, fmt.Errorf("host uses %s mode, not cli", mode))
+	}
+	var d *network.Driver
+	err := login(ctx, r, func() (err error) {
+		d, err = dial(ctx, r, opts)
+		return err
+	})
+	return d, err
+}
+
+// dial은 로그인을 한 번 시도합니다. 닫힌 드라이버는 다시 열 수 없으므로 시도할 때마다 새로 만듭니다.
+func dial(ctx context.Context, r inventory.Router, opts []util.Option) (*network.Driver, error) {
+	p, err := platform.NewPlatform(r.Platform, r.Address(), opts...)
+	if err != nil {
+		return nil, runner.Fail(StagePlatform, err)
+	}
+
+	d, err := p.GetNetworkDriver()
+	if err != nil {
+		return nil, runner.Fail(StageDriver, err)
+	}
+	if ic, ok := ctx.Value(interceptorKey{}).(Interceptor); ok {
+		if d.Transport.Impl, err = ic(r, d.Transport.Impl); err != nil {
+			return nil, runner.Fail(StageDriver, err)
+		}
+	}
+	d.Transport.Impl = guardRead(d.Transport.Impl)
+
+	stop := context.AfterFunc(ctx, func() { d.Close() })
+	defer stop()
+	if err := d.Open(); err != nil {
+		return nil, runner.Fail(StageOpen, ctxErr(ctx, err))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, runner.Fail(StageOpen, err)
+	}
+	return d, nil
+}
+
+// closeDriver는 드라이버를 닫습니다. 이미 끊긴 세션에는 로그아웃 명령(OnClose)을 보내지 않고 채널만 닫습니다.
+// (보내면 scrapligo 타임아웃까지 응답을 기다립니다)
+func closeDriver(d *network.Driver) {
+	if !d.Transport.IsAlive() {
+		d.Channel.Close()
+		return
+	}
+	d.Close()
+}
+
+// readGuard는 transport의 읽기 에러를 io.EOF로 바꿉니다.
+// scrapligo의 읽기 루프는 읽기 에러를 버퍼 없는 채널로 넘기는데, 아무도 받지 않을 때 그 채널을 닫으면
+// (로그인에 실패해서 Open이 채널을 닫는 경우 등) "send on closed channel"로 프로그램 전체가 panic합니다.
+// ssh 명령(system transport)이 끝나면서 pty가 EIO를 돌려주는 경우가 그렇습니다. (호스트 키 거부, 연결이 끊긴 경우)
+// io.EOF이면 읽기 루프가 그냥 끝나므로, 그 전에 받은 내용(ssh의 에러 메시지)을 채널이 읽어 갈 시간을 준 뒤 돌려줍니다.
+// 읽기 에러가 난 transport는 IsAlive가 false이므로 풀은 끊긴 세션을 명령을 보내 보지 않고 버립니다.
+type readGuard struct {
+	transport.Implementation
+	closed chan struct{}
+	once   sync.Once
+	failed atomic.Bool
+}
+
+// guardRead는 t를 readGuard로 감쌉니다. 채널 안에서 로그인하는 transport는 scrapligo가 타입으로 알아보므로 그대로 드러냅니다.
+func guardRead(t transport.Implementation) transport.Implementation {
+	g := &readGuard{Implementation: t, closed: make(chan struct{})}
+	if auth, ok := t.(inChannelAuth); ok {
+		return &authReadGuard{readGuard: g, auth: auth}
+	}
+	return g
+}
+
+func (t *readGuard) Read(n int) ([]byte, error) {
+	b, err := t.Implementation.Read(n)
+	if err == nil || errors.Is(err, io.EOF) {
+		return b, err
+	}
+	t.failed.Store(true)
+	select {
+	case <-t.closed:
+	case <-time.After(time.Second):
+	}
+	return b, io.EOF
+}
+
+func (t *readGuard) IsAlive() bool {
+	return !t.failed.Load() && t.Implementation.IsAlive()
+}
+
+func (t *readGuard) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return t.Implementation.Close()
+}
+
+type inChannelAuth interface {
+	transport.InChannelAuthImplementation
+	transport.SSHImplementation
+}
+
+// authReadGuard는 채널 안에서 로그인하는 transport(system)를 위한 readGuard입니다.
+type authReadGuard struct {
+	*readGuard
+	auth inChannelAuth
+}
+
+func (t *authReadGuard) GetInChannelAuthType() transport.InChannelAuthType {
+	return t.auth.GetInChannelAuthType()
+}
+
+func (t *authReadGuard) GetSSHArgs() *transport.SSHArgs {
+	return t.auth.GetSSHArgs()
+}
+
+// Close는 세션을 닫습니다. 여러 번 불러도 됩니다.
+func (s *Session) Close() error {
+	s.stop()
+	s.close()
+	return nil
+}
+
+// broken은 명령이 transport 단계에서 실패한 세션을 풀에 돌려주지 않도록 표시합니다.
+// (장비가 명령을 거부한 것은 세션에 문제가 없으므로 해당하지 않습니다)
+func (s *Session) broken() {
+	if s.conn != nil {
+		s.conn.broken.Store(true)
+	}
+}
+
+// Send는 명령을 보내고 결과를 돌려줍니다.
+// 장비가 명령을 거부한 경우(scrapligo의 FailedWhenContains, 예: "% Invalid input")도 에러로 취급합니다.
+func (s *Session) Send(command string) (*response.Response, error) {
+	// ctx가 끝나 이미 닫힌 세션에 보내면 scrapligo는 타임아웃까지 기다립니다.
+	if err := s.ctx.Err(); err != nil {
+		return nil, runner.Fail(StageSend, err)
+	}
+	rs, err := s.SendCommand(command)
+	if err != nil {
+		s.broken()
+		return rs, runner.Fail(StageSend, ctxErr(s.ctx, err))
+	}
+	if rs.Failed != nil {
+		return rs, runner.Fail(StageSend, rs.Failed)
+	}
+	return rs, nil
+}
+
+// Configure는 설정 모드로 들어가 설정 줄들을 보냅니다. 장비가 거부한 줄이 있으면 거기서 멈추고 에러를 돌려줍니다.
+func (s *Session) Configure(lines []string) (*response.MultiResponse, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, runner.Fail(StageConfig, err)
+	}
+	mr, err := s.SendConfigs(lines, opoptions.WithStopOnFailed())
+	if err != nil {
+		s.broken()
+		return mr, runner.Fail(StageConfig, ctxErr(s.ctx, err))
+	}
+	if mr.Failed != nil {
+		return mr, runner.Fail(StageConfig, mr.Failed)
+	}
+	return mr, nil
+}
+
+// runningConfigCommands는 running config를 보여주는 명령이 "show running-config"가 아닌 플랫폼입니다.
+var runningConfigCommands = map[string]string{
+	platform.JuniperJunos:     "show configuration",
+	platform.NokiaSros:        "admin show configuration",
+	platform.NokiaSrosClassic: "admin display-config",
+	platform.NokiaSrl:         "info from running",
+	platform.PaloAltoPanos:    "show config running",
+	platform.HuaweiVrp:        "display current-configuration",
+	platform.HpComware:        "display current-configuration",
+}
+
+// RunningConfig는 장비의 running config를 가져옵니다.
+func (s *Session) RunningConfig() (string, error) {
+	cmd, ok := runningConfigCommands[s.Host.Platform]
+	if !ok {
+		cmd = "show running-config"
+	}
+	rs, err := s.Send(cmd)
+	if err != nil {
+		return "", err
+	}
+	return rs.Result, nil
+}
+
+// Parse는 명령 결과를 TextFSM 템플릿으로 파싱합니다. 레코드가 하나도 없으면 에러입니다.
+func Parse(rs *response.Response, template string) ([]map[string]interface{}, error) {
+	parsed, err := rs.TextFsmParse(template)
+	if err != nil {
+		return nil, runner.Fail(StageParse, err)
+	}
+	if len(parsed) == 0 {
+		return nil, runner.Fail(StageParse, errors.New("no records parsed from "+template))
+	}
+	return parsed, nil
+}
+
+// Parse는 명령 결과를 장비 플랫폼과 명령에 맞는 내장 템플릿(textfsm.Default)으로 파싱합니다.
+// 맞는 템플릿이 없으면 textfsm.ErrNoTemplate, 레코드가 하나도 없으면 에러입니다.
+func (s *Session) Parse(rs *response.Response) ([]map[string]interface{}, error) {
+	parsed, err := textfsm.Parse(s.Host.Platform, rs.Input, rs.Result)
+	if err != nil {
+		return nil, runner.Fail(StageParse, err)
+	}
+	if len(parsed) == 0 {
+		return nil, runner.Fail(StageParse, fmt.Errorf("no records parsed from %q", rs.Input))
+	}
+	return parsed, nil
+}
+
+// ctxErr는 ctx가 끝나서 세션이 닫힌 경우 transport 에러 대신 ctx의 에러를 돌려줍니다.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
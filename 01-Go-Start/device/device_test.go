@@ -0,0 +1,120 @@
+package device_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh/knownhosts"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// startFake는 흉내 장비를 띄우고 테스트가 끝나면 닫습니다.
+func startFake(t *testing.T, d fakedevice.Device) *fakedevice.Server {
+	t.Helper()
+	srv, err := fakedevice.Start(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// getVersion은 예제들의 getVersion처럼 show version을 보내고 TextFSM으로 파싱합니다.
+func getVersion(ctx context.Context, srv *fakedevice.Server) (textfsm.VersionInfo, error) {
+	s, err := device.Open(ctx, srv.Router())
+	if err != nil {
+		return textfsm.VersionInfo{}, err
+	}
+	defer s.Close()
+
+	rs, err := s.Send("show version")
+	if err != nil {
+		return textfsm.VersionInfo{}, err
+	}
+	parsed, err := s.Parse(rs)
+	if err != nil {
+		return textfsm.VersionInfo{}, err
+	}
+	return textfsm.DecodeOne[textfsm.VersionInfo](parsed)
+}
+
+func TestOpenGetVersion(t *testing.T) {
+	for _, platform := range fakedevice.Platforms() {
+		t.Run(platform, func(t *testing.T) {
+			srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: platform})
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			info, err := getVersion(ctx, srv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Version == "" {
+				t.Errorf("empty version in %+v", info)
+			}
+		})
+	}
+}
+
+func TestOpenBadPassword(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	r := srv.Router()
+	r.Password = "wrong"
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := device.Open(ctx, r)
+	if err == nil {
+		t.Fatal("Open succeeded with a wrong password")
+	}
+	if stage := runner.StageOf(err); stage != device.StageOpen {
+		t.Errorf("stage = %q, want %q", stage, device.StageOpen)
+	}
+	if class := device.Classify(err); class != device.ClassAuth {
+		t.Errorf("class = %q, want %q (%v)", class, device.ClassAuth, err)
+	}
+}
+
+func TestOpenStrictKey(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	dir := t.TempDir()
+	knownHosts := filepath.Join(dir, "known_hosts")
+	sshConfig := filepath.Join(dir, "ssh_config")
+	if err := os.WriteFile(sshConfig, []byte("Host *\n  UserKnownHostsFile "+knownHosts+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	strict := true
+	r := srv.Router()
+	r.SSHConfig = sshConfig
+	r.StrictKey = &strict
+
+	// known_hosts에 없는 호스트 키는 거부합니다.
+	if err := os.WriteFile(knownHosts, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if s, err := device.Open(ctx, r); err == nil {
+		s.Close()
+		t.Fatal("Open accepted an unknown host key")
+	} else if class := device.Classify(err); class != device.ClassHostKey {
+		t.Errorf("class = %q, want %q (%v)", class, device.ClassHostKey, err)
+	}
+
+	// known_hosts에 넣은 뒤에는 접속합니다.
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.Addr().String())}, srv.HostKey())
+	if err := os.WriteFile(knownHosts, []byte(line+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := device.Open(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+}
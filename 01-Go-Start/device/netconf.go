@@ -0,0 +1,236 @@
+package device
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/scrapli/scrapligo/driver/netconf"
+	"github.com/scrapli/scrapligo/driver/opoptions"
+	"github.com/scrapli/scrapligo/driver/options"
+	"github.com/scrapli/scrapligo/response"
+	"github.com/scrapli/scrapligo/transport"
+	"github.com/scrapli/scrapligo/util"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// StageRPC는 NETCONF RPC가 실패한 경우입니다. 장비가 <rpc-error>로 거부한 경우도 포함합니다.
+const StageRPC runner.Stage = "rpc"
+
+// NETCONF 데이터스토어 이름입니다.
+const (
+	Running   = "running"
+	Candidate = "candidate"
+)
+
+// NetconfSession은 열려 있는 NETCONF 세션입니다. (인벤토리에서 mode: netconf인 장비)
+// 메서드는 scrapligo netconf 드라이버를 감싸서 <rpc-error>도 에러로 돌려주고, ctx가 끝나면 세션을 닫습니다.
+type NetconfSession struct {
+	Driver *netconf.Driver
+	Host   inventory.Router
+
+	ctx   context.Context
+	close func()
+	stop  func() bool
+}
+
+// OpenNetconf는 장비에 NETCONF over SSH로 접속합니다. Port를 지정하지 않았으면 830번 포트입니다.
+// opts는 기본 옵션 뒤에 붙으므로 기본값을 덮어쓸 수 있습니다. (예: options.WithNetconfPreferredVersion("1.0"))
+//
+// CLI와 달리 Go SSH 클라이언트(scrapligo standard transport)로 접속하므로 ssh_config는 쓰지 않습니다.
+// ssh 명령(system transport)은 pty를 거치면서 한 줄이 4KB를 넘는 RPC를 잘라 버립니다.
+func OpenNetconf(ctx context.Context, r inventory.Router, opts ...util.Option) (*NetconfSession, error) {
+	if r.Port == 0 {
+		r.Port = inventory.DefaultNETCONFPort
+	}
+	opts = append([]util.Option{options.WithTransportType(transport.StandardTransport)}, opts...)
+	var d *netconf.Driver
+	err := login(ctx, r, func() (err error) {
+		d, err = dialNetconf(ctx, r, append(Options(ctx, r), opts...))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &NetconfSession{Driver: d, Host: r, ctx: ctx}
+	s.close = sync.OnceFunc(func() { closeNetconf(d) })
+	s.stop = context.AfterFunc(ctx, s.close)
+	return s, nil
+}
+
+// dialNetconf는 NETCONF 로그인을 한 번 시도합니다.
+func dialNetconf(ctx context.Context, r inventory.Router, opts []util.Option) (*netconf.Driver, error) {
+	d, err := netconf.NewDriver(r.Address(), opts...)
+	if err != nil {
+		return nil, runner.Fail(StageDriver, err)
+	}
+	if ic, ok := ctx.Value(interceptorKey{}).(Interceptor); ok {
+		if d.Transport.Impl, err = ic(r, d.Transport.Impl); err != nil {
+			return nil, runner.Fail(StageDriver, err)
+		}
+	}
+
+	// hello를 주고받는 동안에는 드라이버의 읽기 루프가 없으므로 d.Close 대신 transport를 닫아서 멈춥니다.
+	stop := context.AfterFunc(ctx, func() { d.Transport.Close(true) })
+	err = d.Open()
+	stop()
+	if err != nil {
+		return nil, runner.Fail(StageOpen, ctxErr(ctx, err))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, runner.Fail(StageOpen, err)
+	}
+	return d, nil
+}
+
+// closeNetconf는 드라이버를 닫습니다. 장비가 먼저 연결을 끊었으면 scrapligo의 읽기 루프가 멈춰 있어
+// d.Close가 돌아오지 않으므로, 잠시 기다렸다가 channel을 직접 닫습니다.
+func closeNetconf(d *netconf.Driver) {
+	done := make(chan struct{})
+	go func() {
+		d.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		d.Transport.Close(true)
+	}
+}
+
+// Close는 세션을 닫습니다. 여러 번 불러도 됩니다.
+func (s *NetconfSession) Close() error {
+	s.stop()
+	s.close()
+	return nil
+}
+
+// rpc는 RPC 하나를 보냅니다. transport 에러와 <rpc-error> 모두 StageRPC 에러입니다.
+func (s *NetconfSession) rpc(send func() (*response.NetconfResponse, error)) (*response.NetconfResponse, error) {
+	// ctx가 끝나 이미 닫힌 세션에 보내면 scrapligo는 타임아웃까지 기다립니다.
+	if err := s.ctx.Err(); err != nil {
+		return nil, runner.Fail(StageRPC, err)
+	}
+	rs, err := send()
+	if err != nil {
+		return rs, runner.Fail(StageRPC, ctxErr(s.ctx, err))
+	}
+	if rs.Failed != nil {
+		return rs, runner.Fail(StageRPC, replyError(rs))
+	}
+	return rs, nil
+}
+
+// Get은 running 설정과 상태 데이터를 가져옵니다. filter는 subtree 필터 XML이고 비어 있으면 전부입니다.
+func (s *NetconfSession) Get(filter string) (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Get(filter) })
+}
+
+// GetConfig는 source 데이터스토어(running, candidate)의 설정을 가져옵니다. filter는 Get과 같습니다.
+func (s *NetconfSession) GetConfig(source, filter string) (*response.NetconfResponse, error) {
+	var opts []util.Option
+	if filter != "" {
+		opts = append(opts, opoptions.WithFilter(filter))
+	}
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.GetConfig(source, opts...) })
+}
+
+// EditConfig는 config(<config> 요소 또는 그 안의 내용)를 target 데이터스토어에 적용합니다.
+func (s *NetconfSession) EditConfig(target, config string) (*response.NetconfResponse, error) {
+	if !strings.HasPrefix(strings.TrimSpace(config), "<config") {
+		config = "<config>" + config + "</config>"
+	}
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.EditConfig(target, config) })
+}
+
+// Lock은 target 데이터스토어를 잠급니다. 다른 세션이 잠갔으면 lock-denied 에러입니다.
+func (s *NetconfSession) Lock(target string) (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Lock(target) })
+}
+
+// Unlock은 Lock으로 잠근 데이터스토어를 풉니다.
+func (s *NetconfSession) Unlock(target string) (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Unlock(target) })
+}
+
+// Commit은 candidate를 running에 반영합니다.
+func (s *NetconfSession) Commit() (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Commit() })
+}
+
+// Discard는 commit하지 않은 candidate의 변경을 버립니다.
+func (s *NetconfSession) Discard() (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Discard() })
+}
+
+// Validate는 source 데이터스토어의 설정을 장비가 검사하게 합니다.
+func (s *NetconfSession) Validate(source string) (*response.NetconfResponse, error) {
+	return s.rpc(func() (*response.NetconfResponse, error) { return s.Driver.Validate(source) })
+}
+
+// RPCError는 장비가 돌려준 <rpc-error>입니다. (RFC 6241 4.3)
+type RPCError struct {
+	Type     string `xml:"error-type"`
+	Tag      string `xml:"error-tag"`
+	Severity string `xml:"error-severity"`
+	Path     string `xml:"error-path"`
+	Message  string `xml:"error-message"`
+}
+
+func (e *RPCError) Error() string {
+	msg := e.Tag
+	if e.Path != "" {
+		msg += " at " + strings.TrimSpace(e.Path)
+	}
+	if e.Message != "" {
+		msg += ": " + strings.TrimSpace(e.Message)
+	}
+	return msg
+}
+
+// replyError는 <rpc-reply>의 <rpc-error>들을 *RPCError로 돌려줍니다. 읽을 수 없으면 scrapligo의 에러 그대로입니다.
+func replyError(rs *response.NetconfResponse) error {
+	var reply struct {
+		Errors []*RPCError `xml:"rpc-error"`
+	}
+	if err := xml.Unmarshal([]byte(rs.Result), &reply); err != nil || len(reply.Errors) == 0 {
+		return rs.Failed
+	}
+	errs := make([]error, len(reply.Errors))
+	for i, e := range reply.Errors {
+		errs[i] = e
+	}
+	return errors.Join(errs...)
+}
+
+// DecodeData는 get, get-config 응답의 <data> 요소를 v로 디코딩합니다.
+// v는 encoding/xml 태그를 단 구조체이고, 태그는 <data> 기준입니다. (inventory의 XML 파일과 같은 방식)
+//
+//	var ifs struct {
+//		Interfaces []struct {
+//			Name    string `xml:"name"`
+//			Enabled bool   `xml:"enabled"`
+//		} `xml:"interfaces>interface"`
+//	}
+//	err := device.DecodeData(rs, &ifs)
+func DecodeData(rs *response.NetconfResponse, v any) error {
+	d := xml.NewDecoder(strings.NewReader(rs.Result))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return runner.Fail(StageParse, fmt.Errorf("no <data> in reply: %w", err))
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "data" {
+			if err := d.DecodeElement(v, &start); err != nil {
+				return runner.Fail(StageParse, err)
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package device_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+const (
+	systemNS     = "urn:ietf:params:xml:ns:yang:ietf-system"
+	interfacesNS = "urn:ietf:params:xml:ns:yang:ietf-interfaces"
+)
+
+// openNetconf는 흉내 장비에 NETCONF로 접속합니다. 테스트가 끝나면 닫습니다.
+func openNetconf(ctx context.Context, t *testing.T, srv *fakedevice.Server) *device.NetconfSession {
+	t.Helper()
+	r := srv.Router()
+	r.Mode = inventory.ModeNETCONF
+	s, err := device.OpenNetconf(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// hostnameConfig는 hostname만 바꾸는 edit-config 내용입니다.
+func hostnameConfig(name string) string {
+	return `<system xmlns="` + systemNS + `"><hostname>` + name + `</hostname></system>`
+}
+
+// hostname은 source 데이터스토어의 hostname입니다.
+func hostname(t *testing.T, s *device.NetconfSession, source string) string {
+	t.Helper()
+	rs, err := s.GetConfig(source, `<system xmlns="`+systemNS+`"/>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Hostname string `xml:"system>hostname"`
+	}
+	if err := device.DecodeData(rs, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data.Hostname
+}
+
+// rpcErrorTag는 err 안의 *device.RPCError의 error-tag입니다. StageRPC 에러가 아니면 실패합니다.
+func rpcErrorTag(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		t.Fatal("RPC succeeded")
+	}
+	if stage := runner.StageOf(err); stage != device.StageRPC {
+		t.Errorf("stage = %q, want %q", stage, device.StageRPC)
+	}
+	var re *device.RPCError
+	if !errors.As(err, &re) {
+		t.Fatalf("err = %v, want an <rpc-error>", err)
+	}
+	return re.Tag
+}
+
+func TestNetconfGet(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := openNetconf(ctx, t, srv)
+
+	// 상태 데이터는 get에만 보입니다.
+	filter := `<interfaces-state xmlns="` + interfacesNS + `"/>`
+	rs, err := s.Get(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state struct {
+		Interfaces []struct {
+			Name       string `xml:"name"`
+			OperStatus string `xml:"oper-status"`
+		} `xml:"interfaces-state>interface"`
+	}
+	if err := device.DecodeData(rs, &state); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Interfaces) != 3 || state.Interfaces[0].Name != "GigabitEthernet1" || state.Interfaces[0].OperStatus != "up" {
+		t.Errorf("get interfaces-state = %+v", state.Interfaces)
+	}
+
+	rs, err = s.GetConfig(device.Running, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rs.Result, "oper-status") {
+		t.Errorf("get-config returned state data:\n%s", rs.Result)
+	}
+	if got := hostname(t, s, device.Running); got != "rtr1" {
+		t.Errorf("hostname = %q, want rtr1", got)
+	}
+}
+
+func TestNetconfCommit(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := openNetconf(ctx, t, srv)
+
+	if _, err := s.Lock(device.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.EditConfig(device.Candidate, hostnameConfig("rtr2")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Validate(device.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	// commit 전에는 candidate에만 있습니다.
+	if got := hostname(t, s, device.Running); got != "rtr1" {
+		t.Errorf("running hostname before commit = %q, want rtr1", got)
+	}
+	if got := hostname(t, s, device.Candidate); got != "rtr2" {
+		t.Errorf("candidate hostname = %q, want rtr2", got)
+	}
+
+	if _, err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Unlock(device.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	if got := hostname(t, s, device.Running); got != "rtr2" {
+		t.Errorf("running hostname after commit = %q, want rtr2", got)
+	}
+}
+
+func TestNetconfLock(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	a := openNetconf(ctx, t, srv)
+	b := openNetconf(ctx, t, srv)
+
+	if _, err := a.Lock(device.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	_, err := b.Lock(device.Candidate)
+	if tag := rpcErrorTag(t, err); tag != "lock-denied" {
+		t.Errorf("second lock: tag = %q, want lock-denied", tag)
+	}
+	_, err = b.Unlock(device.Candidate)
+	if tag := rpcErrorTag(t, err); tag != "operation-failed" {
+		t.Errorf("unlock by another session: tag = %q, want operation-failed", tag)
+	}
+
+	if _, err := a.Unlock(device.Candidate); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Lock(device.Candidate); err != nil {
+		t.Errorf("lock after unlock: %v", err)
+	}
+}
+
+func TestNetconfReject(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{
+		Hostname: "rtr1",
+		Platform: "cisco_iosxe",
+		Reject:   []*regexp.Regexp{regexp.MustCompile(`<hostname>bad</hostname>`)},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s := openNetconf(ctx, t, srv)
+
+	// running에 바로 넣으면 edit-config에서 거부합니다.
+	_, err := s.EditConfig(device.Running, hostnameConfig("bad"))
+	if tag := rpcErrorTag(t, err); tag != "invalid-value" {
+		t.Errorf("edit-config running: tag = %q, want invalid-value", tag)
+	}
+
+	// candidate에는 들어가지만 validate와 commit에서 거부합니다.
+	if _, err := s.EditConfig(device.Candidate, hostnameConfig("bad")); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Validate(device.Candidate)
+	if tag := rpcErrorTag(t, err); tag != "invalid-value" {
+		t.Errorf("validate: tag = %q, want invalid-value", tag)
+	}
+	_, err = s.Commit()
+	if tag := rpcErrorTag(t, err); tag != "invalid-value" {
+		t.Errorf("commit: tag = %q, want invalid-value", tag)
+	}
+	if got := hostname(t, s, device.Running); got != "rtr1" {
+		t.Errorf("running hostname = %q, want rtr1", got)
+	}
+
+	if _, err := s.Discard(); err != nil {
+		t.Fatal(err)
+	}
+	if got := hostname(t, s, device.Candidate); got != "rtr1" {
+		t.Errorf("candidate hostname after discard = %q, want rtr1", got)
+	}
+}
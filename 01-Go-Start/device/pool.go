@@ -0,0 +1,351 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scrapli/scrapligo/driver/network"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 기본 세션 풀 설정입니다.
+const (
+	DefaultPoolPerHost = 1
+	DefaultIdleTimeout = 5 * time.Minute
+)
+
+// ErrPoolClosed는 닫힌 풀에서 세션을 꺼내려는 경우입니다.
+var ErrPoolClosed = errors.New("session pool closed")
+
+// PoolOptions는 세션 풀의 동작을 정합니다.
+type PoolOptions struct {
+	// PerHost는 장비마다 동시에 열어 두는 최대 세션 수입니다. 0이면 DefaultPoolPerHost입니다.
+	// 모두 쓰고 있으면 Get은 하나가 돌아올 때까지 기다립니다.
+	PerHost int
+	// IdleTimeout은 쓰지 않는 세션을 닫기까지의 시간입니다. 0이면 DefaultIdleTimeout입니다.
+	IdleTimeout time.Duration
+	// Keepalive는 쓰지 않는 세션에 프롬프트를 요청하는 간격입니다. 장비의 exec-timeout이나 중간 방화벽이
+	// 세션을 끊지 않게 합니다. 0이면 보내지 않습니다.
+	Keepalive time.Duration
+}
+
+// Register는 PoolOptions를 명령행 플래그로 등록합니다.
+func (o *PoolOptions) Register(fs *flag.FlagSet) {
+	fs.IntVar(&o.PerHost, "pool-per-host", DefaultPoolPerHost, "maximum pooled sessions per host")
+	fs.DurationVar(&o.IdleTimeout, "pool-idle", DefaultIdleTimeout, "close pooled sessions idle for this long")
+	fs.DurationVar(&o.Keepalive, "pool-keepalive", 0, "send a keepalive on idle pooled sessions at this interval (0 = never)")
+}
+
+// PoolStats는 풀이 지금까지 한 일입니다.
+type PoolStats struct {
+	// Opened는 새로 로그인한 세션 수, Reused는 열려 있던 세션을 다시 내준 횟수입니다.
+	Opened, Reused int64
+	// Discarded는 상태 점검이나 keepalive에 실패했거나 명령 중에 끊겨 버린 세션 수입니다.
+	Discarded int64
+	// Expired는 IdleTimeout이 지나 닫은 세션 수입니다.
+	Expired int64
+}
+
+// Pool은 장비마다 로그인한 세션을 열어 두고 여러 Job에 돌려 가며 내주는 세션 풀입니다.
+// ctx에 WithPool로 넣어 두면 Open이 풀에서 세션을 꺼내고 Session.Close가 풀에 돌려주므로,
+// runner의 Job은 고치지 않아도 됩니다.
+//
+//	pool := device.NewPool(device.PoolOptions{PerHost: 2, Keepalive: 30 * time.Second})
+//	defer pool.Close()
+//	ctx = device.WithPool(ctx, pool)
+//
+// 다시 내주기 전에 세션이 살아 있는지 확인하고 기본 권한(enable 등)으로 돌려놓습니다. 실패하면 버리고 새로 로그인합니다.
+// 쓰는 동안 Job의 ctx가 끝나거나 명령이 transport 단계에서 실패한 세션은 풀에 돌아오지 않습니다.
+type Pool struct {
+	opts PoolOptions
+
+	mu     sync.Mutex
+	hosts  map[hostKey]*poolHost
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+
+	opened, reused, discarded, expired atomic.Int64
+}
+
+// hostKey는 풀이 세션을 나누는 기준입니다. 이름이 같아도 주소, 포트, 계정이 다르면 다른 장비로 봅니다.
+// (예: 인벤토리를 다시 읽어 주소가 바뀐 장비, 같은 이름으로 콘솔 서버 포트를 쓰는 장비)
+type hostKey struct {
+	hostname, address string
+	port              int
+	username          string
+}
+
+func keyOf(r inventory.Router) hostKey {
+	return hostKey{hostname: r.Hostname, address: r.Address(), port: r.Port, username: r.Username}
+}
+
+// poolHost는 장비 한 대의 세션들입니다. slots는 PerHost개까지 세션을 빌려 가는 세마포어입니다.
+type poolHost struct {
+	slots chan struct{}
+	idle  []*pooled
+}
+
+// pooled는 풀에 있는 세션 하나입니다.
+type pooled struct {
+	d *network.Driver
+	// close는 드라이버를 한 번만 닫습니다. (ctx로 닫힌 세션을 돌려받을 때 다시 닫지 않도록)
+	close    func()
+	lastUsed time.Time
+	lastPing time.Time
+	// broken은 다시 내주면 안 되는 세션입니다. (ctx로 닫혔거나 명령 중에 끊김)
+	broken atomic.Bool
+}
+
+type poolKey struct{}
+
+// WithPool은 ctx로 여는 세션을 p에서 꺼내도록 합니다.
+func WithPool(ctx context.Context, p *Pool) context.Context {
+	return context.WithValue(ctx, poolKey{}, p)
+}
+
+// NewPool은 세션 풀을 만듭니다. 다 쓰면 Close로 열린 세션을 닫아야 합니다.
+func NewPool(opts PoolOptions) *Pool {
+	if opts.PerHost <= 0 {
+		opts.PerHost = DefaultPoolPerHost
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+	p := &Pool{
+		opts:  opts,
+		hosts: map[hostKey]*poolHost{},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go p.janitor()
+	return p
+}
+
+// Get은 r의 세션을 빌립니다. 다 쓴 세션은 Close로 돌려줍니다.
+// 쉬고 있는 세션이 있으면 점검한 뒤 내주고, 없으면 새로 로그인합니다.
+func (p *Pool) Get(ctx context.Context, r inventory.Router) (*Session, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, runner.Fail(StageOpen, ErrPoolClosed)
+	}
+	key := keyOf(r)
+	h, ok := p.hosts[key]
+	if !ok {
+		h = &poolHost{slots: make(chan struct{}, p.opts.PerHost)}
+		p.hosts[key] = h
+	}
+	p.mu.Unlock()
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, runner.Fail(StageOpen, ctx.Err())
+	}
+
+	for {
+		c := p.take(h)
+		if c == nil {
+			break
+		}
+		if err := p.check(ctx, c); err != nil {
+			p.discard(c)
+			continue
+		}
+		p.reused.Add(1)
+		return p.lease(ctx, r, h, c), nil
+	}
+
+	// 세션은 빌려 간 Job보다 오래 살아야 하므로 ctx의 기한을 scrapligo 타임아웃으로 넘기지 않습니다.
+	// 로그인하는 동안 ctx가 끝나면 닫아서 멈춥니다.
+	d, err := open(ctx, r, Options(context.WithoutCancel(ctx), r))
+	if err != nil {
+		<-h.slots
+		return nil, err
+	}
+	p.opened.Add(1)
+	return p.lease(ctx, r, h, &pooled{d: d, close: sync.OnceFunc(func() { closeDriver(d) })}), nil
+}
+
+// take는 쉬고 있는 세션 하나를 꺼냅니다. 가장 최근에 쓴 세션부터 씁니다.
+func (p *Pool) take(h *poolHost) *pooled {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(h.idle) == 0 {
+		return nil
+	}
+	c := h.idle[len(h.idle)-1]
+	h.idle = h.idle[:len(h.idle)-1]
+	return c
+}
+
+// check는 세션이 살아 있는지 확인하고 기본 권한으로 돌려놓습니다. ctx가 끝나면 세션을 닫아 멈춥니다.
+func (p *Pool) check(ctx context.Context, c *pooled) error {
+	if !c.d.Transport.IsAlive() {
+		return errors.New("transport closed")
+	}
+	stop := context.AfterFunc(ctx, c.close)
+	defer stop()
+	return c.d.AcquirePriv(c.d.DefaultDesiredPriv)
+}
+
+// lease는 빌려 주는 Session을 만듭니다. Close하면 풀에 돌아가고, 그 전에 ctx가 끝나면 세션을 닫고 버립니다.
+func (p *Pool) lease(ctx context.Context, r inventory.Router, h *poolHost, c *pooled) *Session {
+	s := &Session{Driver: c.d, Host: r, ctx: ctx, conn: c}
+	s.stop = context.AfterFunc(ctx, func() {
+		c.broken.Store(true)
+		c.close()
+	})
+	s.close = sync.OnceFunc(func() {
+		// Close보다 ctx가 먼저 끝났으면 위의 AfterFunc가 세션을 닫고 있을 수 있습니다.
+		if ctx.Err() != nil {
+			c.broken.Store(true)
+		}
+		p.put(h, c, true)
+	})
+	return s
+}
+
+// put은 빌려 간 세션을 돌려받습니다. used가 false이면(keepalive) IdleTimeout을 새로 세지 않습니다.
+func (p *Pool) put(h *poolHost, c *pooled, used bool) {
+	defer func() { <-h.slots }()
+
+	p.mu.Lock()
+	if c.broken.Load() || p.closed {
+		p.mu.Unlock()
+		p.discard(c)
+		return
+	}
+	c.lastPing = time.Now()
+	if used {
+		c.lastUsed = c.lastPing
+	}
+	h.idle = append(h.idle, c)
+	p.mu.Unlock()
+}
+
+func (p *Pool) discard(c *pooled) {
+	c.close()
+	if !p.isClosed() {
+		p.discarded.Add(1)
+	}
+}
+
+func (p *Pool) isClosed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// janitor는 오래 쉰 세션을 닫고 keepalive를 보냅니다.
+func (p *Pool) janitor() {
+	defer close(p.done)
+
+	tick := time.Second
+	for _, d := range []time.Duration{p.opts.IdleTimeout / 2, p.opts.Keepalive / 2} {
+		if d > 0 && d < tick {
+			tick = d
+		}
+	}
+	t := time.NewTicker(tick)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-t.C:
+		}
+		p.sweep()
+	}
+}
+
+// sweep은 IdleTimeout이 지난 세션을 닫고, keepalive 때가 된 세션에 프롬프트를 요청합니다.
+// keepalive 중인 세션은 빌려 간 것으로 칩니다. 그 장비의 세션을 모두 빌려 갔으면 다음으로 미룹니다.
+func (p *Pool) sweep() {
+	now := time.Now()
+	var expired []*pooled
+	type ping struct {
+		h *poolHost
+		c *pooled
+	}
+	var pings []ping
+
+	p.mu.Lock()
+	for _, h := range p.hosts {
+		keep := h.idle[:0]
+		for _, c := range h.idle {
+			switch {
+			case now.Sub(c.lastUsed) >= p.opts.IdleTimeout:
+				expired = append(expired, c)
+			case p.opts.Keepalive > 0 && now.Sub(c.lastPing) >= p.opts.Keepalive && tryAcquire(h.slots):
+				pings = append(pings, ping{h, c})
+			default:
+				keep = append(keep, c)
+			}
+		}
+		clear(h.idle[len(keep):])
+		h.idle = keep
+	}
+	p.mu.Unlock()
+
+	for _, c := range expired {
+		c.close()
+		p.expired.Add(1)
+	}
+	for _, k := range pings {
+		if _, err := k.c.d.GetPrompt(); err != nil {
+			k.c.broken.Store(true)
+		}
+		p.put(k.h, k.c, false)
+	}
+}
+
+func tryAcquire(slots chan struct{}) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Stats는 풀이 지금까지 한 일을 돌려줍니다.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Opened:    p.opened.Load(),
+		Reused:    p.reused.Load(),
+		Discarded: p.discarded.Load(),
+		Expired:   p.expired.Load(),
+	}
+}
+
+// Close는 쉬고 있는 세션을 모두 닫습니다. 빌려 간 세션은 돌아올 때 닫습니다. 여러 번 불러도 됩니다.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	var idle []*pooled
+	for _, h := range p.hosts {
+		idle = append(idle, h.idle...)
+		h.idle = nil
+	}
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+	for _, c := range idle {
+		c.close()
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package device_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// newPool은 풀을 만들고 테스트가 끝나면 닫습니다.
+func newPool(t *testing.T, opts device.PoolOptions) *device.Pool {
+	t.Helper()
+	p := device.NewPool(opts)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// borrow는 p에서 r의 세션을 빌려 명령 하나를 보내고 돌려줍니다.
+func borrow(ctx context.Context, t *testing.T, p *device.Pool, r inventory.Router) {
+	t.Helper()
+	s, err := p.Get(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Send("show version"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkStats(t *testing.T, p *device.Pool, want device.PoolStats) {
+	t.Helper()
+	if got := p.Stats(); got != want {
+		t.Errorf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestPoolReuse(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	p := newPool(t, device.PoolOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	borrow(ctx, t, p, srv.Router())
+	borrow(ctx, t, p, srv.Router())
+	checkStats(t, p, device.PoolStats{Opened: 1, Reused: 1})
+}
+
+func TestPoolKey(t *testing.T) {
+	// 이름이 같아도 포트나 계정이 다르면 세션을 함께 쓰지 않습니다.
+	a := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	b := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe", Username: "ops", Password: "ops"})
+	p := newPool(t, device.PoolOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	borrow(ctx, t, p, a.Router())
+	borrow(ctx, t, p, b.Router())
+	if !slices.Contains(b.History(), "show version") {
+		t.Errorf("second host got no command, history = %q", b.History())
+	}
+
+	// 같은 장비에 다른 계정으로 접속해도 새로 로그인합니다.
+	other := a.Router()
+	other.Username = "ops"
+	other.Password = "ops"
+	if _, err := p.Get(ctx, other); err == nil {
+		t.Error("reused a session logged in as another user")
+	}
+	checkStats(t, p, device.PoolStats{Opened: 2})
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	p := newPool(t, device.PoolOptions{IdleTimeout: 200 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	borrow(ctx, t, p, srv.Router())
+	time.Sleep(600 * time.Millisecond)
+	borrow(ctx, t, p, srv.Router())
+	checkStats(t, p, device.PoolStats{Opened: 2, Expired: 1})
+}
+
+func TestPoolPerHost(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	p := newPool(t, device.PoolOptions{PerHost: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := p.Get(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 하나를 빌려 간 동안 두 번째 Get은 기다립니다.
+	short, cancelShort := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancelShort()
+	if _, err := p.Get(short, srv.Router()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Get = %v, want a deadline error", err)
+	}
+
+	got := make(chan error, 1)
+	go func() {
+		s, err := p.Get(ctx, srv.Router())
+		if err == nil {
+			s.Close()
+		}
+		got <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	s.Close()
+	if err := <-got; err != nil {
+		t.Fatal(err)
+	}
+	checkStats(t, p, device.PoolStats{Opened: 1, Reused: 1})
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	p := newPool(t, device.PoolOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 쉬는 동안 장비가 끊은 세션은 다시 내주지 않고 새로 로그인합니다.
+	s, err := p.Get(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Channel.WriteAndReturn([]byte("exit"), false); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	for deadline := time.Now().Add(5 * time.Second); s.Transport.IsAlive(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("transport still alive after exit")
+		}
+	}
+
+	borrow(ctx, t, p, srv.Router())
+	checkStats(t, p, device.PoolStats{Opened: 2, Discarded: 1})
+}
+
+func TestPoolCloseWhileLeased(t *testing.T) {
+	srv := startFake(t, fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	p := device.NewPool(device.PoolOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := p.Get(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 빌려 간 세션은 돌려줄 때까지 쓸 수 있고, 돌려주면 닫힙니다.
+	if _, err := s.Send("show version"); err != nil {
+		t.Errorf("send on a leased session after Close: %v", err)
+	}
+	s.Close()
+	if s.Transport.IsAlive() {
+		t.Error("session returned to a closed pool is still open")
+	}
+
+	if _, err := p.Get(ctx, srv.Router()); !errors.Is(err, device.ErrPoolClosed) {
+		t.Errorf("Get after Close = %v, want ErrPoolClosed", err)
+	}
+}
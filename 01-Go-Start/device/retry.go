@@ -0,0 +1,323 @@
+package device
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/scrapli/scrapligo/response"
+	"github.com/scrapli/scrapligo/util"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// ErrorClass는 재시도할지 정하기 위해 에러를 나눈 종류입니다.
+type ErrorClass string
+
+const (
+	// ClassAuth는 로그인이 거부된 경우입니다. 다시 시도하면 계정이 잠길 수 있습니다.
+	ClassAuth ErrorClass = "auth"
+	// ClassHostKey는 호스트 키가 맞지 않는 경우입니다.
+	ClassHostKey ErrorClass = "hostkey"
+	// ClassResolve는 호스트 이름을 찾지 못한 경우입니다.
+	ClassResolve ErrorClass = "resolve"
+	// ClassTimeout은 접속이나 명령이 scrapligo 타임아웃에 걸린 경우입니다.
+	ClassTimeout ErrorClass = "timeout"
+	// ClassRefused는 장비가 접속을 거부한 경우입니다. (sshd가 아직 뜨지 않았거나 세션 수가 가득 찬 경우 등)
+	ClassRefused ErrorClass = "refused"
+	// ClassUnreachable은 장비까지 경로가 없는 경우입니다.
+	ClassUnreachable ErrorClass = "unreachable"
+	// ClassClosed는 로그인이나 명령 중에 연결이 끊긴 경우입니다.
+	// ssh 명령(system transport)은 접속 거부도 이렇게 보입니다. (ssh가 끝나면서 pty를 닫습니다)
+	// 읽기 루프가 끝난 뒤의 scrapligo 에러(util.ErrConnectionError)도 여기에 듭니다.
+	ClassClosed ErrorClass = "closed"
+	// ClassCommand는 장비가 명령이나 RPC를 거부한 경우입니다.
+	ClassCommand ErrorClass = "command"
+	ClassOther   ErrorClass = "other"
+)
+
+// DefaultRetryOn은 기본으로 재시도하는 에러 종류입니다. 인증 실패와 장비가 거부한 명령은 다시 해도 같으므로 뺍니다.
+var DefaultRetryOn = []ErrorClass{ClassTimeout, ClassRefused, ClassUnreachable, ClassClosed}
+
+// Classify는 접속이나 명령의 에러를 종류로 나눕니다.
+// scrapligo는 ssh의 에러 출력을 문장으로만 알려 주므로 메시지도 봅니다.
+func Classify(err error) ErrorClass {
+	var opErr *response.OperationError
+	var rpcErr *RPCError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	msg := strings.ToLower(err.Error())
+	switch {
+	case errors.As(err, &opErr), errors.As(err, &rpcErr):
+		return ClassCommand
+	case errors.Is(err, util.ErrAuthError), strings.Contains(msg, "permission denied"),
+		strings.Contains(msg, "unable to authenticate"):
+		return ClassAuth
+	case strings.Contains(msg, "host key"):
+		return ClassHostKey
+	case errors.As(err, &dnsErr), strings.Contains(msg, "could not resolve hostname"):
+		return ClassResolve
+	case errors.Is(err, syscall.ECONNREFUSED), strings.Contains(msg, "connection refused"):
+		return ClassRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH),
+		strings.Contains(msg, "no route to host"):
+		return ClassUnreachable
+	case errors.Is(err, util.ErrTimeoutError), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout(),
+		strings.Contains(msg, "timed out"):
+		return ClassTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, syscall.EIO), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE), errors.Is(err, net.ErrClosed), errors.Is(err, util.ErrConnectionError):
+		return ClassClosed
+	}
+	return ClassOther
+}
+
+// 기본 재시도 설정입니다.
+const (
+	DefaultMaxAttempts = 3
+	DefaultBackoff     = time.Second
+	DefaultMaxBackoff  = 30 * time.Second
+	DefaultJitter      = 0.5
+)
+
+// RetryPolicy는 실패한 로그인이나 Job을 다시 시도하는 방법입니다.
+// n번째 재시도 전에는 Backoff×2^(n-1)을 MaxBackoff까지 늘려 기다리고,
+// Jitter만큼 줄인 범위에서 무작위로 골라 여러 장비가 한꺼번에 다시 접속하지 않게 합니다.
+type RetryPolicy struct {
+	// MaxAttempts는 처음 시도를 포함한 최대 시도 횟수입니다. 1 이하이면 다시 시도하지 않습니다.
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	// Jitter는 0~1입니다. 0.5이면 기다리는 시간이 계산한 값의 50~100% 사이입니다.
+	Jitter float64
+	// RetryOn은 다시 시도할 에러 종류입니다. 비어 있으면 DefaultRetryOn입니다.
+	RetryOn []ErrorClass
+	// OnRetry가 nil이 아니면 다시 시도하기 전에 부릅니다. (진행 상황 출력용)
+	OnRetry func(r inventory.Router, attempt int, err error, delay time.Duration)
+}
+
+// Retryable은 err를 다시 시도할지 확인합니다.
+func (p RetryPolicy) Retryable(err error) bool {
+	on := p.RetryOn
+	if len(on) == 0 {
+		on = DefaultRetryOn
+	}
+	class := Classify(err)
+	for _, c := range on {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// delay는 attempt번째 시도가 실패한 뒤 기다릴 시간입니다.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if j := min(max(p.Jitter, 0), 1); j > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * j * float64(d))
+	}
+	return d
+}
+
+// Do는 op가 성공하거나, 다시 시도하지 않을 에러를 돌려주거나, MaxAttempts번 시도할 때까지 op를 부릅니다.
+// ctx가 끝나면 기다리지 않고 마지막 에러를 돌려줍니다. 두 번 이상 시도했으면 에러에 시도 횟수를 붙입니다.
+func (p RetryPolicy) Do(ctx context.Context, r inventory.Router, op func() error) error {
+	return p.do(ctx, r, p.Retryable, op)
+}
+
+func (p RetryPolicy) do(ctx context.Context, r inventory.Router, retryable func(error) bool, op func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			if err != nil && attempt > 1 {
+				err = &retryError{err: err, attempts: attempt}
+			}
+			return err
+		}
+
+		d := p.delay(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(r, attempt, err, d)
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return &retryError{err: err, attempts: attempt}
+		}
+	}
+}
+
+// retryError는 여러 번 시도한 끝에 실패한 에러입니다. 단계(runner.StageOf)와 원래 에러는 그대로 꺼낼 수 있습니다.
+type retryError struct {
+	err      error
+	attempts int
+}
+
+func (e *retryError) Error() string {
+	return fmt.Sprintf("%s (after %d attempts)", e.err, e.attempts)
+}
+
+func (e *retryError) Unwrap() error {
+	return e.err
+}
+
+type retryKey struct{}
+
+// WithRetry는 ctx로 여는 세션의 로그인을 p에 따라 다시 시도하도록 합니다. Retry로 감싼 Job도 p를 씁니다.
+func WithRetry(ctx context.Context, p RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryKey{}, p)
+}
+
+// retryPolicy는 ctx의 재시도 정책입니다. 없으면 다시 시도하지 않습니다.
+func retryPolicy(ctx context.Context) RetryPolicy {
+	p, _ := ctx.Value(retryKey{}).(RetryPolicy)
+	return p
+}
+
+// Retry는 job이 실패하면 ctx의 재시도 정책(WithRetry)에 따라 job 전체를 다시 실행합니다.
+// 로그인 실패는 Open이 이미 다시 시도했으므로 여기서는 로그인 뒤(명령 중에 끊김 등)의 실패만 다시 시도합니다.
+// job을 처음부터 다시 실행하므로 show 명령처럼 여러 번 실행해도 되는 Job에만 씁니다. (설정 변경에는 쓰지 않습니다)
+func Retry[T any](job runner.Job[T]) runner.Job[T] {
+	return func(ctx context.Context, r inventory.Router) (T, error) {
+		p := retryPolicy(ctx)
+		var out T
+		err := p.do(ctx, r, func(err error) bool {
+			return runner.StageOf(err) != StageOpen && p.Retryable(err)
+		}, func() error {
+			var err error
+			out, err = job(ctx, r)
+			return err
+		})
+		return out, err
+	}
+}
+
+// LoginLimiter는 새로 로그인하는 속도를 초당 rate번으로 제한합니다. 여러 번 쉬었으면 burst번까지 한꺼번에 허용합니다.
+// 여러 Job이 함께 쓰도록 WithLoginLimiter로 ctx에 넣어 둡니다. 풀에서 다시 내주는 세션은 세지 않습니다.
+type LoginLimiter struct {
+	interval time.Duration
+	burst    int
+
+	mu sync.Mutex
+	// next는 다음 로그인이 burst 없이 허용되는 시각입니다.
+	next time.Time
+}
+
+// NewLoginLimiter는 초당 rate번까지 로그인을 허용하는 LoginLimiter를 만듭니다. burst가 1보다 작으면 1입니다.
+func NewLoginLimiter(rate float64, burst int) *LoginLimiter {
+	return &LoginLimiter{interval: time.Duration(float64(time.Second) / rate), burst: max(burst, 1)}
+}
+
+// Wait는 로그인할 차례가 될 때까지 기다립니다. ctx가 먼저 끝나면 ctx의 에러입니다.
+func (l *LoginLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	at := l.next.Add(-time.Duration(l.burst-1) * l.interval)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	d := time.Until(at)
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type limiterKey struct{}
+
+// WithLoginLimiter는 ctx로 여는 세션(CLI, NETCONF)이 로그인하기 전에 l을 기다리도록 합니다.
+func WithLoginLimiter(ctx context.Context, l *LoginLimiter) context.Context {
+	return context.WithValue(ctx, limiterKey{}, l)
+}
+
+// login은 ctx의 LoginLimiter를 기다린 뒤 ctx의 재시도 정책에 따라 op(로그인)를 부릅니다.
+// 다시 시도할 때마다 LoginLimiter를 다시 기다립니다.
+func login(ctx context.Context, r inventory.Router, op func() error) error {
+	l, _ := ctx.Value(limiterKey{}).(*LoginLimiter)
+	return retryPolicy(ctx).Do(ctx, r, func() error {
+		if l != nil {
+			if err := l.Wait(ctx); err != nil {
+				return runner.Fail(StageOpen, err)
+			}
+		}
+		return op()
+	})
+}
+
+// Flags는 로그인 재시도와 속도 제한 명령행 플래그입니다.
+type Flags struct {
+	Retry      RetryPolicy
+	LoginRate  float64
+	LoginBurst int
+	retryOn    string
+}
+
+// Register는 -retries, -retry-backoff, -retry-max-backoff, -retry-jitter, -retry-on, -login-rate, -login-burst 플래그를 등록합니다.
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.IntVar(&f.Retry.MaxAttempts, "retries", DefaultMaxAttempts, "maximum login attempts per host (1 = no retry)")
+	fs.DurationVar(&f.Retry.Backoff, "retry-backoff", DefaultBackoff, "delay before the first retry, doubled on each further retry")
+	fs.DurationVar(&f.Retry.MaxBackoff, "retry-max-backoff", DefaultMaxBackoff, "maximum delay between retries")
+	fs.Float64Var(&f.Retry.Jitter, "retry-jitter", DefaultJitter, "randomly shorten each delay by up to this fraction (0-1)")
+	fs.StringVar(&f.retryOn, "retry-on", joinClasses(DefaultRetryOn),
+		"comma-separated error classes to retry: auth, hostkey, resolve, timeout, refused, unreachable, closed, command, other")
+	fs.Float64Var(&f.LoginRate, "login-rate", 0, "maximum new SSH logins per second across all hosts (0 = no limit)")
+	fs.IntVar(&f.LoginBurst, "login-burst", 1, "logins allowed at once after being idle with -login-rate")
+}
+
+// Context는 플래그에 맞게 로그인을 다시 시도하고 속도를 제한하는 ctx를 돌려줍니다.
+func (f *Flags) Context(ctx context.Context) (context.Context, error) {
+	if f.retryOn != "" {
+		f.Retry.RetryOn = nil
+		for _, s := range strings.Split(f.retryOn, ",") {
+			c := ErrorClass(strings.TrimSpace(s))
+			switch c {
+			case ClassAuth, ClassHostKey, ClassResolve, ClassTimeout, ClassRefused, ClassUnreachable, ClassClosed, ClassCommand, ClassOther:
+			default:
+				return nil, fmt.Errorf("unknown error class %q in -retry-on", s)
+			}
+			f.Retry.RetryOn = append(f.Retry.RetryOn, c)
+		}
+	}
+	ctx = WithRetry(ctx, f.Retry)
+	if f.LoginRate > 0 {
+		ctx = WithLoginLimiter(ctx, NewLoginLimiter(f.LoginRate, f.LoginBurst))
+	}
+	return ctx, nil
+}
+
+func joinClasses(cs []ErrorClass) string {
+	s := make([]string, len(cs))
+	for i, c := range cs {
+		s[i] = string(c)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,72 @@
+package facts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tucker-study/01-Go-Start/runner"
+)
+
+// StageCache는 모은 사실을 캐시에 저장하다 실패한 단계입니다.
+const StageCache runner.Stage = "cache"
+
+// DefaultDir는 사실 캐시의 기본 디렉터리입니다.
+const DefaultDir = "facts"
+
+// Cache는 장비마다 <Dir>/<hostname>.json에 사실을 저장하는 캐시입니다.
+type Cache struct {
+	Dir string
+}
+
+func (c *Cache) path(host string) string {
+	return filepath.Join(c.Dir, host+".json")
+}
+
+// Load는 host의 사실을 읽습니다. 캐시에 없으면 fs.ErrNotExist를 감싼 에러입니다.
+func (c *Cache) Load(host string) (*Facts, error) {
+	b, err := os.ReadFile(c.path(host))
+	if err != nil {
+		return nil, err
+	}
+	var f Facts
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", c.path(host), err)
+	}
+	return &f, nil
+}
+
+// Save는 f를 저장합니다. 쓰다가 실패해도 예전 파일이 깨지지 않도록 임시 파일을 옮깁니다.
+func (c *Cache) Save(f *Facts) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path(f.Host) + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(f.Host))
+}
+
+// Hosts는 캐시에 사실이 있는 장비들입니다. (이름 순)
+func (c *Cache) Hosts() ([]string, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, e := range entries {
+		if host, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
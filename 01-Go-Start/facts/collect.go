@@ -0,0 +1,307 @@
+package facts
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 사실을 모으는 항목들입니다. Facts.Errors의 키로도 씁니다.
+const (
+	SectionVersion    = "version"
+	SectionInterfaces = "interfaces"
+	SectionInventory  = "inventory"
+	SectionNeighbors  = "neighbors"
+	SectionRoutes     = "routes"
+)
+
+// Sections는 Collect가 모으는 순서입니다.
+var Sections = []string{SectionVersion, SectionInterfaces, SectionInventory, SectionNeighbors, SectionRoutes}
+
+// commands는 항목마다 보내는 명령입니다. 출력은 textfsm 내장 템플릿으로 파싱하므로,
+// 템플릿이 없는 플랫폼에서는 그 항목이 Errors에 남습니다.
+var commands = map[string]string{
+	SectionVersion:    "show version",
+	SectionInterfaces: "show ip interface brief",
+	SectionInventory:  "show inventory",
+	SectionNeighbors:  "show lldp neighbors detail",
+	SectionRoutes:     "show ip route summary",
+}
+
+type interfaceRow struct {
+	Name   string `textfsm:"INTERFACE"`
+	IP     string `textfsm:"IP_ADDRESS"`
+	VRF    string `textfsm:"VRF"`
+	Status string `textfsm:"STATUS"`
+	Proto  string `textfsm:"PROTO"`
+	Admin  string `textfsm:"ADMIN"`
+	MTU    int    `textfsm:"MTU"`
+}
+
+type moduleRow struct {
+	Kind   string `textfsm:"KIND"`
+	Name   string `textfsm:"NAME"`
+	Descr  string `textfsm:"DESCR"`
+	PID    string `textfsm:"PID"`
+	VID    string `textfsm:"VID"`
+	Serial string `textfsm:"SN"`
+}
+
+type routeRow struct {
+	Source   string `textfsm:"SOURCE"`
+	Networks int    `textfsm:"NETWORKS"`
+	Subnets  int    `textfsm:"SUBNETS"`
+	Routes   int    `textfsm:"ROUTES"`
+}
+
+// Collect는 열린 세션에서 모든 항목을 모읍니다. 일부 항목이 실패해도(명령이 없는 플랫폼 등)
+// 나머지를 모으고 이유를 Errors에 남깁니다. 모든 항목이 실패하면 첫 번째 에러를 돌려줍니다.
+// raw에는 명령마다 받은 출력이 남습니다.
+func Collect(s *device.Session) (*Facts, string, error) {
+	f := &Facts{
+		Host:      s.Host.Hostname,
+		Platform:  s.Host.Platform,
+		Vendor:    Vendor(s.Host.Platform),
+		Collected: time.Now().UTC(),
+	}
+
+	var first error
+	var b strings.Builder
+	for _, section := range Sections {
+		cmd := commands[section]
+		out, err := collect(s, f, section, cmd)
+		if out != "" {
+			b.WriteString("### " + cmd + "\n" + out + "\n")
+		}
+		if err == nil {
+			continue
+		}
+		if f.Errors == nil {
+			f.Errors = map[string]string{}
+		}
+		f.Errors[section] = err.Error()
+		if first == nil {
+			first = err
+		}
+	}
+	if len(f.Errors) == len(Sections) {
+		return f, b.String(), first
+	}
+	return f, b.String(), nil
+}
+
+// collect는 항목 하나를 모아 f에 채우고 받은 출력을 돌려줍니다.
+func collect(s *device.Session, f *Facts, section, cmd string) (string, error) {
+	if section == SectionNeighbors {
+		// 이웃이 없는 장비도 있으므로 파싱한 줄이 없어도 실패가 아닙니다.
+		ns, out, err := Neighbors(s, ProtocolLLDP)
+		f.Neighbors = ns
+		return out, err
+	}
+
+	rs, err := s.Send(cmd)
+	if err != nil {
+		return "", err
+	}
+	records, err := s.Parse(rs)
+	if err != nil {
+		return rs.Result, err
+	}
+
+	switch section {
+	case SectionVersion:
+		v, err := textfsm.DecodeOne[textfsm.VersionInfo](records)
+		if err != nil {
+			return rs.Result, runner.Fail(device.StageParse, err)
+		}
+		f.Hostname, f.Version, f.Uptime = v.Hostname, v.Version, v.Uptime
+		f.UptimeSeconds = uptimeSeconds(v.Uptime)
+		if len(v.Hardware) > 0 {
+			f.Model = v.Hardware[0]
+		}
+		f.addSerials(v.Serial...)
+	case SectionInterfaces:
+		if f.Interfaces, err = ParseInterfaces(records); err != nil {
+			return rs.Result, err
+		}
+	case SectionInventory:
+		rows, err := textfsm.Decode[moduleRow](records)
+		if err != nil {
+			return rs.Result, runner.Fail(device.StageParse, err)
+		}
+		for _, r := range rows {
+			m := r.normalize()
+			f.Modules = append(f.Modules, m)
+			f.addSerials(m.Serial)
+		}
+	case SectionRoutes:
+		if f.Routes, err = ParseRoutes(records); err != nil {
+			return rs.Result, err
+		}
+	}
+	return rs.Result, nil
+}
+
+// ParseInterfaces는 "show ip interface brief"를 파싱한 레코드를 플랫폼과 상관없는 Interface로 바꿉니다.
+func ParseInterfaces(records []map[string]interface{}) ([]Interface, error) {
+	rows, err := textfsm.Decode[interfaceRow](records)
+	if err != nil {
+		return nil, runner.Fail(device.StageParse, err)
+	}
+	var ifs []Interface
+	for _, r := range rows {
+		ifs = append(ifs, r.normalize())
+	}
+	return ifs, nil
+}
+
+// ParseRoutes는 "show ip route summary"를 파싱한 레코드를 출처별 경로 수로 모읍니다.
+func ParseRoutes(records []map[string]interface{}) (*RouteSummary, error) {
+	rows, err := textfsm.Decode[routeRow](records)
+	if err != nil {
+		return nil, runner.Fail(device.StageParse, err)
+	}
+	return routeSummary(rows), nil
+}
+
+// Vendor는 scrapligo 플랫폼 이름의 벤더 부분입니다. (cisco_iosxe → cisco)
+func Vendor(platform string) string {
+	vendor, _, _ := strings.Cut(platform, "_")
+	return vendor
+}
+
+func (f *Facts) addSerials(serials ...string) {
+	for _, sn := range serials {
+		sn = strings.TrimSpace(sn)
+		if sn == "" {
+			continue
+		}
+		dup := false
+		for _, have := range f.Serials {
+			dup = dup || have == sn
+		}
+		if !dup {
+			f.Serials = append(f.Serials, sn)
+		}
+	}
+}
+
+// normalize는 플랫폼마다 다른 상태 표기를 AdminUp/OperUp으로 바꿉니다.
+//
+//	cisco_iosxe  STATUS=administratively down|up|down, PROTO=up|down
+//	cisco_nxos   ADMIN=up|down, STATUS=link 상태, PROTO=up|down
+//	arista_eos   STATUS=adminDown|up|down, PROTO=up|down|lowerLayerDown
+func (r interfaceRow) normalize() Interface {
+	i := Interface{
+		Name:   CanonicalInterface(r.Name),
+		VRF:    r.VRF,
+		OperUp: r.Proto == "up",
+		MTU:    r.MTU,
+	}
+	if r.IP != "unassigned" {
+		i.IPv4 = r.IP
+	}
+	if r.Admin != "" {
+		i.AdminUp = r.Admin == "up"
+	} else {
+		i.AdminUp = !strings.Contains(strings.ToLower(r.Status), "admin")
+	}
+	return i
+}
+
+// normalize는 인벤토리 줄을 Module로 바꿉니다. 이름이 없는 줄(arista_eos의 섀시, 파워 슬롯)은 이름을 지어 줍니다.
+func (r moduleRow) normalize() Module {
+	m := Module{Name: r.Name, Description: r.Descr, PID: r.PID, VID: r.VID, Serial: r.Serial}
+	switch {
+	case r.Kind != "":
+		m.Name = strings.TrimSpace(r.Kind + " " + r.Name)
+	case m.Name == "":
+		m.Name = "Chassis"
+	}
+	return m
+}
+
+// routeSummary는 라우팅 요약 줄들을 출처별 경로 수로 모읍니다.
+// Total 줄이 있으면(cisco_iosxe, arista_eos) 그 값을, 없으면(cisco_nxos) 합을 Total로 씁니다.
+func routeSummary(rows []routeRow) *RouteSummary {
+	rs := &RouteSummary{Sources: map[string]int{}}
+	total, sum := -1, 0
+	for _, r := range rows {
+		n := r.Routes + r.Networks + r.Subnets
+		source := routeSource(r.Source)
+		switch source {
+		case "total":
+			total = n
+			continue
+		case "internal":
+			// cisco_iosxe의 internal은 경로가 아니라 내부 자료구조 수입니다.
+			continue
+		}
+		rs.Sources[source] += n
+		sum += n
+	}
+	rs.Total = sum
+	if total >= 0 {
+		rs.Total = total
+	}
+	return rs
+}
+
+// routeSource는 출처 이름을 맞춥니다. (bgp 65001, bgp-65003 → bgp, direct → connected, static (persistent) → static)
+func routeSource(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if i := strings.IndexAny(s, " -("); i > 0 {
+		s = s[:i]
+	}
+	if s == "direct" {
+		return "connected"
+	}
+	return s
+}
+
+// Result는 장비 한 대의 사실 수집 결과입니다. Raw에는 명령마다 받은 출력이 남습니다.
+type Result struct {
+	Facts *Facts
+	// Cached는 장비에 접속하지 않고 캐시의 사실을 썼는지입니다.
+	Cached bool
+
+	runner.Output
+}
+
+// Job은 장비마다 사실을 모아 cache에 저장하는 Job을 만듭니다.
+// maxAge가 0보다 크면 그보다 최근에 모은 캐시가 있는 장비는 접속하지 않습니다. cache가 nil이면 저장하지 않습니다.
+// 명령 중에 세션이 끊기는 등으로 실패하면 ctx의 재시도 정책(device.WithRetry)에 따라 처음부터 다시 모읍니다.
+func Job(cache *Cache, maxAge time.Duration) runner.Job[Result] {
+	return device.Retry(func(ctx context.Context, r inventory.Router) (Result, error) {
+		var res Result
+		if cache != nil && maxAge > 0 {
+			f, err := cache.Load(r.Hostname)
+			if err == nil && time.Since(f.Collected) < maxAge {
+				res.Facts, res.Cached = f, true
+				return res, nil
+			}
+		}
+
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return res, err
+		}
+		defer s.Close()
+
+		res.Facts, res.Raw, err = Collect(s)
+		if err != nil {
+			return res, err
+		}
+		if cache != nil {
+			if err := cache.Save(res.Facts); err != nil {
+				return res, runner.Fail(StageCache, err)
+			}
+		}
+		return res, nil
+	})
+}
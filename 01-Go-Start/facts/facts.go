@@ -0,0 +1,146 @@
+package facts
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 장비마다 여러 show 명령으로 사실(facts)을 모아 벤더와 상관없는 형태로 정리하는 패키지입니다.
+// 버전/모델/시리얼, 인터페이스, 인벤토리 모듈, LLDP 이웃, 라우팅 요약을 모으고,
+// 장비마다 <dir>/<hostname>.json으로 캐시해 다른 도구가 장비에 접속하지 않고 읽을 수 있게 합니다.
+//
+//	cache := &facts.Cache{Dir: facts.DefaultDir}
+//	f, raw, err := facts.Collect(s)
+//	err = cache.Save(f)
+//	f, err = cache.Load("rtr1")
+
+// Facts는 장비 한 대의 사실입니다. 가져오지 못한 항목은 비어 있고, 이유는 Errors에 남습니다.
+type Facts struct {
+	Host      string    `json:"host"`
+	Platform  string    `json:"platform"`
+	Vendor    string    `json:"vendor"`
+	Collected time.Time `json:"collected"`
+
+	Hostname string   `json:"hostname,omitempty"`
+	Model    string   `json:"model,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	Serials  []string `json:"serials,omitempty"`
+	Uptime   string   `json:"uptime,omitempty"`
+	// UptimeSeconds는 Uptime을 초로 바꾼 값입니다. (플랫폼마다 Uptime 형식이 다릅니다)
+	UptimeSeconds int64 `json:"uptime_seconds,omitempty"`
+
+	Interfaces []Interface       `json:"interfaces,omitempty"`
+	Modules    []Module          `json:"modules,omitempty"`
+	Neighbors  []Neighbor        `json:"neighbors,omitempty"`
+	Routes     *RouteSummary     `json:"routes,omitempty"`
+	Errors     map[string]string `json:"errors,omitempty"`
+}
+
+// Interface는 IP 인터페이스 하나입니다. 이름은 줄이지 않은 형태입니다. (Gi1 → GigabitEthernet1)
+type Interface struct {
+	Name    string `json:"name"`
+	IPv4    string `json:"ipv4,omitempty"`
+	VRF     string `json:"vrf,omitempty"`
+	AdminUp bool   `json:"admin_up"`
+	OperUp  bool   `json:"oper_up"`
+	MTU     int    `json:"mtu,omitempty"`
+}
+
+// Module은 인벤토리의 부품 하나입니다. (섀시, 라인카드, 파워 등)
+type Module struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	PID         string `json:"pid,omitempty"`
+	VID         string `json:"vid,omitempty"`
+	Serial      string `json:"serial,omitempty"`
+}
+
+// Neighbor는 LLDP나 CDP로 보이는 이웃 하나입니다.
+type Neighbor struct {
+	// Protocol은 이웃을 본 프로토콜입니다. (ProtocolLLDP, ProtocolCDP)
+	Protocol       string   `json:"protocol,omitempty"`
+	LocalInterface string   `json:"local_interface"`
+	Name           string   `json:"name"`
+	Interface      string   `json:"interface,omitempty"`
+	ChassisID      string   `json:"chassis_id,omitempty"`
+	MgmtAddress    string   `json:"mgmt_address,omitempty"`
+	Capabilities   []string `json:"capabilities,omitempty"`
+	// Platform은 CDP가 알려 주는 하드웨어 이름입니다. (cisco C8000V, N9K-C9300v)
+	Platform string `json:"platform,omitempty"`
+	// Description은 LLDP System Description이나 CDP Version으로, 이웃의 OS를 알아볼 때 씁니다.
+	Description string `json:"description,omitempty"`
+}
+
+// RouteSummary는 기본 VRF의 경로 수입니다. Sources는 출처(connected, static, bgp, ...)별 경로 수입니다.
+type RouteSummary struct {
+	Total   int            `json:"total"`
+	Sources map[string]int `json:"sources"`
+}
+
+// Up은 관리상/동작상 모두 올라와 있는 인터페이스 수입니다.
+func (f *Facts) Up() int {
+	n := 0
+	for _, i := range f.Interfaces {
+		if i.AdminUp && i.OperUp {
+			n++
+		}
+	}
+	return n
+}
+
+// Interface는 이름이 name인 인터페이스를 찾습니다. 줄인 이름으로 찾아도 됩니다.
+func (f *Facts) Interface(name string) (Interface, bool) {
+	name = CanonicalInterface(name)
+	for _, i := range f.Interfaces {
+		if strings.EqualFold(i.Name, name) {
+			return i, true
+		}
+	}
+	return Interface{}, false
+}
+
+// interfaceNames는 줄여 쓰는 인터페이스 이름의 원래 이름입니다. 앞에서부터 맞는 것을 씁니다.
+var interfaceNames = []string{
+	"GigabitEthernet", "TenGigabitEthernet", "TwentyFiveGigE", "FortyGigabitEthernet", "HundredGigE",
+	"FastEthernet", "Ethernet", "Loopback", "Port-channel", "Vlan", "Tunnel", "Management", "mgmt",
+}
+
+var interfaceName = regexp.MustCompile(`^([A-Za-z][A-Za-z-]*?)\s*(\d\S*)$`)
+
+// CanonicalInterface는 줄여 쓴 인터페이스 이름을 원래 이름으로 바꿉니다. (Gi1 → GigabitEthernet1, Eth1/1 → Ethernet1/1)
+// 모르는 이름은 그대로 둡니다.
+func CanonicalInterface(name string) string {
+	m := interfaceName.FindStringSubmatch(strings.TrimSpace(name))
+	if m == nil {
+		return name
+	}
+	for _, full := range interfaceNames {
+		if len(m[1]) <= len(full) && strings.EqualFold(full[:len(m[1])], m[1]) {
+			return full + m[2]
+		}
+	}
+	return name
+}
+
+var uptimePart = regexp.MustCompile(`(\d+)\s+(year|week|day|hour|minute|second)`)
+
+var uptimeUnits = map[string]int64{
+	"year":   365 * 24 * 3600,
+	"week":   7 * 24 * 3600,
+	"day":    24 * 3600,
+	"hour":   3600,
+	"minute": 60,
+	"second": 1,
+}
+
+// uptimeSeconds는 "1 week, 2 days, 3 hours", "2 day(s), 3 hour(s)" 같은 가동 시간을 초로 바꿉니다.
+func uptimeSeconds(s string) int64 {
+	var total int64
+	for _, m := range uptimePart.FindAllStringSubmatch(s, -1) {
+		n, _ := strconv.ParseInt(m[1], 10, 64)
+		total += n * uptimeUnits[m[2]]
+	}
+	return total
+}
@@ -0,0 +1,137 @@
+package facts
+
+import (
+	"strings"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 이웃을 찾는 프로토콜입니다. Neighbor.Protocol에 남습니다.
+const (
+	ProtocolLLDP = "lldp"
+	ProtocolCDP  = "cdp"
+)
+
+// Protocols는 이웃을 찾을 때 쓸 수 있는 프로토콜입니다.
+var Protocols = []string{ProtocolLLDP, ProtocolCDP}
+
+var neighborCommands = map[string]string{
+	ProtocolLLDP: "show lldp neighbors detail",
+	ProtocolCDP:  "show cdp neighbors detail",
+}
+
+type neighborRow struct {
+	Local     string `textfsm:"LOCAL_INTERFACE"`
+	Chassis   string `textfsm:"CHASSIS_ID"`
+	PortID    string `textfsm:"NEIGHBOR_PORT_ID"`
+	PortDescr string `textfsm:"NEIGHBOR_INTERFACE"`
+	Name      string `textfsm:"NEIGHBOR_NAME"`
+	Caps      string `textfsm:"CAPABILITIES"`
+	Mgmt      string `textfsm:"MGMT_ADDRESS"`
+	Descr     string `textfsm:"SYSTEM_DESCRIPTION"`
+}
+
+type cdpRow struct {
+	Local    string `textfsm:"LOCAL_INTERFACE"`
+	Name     string `textfsm:"NEIGHBOR_NAME"`
+	Port     string `textfsm:"NEIGHBOR_INTERFACE"`
+	Caps     string `textfsm:"CAPABILITIES"`
+	Mgmt     string `textfsm:"MGMT_ADDRESS"`
+	Platform string `textfsm:"PLATFORM"`
+	Version  string `textfsm:"SOFTWARE_VERSION"`
+}
+
+// Neighbors는 protocol로 보이는 이웃들을 모으고 받은 출력을 돌려줍니다. 이웃이 없으면 빈 목록입니다.
+// 플랫폼에 그 프로토콜의 템플릿이 없으면(arista_eos의 CDP) 명령을 보내지 않고 textfsm.ErrNoTemplate입니다.
+func Neighbors(s *device.Session, protocol string) ([]Neighbor, string, error) {
+	cmd := neighborCommands[protocol]
+	ix, err := textfsm.Default()
+	if err != nil {
+		return nil, "", runner.Fail(device.StageParse, err)
+	}
+	if _, err := ix.Template(s.Host.Platform, cmd); err != nil {
+		return nil, "", runner.Fail(device.StageParse, err)
+	}
+
+	rs, err := s.Send(cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	records, err := ix.Parse(s.Host.Platform, cmd, rs.Result)
+	if err != nil {
+		return nil, rs.Result, runner.Fail(device.StageParse, err)
+	}
+
+	var ns []Neighbor
+	switch protocol {
+	case ProtocolCDP:
+		rows, err := textfsm.Decode[cdpRow](records)
+		if err != nil {
+			return nil, rs.Result, runner.Fail(device.StageParse, err)
+		}
+		for _, r := range rows {
+			ns = append(ns, r.normalize())
+		}
+	default:
+		rows, err := textfsm.Decode[neighborRow](records)
+		if err != nil {
+			return nil, rs.Result, runner.Fail(device.StageParse, err)
+		}
+		for _, r := range rows {
+			ns = append(ns, r.normalize())
+		}
+	}
+	return ns, rs.Result, nil
+}
+
+// normalize는 LLDP 이웃을 Neighbor로 바꿉니다.
+// Port ID가 인터페이스 이름이 아니면(MAC 주소 등) Port Description을 이웃 인터페이스로 씁니다.
+func (r neighborRow) normalize() Neighbor {
+	n := Neighbor{
+		Protocol:       ProtocolLLDP,
+		LocalInterface: CanonicalInterface(r.Local),
+		Name:           r.Name,
+		ChassisID:      r.Chassis,
+		MgmtAddress:    r.Mgmt,
+		Interface:      CanonicalInterface(r.PortID),
+		Capabilities:   capabilities(r.Caps),
+		Description:    r.Descr,
+	}
+	if !interfaceName.MatchString(r.PortID) && r.PortDescr != "" {
+		n.Interface = CanonicalInterface(r.PortDescr)
+	}
+	return n
+}
+
+// normalize는 CDP 이웃을 Neighbor로 바꿉니다. Device ID 뒤의 시리얼 번호는 뗍니다. (nx2(9N3KD63KWT1) → nx2)
+func (r cdpRow) normalize() Neighbor {
+	name, _, _ := strings.Cut(r.Name, "(")
+	return Neighbor{
+		Protocol:       ProtocolCDP,
+		LocalInterface: CanonicalInterface(r.Local),
+		Name:           name,
+		Interface:      CanonicalInterface(r.Port),
+		MgmtAddress:    r.Mgmt,
+		Capabilities:   capabilities(r.Caps),
+		Platform:       r.Platform,
+		Description:    r.Version,
+	}
+}
+
+func capabilities(s string) []string {
+	var caps []string
+	for _, c := range strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' }) {
+		if name, ok := capabilityNames[c]; ok {
+			c = name
+		}
+		caps = append(caps, strings.ToLower(c))
+	}
+	return caps
+}
+
+// capabilityNames는 LLDP 능력 표기를 이름으로 바꿉니다. (arista_eos는 이미 이름으로 보여줍니다)
+var capabilityNames = map[string]string{
+	"R": "router", "B": "bridge", "T": "telephone", "C": "docsis", "W": "wlan", "P": "repeater", "S": "station", "O": "other",
+}
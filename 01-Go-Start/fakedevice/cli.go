@@ -0,0 +1,387 @@
+package fakedevice
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tucker-study/01-Go-Start/confdiff"
+)
+
+// level은 CLI 권한 단계입니다.
+type level int
+
+const (
+	exec level = iota
+	privileged
+	configuration
+)
+
+// frame은 설정 모드에서 들어가 있는 섹션입니다.
+type frame struct {
+	node   *confdiff.Node
+	indent int
+	// flat은 들여쓰지 않고 시작한 섹션입니다. exit가 올 때까지 들여쓰지 않은 줄도 이 섹션 아래로 봅니다.
+	flat bool
+	// indented는 들여쓴 하위 줄을 받은 섹션입니다. 그 뒤의 들여쓰지 않은 줄은 최상위입니다.
+	indented bool
+}
+
+// session은 SSH 셸 하나의 CLI 상태입니다.
+type session struct {
+	srv    *Server
+	w      io.Writer
+	r      *bufio.Reader
+	level  level
+	stack  []frame
+	paging int
+}
+
+func newSession(srv *Server, rw io.ReadWriter) *session {
+	s := &session{srv: srv, w: rw, r: bufio.NewReader(rw), level: privileged, paging: srv.Device.Paging}
+	if srv.Device.Secret != "" {
+		s.level = exec
+	}
+	return s
+}
+
+// run은 연결이 끊기거나 exit를 받을 때까지 명령을 처리하고 종료 코드를 돌려줍니다.
+func (s *session) run() uint32 {
+	fmt.Fprintf(s.w, "\n%s", s.prompt())
+	for {
+		line, err := s.readLine(true)
+		if err != nil {
+			return 0
+		}
+		if strings.TrimSpace(line) != "" {
+			s.srv.record(line)
+		}
+		if s.srv.Device.Latency > 0 {
+			time.Sleep(s.srv.Device.Latency)
+		}
+
+		var quit bool
+		if s.level == configuration {
+			s.configure(line)
+		} else {
+			quit = s.command(strings.Join(strings.Fields(line), " "))
+		}
+		if quit {
+			return 0
+		}
+		if _, err := io.WriteString(s.w, s.prompt()); err != nil {
+			return 0
+		}
+	}
+}
+
+func (srv *Server) record(line string) {
+	srv.mu.Lock()
+	srv.history = append(srv.history, line)
+	srv.mu.Unlock()
+}
+
+// hostname은 running config의 hostname입니다. 설정을 바꾸면 프롬프트도 바뀝니다.
+func (s *session) hostname() string {
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+	for _, c := range s.srv.running.Children {
+		if name, ok := strings.CutPrefix(c.Text, "hostname "); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return s.srv.Device.Hostname
+}
+
+var modeName = regexp.MustCompile(`[^\w-]`)
+
+// sectionMode는 섹션 줄에 맞는 설정 모드 이름입니다. (interface → if, router → router)
+func sectionMode(text string) string {
+	word := strings.Fields(text)[0]
+	switch word {
+	case "interface":
+		return "if"
+	case "address-family":
+		return "router-af"
+	}
+	word = modeName.ReplaceAllString(word, "")
+	return word[:min(len(word), 20)]
+}
+
+func (s *session) prompt() string {
+	switch s.level {
+	case exec:
+		return s.hostname() + ">"
+	case configuration:
+		mode := "config"
+		for i := len(s.stack) - 1; i >= 0; i-- {
+			if f := s.stack[i]; f.flat || f.indented {
+				mode += "-" + sectionMode(f.node.Text)
+				break
+			}
+		}
+		return fmt.Sprintf("%s(%s)#", s.hostname(), mode)
+	}
+	return s.hostname() + "#"
+}
+
+// readLine은 줄 하나를 읽습니다. echo가 true면 받은 글자를 그대로 돌려보냅니다. (scrapligo는 에코를 기다립니다)
+func (s *session) readLine(echo bool) (string, error) {
+	var line []byte
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch c {
+		case '\r', '\n':
+			// \r\n은 한 줄로 봅니다.
+			if c == '\r' && s.r.Buffered() > 0 {
+				if next, _ := s.r.Peek(1); next[0] == '\n' {
+					s.r.ReadByte()
+				}
+			}
+			if _, err := io.WriteString(s.w, "\n"); err != nil {
+				return "", err
+			}
+			return string(line), nil
+		case 0x7f, '\b':
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+				if echo {
+					io.WriteString(s.w, "\b \b")
+				}
+			}
+		case 0x03:
+			// Ctrl+C는 입력 중인 줄을 버립니다.
+			line = line[:0]
+			io.WriteString(s.w, "\n")
+			return "", nil
+		default:
+			line = append(line, c)
+			if echo {
+				if _, err := s.w.Write([]byte{c}); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+}
+
+// output은 명령 결과를 씁니다. 페이징 중이면 한 화면마다 --More--에서 키 입력을 기다립니다.
+// 스페이스는 다음 화면, 엔터는 한 줄 더, 그 밖의 키는 나머지를 버립니다.
+func (s *session) output(text string) {
+	if text == "" {
+		return
+	}
+	lines := strings.Split(text, "\n")
+	if s.paging <= 0 || len(lines) <= s.paging {
+		io.WriteString(s.w, text+"\n")
+		return
+	}
+
+	page := s.paging
+	for len(lines) > 0 {
+		n := min(page, len(lines))
+		io.WriteString(s.w, strings.Join(lines[:n], "\n")+"\n")
+		lines = lines[n:]
+		if len(lines) == 0 {
+			return
+		}
+
+		io.WriteString(s.w, " --More-- ")
+		c, err := s.r.ReadByte()
+		io.WriteString(s.w, "\r          \r")
+		if err != nil {
+			return
+		}
+		switch c {
+		case ' ':
+			page = s.paging
+		case '\r', '\n':
+			page = 1
+		default:
+			return
+		}
+	}
+}
+
+var terminal = regexp.MustCompile(`^term\w* (len|wid)\w* (\d+)$`)
+
+// command는 exec/privileged 모드의 명령 하나를 처리합니다. 세션을 끝내야 하면 true입니다.
+func (s *session) command(cmd string) bool {
+	if cmd == "" {
+		return false
+	}
+	switch {
+	case abbrev(cmd, "exit"), abbrev(cmd, "logout"), abbrev(cmd, "quit"):
+		return true
+	case abbrev(cmd, "enable"):
+		s.enable()
+		return false
+	case s.level == exec:
+		// exec 모드에서는 show 명령만 됩니다.
+		if !strings.HasPrefix("show", strings.Fields(cmd)[0]) {
+			s.output(s.srv.prof.invalid)
+			return false
+		}
+	case abbrev(cmd, "disable"):
+		s.level = exec
+		return false
+	case abbrev(cmd, "configure terminal"):
+		s.level, s.stack = configuration, nil
+		return false
+	}
+
+	if m := terminal.FindStringSubmatch(cmd); m != nil {
+		if m[1] == "len" {
+			s.paging, _ = strconv.Atoi(m[2])
+		}
+		return false
+	}
+	if s.checkpoint(cmd) {
+		return false
+	}
+	if abbrev(cmd, "show running-config") {
+		s.output(s.srv.RunningConfig())
+		return false
+	}
+
+	out, ok, err := s.srv.Device.response(cmd)
+	switch {
+	case err != nil:
+		s.output("% " + err.Error())
+	case !ok:
+		s.output(s.srv.prof.invalid)
+	default:
+		s.output(out)
+	}
+	return false
+}
+
+// enable은 privileged 모드로 올라갑니다. Secret이 있으면 비밀번호를 묻습니다.
+func (s *session) enable() {
+	secret := s.srv.Device.Secret
+	if s.level != exec || secret == "" {
+		s.level = max(s.level, privileged)
+		return
+	}
+	io.WriteString(s.w, "Password: ")
+	pass, err := s.readLine(false)
+	if err != nil {
+		return
+	}
+	if pass != secret {
+		s.output("% Access denied")
+		return
+	}
+	s.level = privileged
+}
+
+// checkpoint는 체크포인트 저장/복원/삭제 명령을 처리합니다. 체크포인트 명령이 아니면 false입니다.
+func (s *session) checkpoint(cmd string) bool {
+	p := s.srv.prof
+	if m := p.save.FindStringSubmatch(cmd); m != nil {
+		if p.confirm != "" {
+			io.WriteString(s.w, fmt.Sprintf(p.confirm, m[1]))
+			if _, err := s.readLine(true); err != nil {
+				return true
+			}
+		}
+		config := s.srv.RunningConfig()
+		s.srv.mu.Lock()
+		s.srv.checkpoints[m[1]] = config
+		s.srv.mu.Unlock()
+		if p.confirm != "" {
+			s.output(fmt.Sprintf("%d bytes copied in 0.012 secs", len(config)))
+		}
+		return true
+	}
+	if m := p.restore.FindStringSubmatch(cmd); m != nil {
+		s.srv.mu.Lock()
+		config, ok := s.srv.checkpoints[m[1]]
+		if ok {
+			s.srv.running = confdiff.Parse(config, confdiff.DefaultIgnore)
+		}
+		s.srv.mu.Unlock()
+		if !ok {
+			s.output(fmt.Sprintf("%% Error: checkpoint %s not found", m[1]))
+		}
+		return true
+	}
+	if m := p.discard.FindStringSubmatch(cmd); m != nil {
+		s.srv.mu.Lock()
+		delete(s.srv.checkpoints, m[1])
+		s.srv.mu.Unlock()
+		return true
+	}
+	return false
+}
+
+// configure는 설정 모드에서 받은 줄 하나를 running config에 적용합니다.
+//
+// 들여쓴 줄은 confdiff.Parse처럼 들여쓰기로 상위 섹션을 찾습니다. 들여쓰지 않은 줄은 장비처럼
+// 현재 섹션(interface, router 등) 아래로 보다가, 최상위 섹션 줄이나 exit가 오면 빠져나옵니다.
+func (s *session) configure(raw string) {
+	raw = strings.TrimRight(raw, " \t")
+	text := strings.TrimSpace(raw)
+	indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+
+	switch {
+	case text == "" || strings.HasPrefix(text, "!"):
+		return
+	case text == "end":
+		s.level, s.stack = privileged, nil
+		return
+	case text == "exit":
+		if len(s.stack) == 0 {
+			s.level = privileged
+			return
+		}
+		s.stack = s.stack[:len(s.stack)-1]
+		return
+	case strings.HasPrefix(text, "do "):
+		s.command(strings.Join(strings.Fields(text)[1:], " "))
+		return
+	}
+	for _, re := range s.srv.Device.Reject {
+		if re.MatchString(text) {
+			s.output(s.srv.prof.invalid)
+			return
+		}
+	}
+
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+
+	var f frame
+	switch top := len(s.stack) - 1; {
+	case indent > 0:
+		for len(s.stack) > 0 && s.stack[len(s.stack)-1].indent >= indent {
+			s.stack = s.stack[:len(s.stack)-1]
+		}
+		if len(s.stack) > 0 {
+			s.stack[len(s.stack)-1].indented = true
+		}
+		f = frame{indent: indent}
+	case topSections.MatchString(text):
+		s.stack = nil
+		f = frame{flat: true}
+	case top >= 0 && s.stack[top].flat && !s.stack[top].indented:
+		f = frame{flat: subSections.MatchString(text)}
+	default:
+		s.stack = nil
+	}
+
+	parent := s.srv.running
+	if len(s.stack) > 0 {
+		parent = s.stack[len(s.stack)-1].node
+	}
+	if f.node = apply(parent, text); f.node != nil && (indent > 0 || f.flat || parent == s.srv.running) {
+		s.stack = append(s.stack, f)
+	}
+}
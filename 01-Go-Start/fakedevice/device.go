@@ -0,0 +1,260 @@
+package fakedevice
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/scrapli/scrapligo/platform"
+	"tucker-study/01-Go-Start/confdiff"
+)
+
+// 실제 장비 없이 scrapligo 프로그램을 실행해 볼 수 있도록 CLI 장비를 흉내 내는 SSH 서버입니다.
+// 프롬프트(>, #, (config)#), enable, 페이징(--More--), 설정 모드와 running config, 체크포인트,
+// 플랫폼별 기본 응답(responses/<platform>/<명령의 공백을 _로>.txt)을 흉내 냅니다.
+// 같은 포트에서 NETCONF over SSH도 받습니다. (netconf.go) gNMI는 StartGNMI로 따로 띄웁니다. (gnmi.go)
+//
+//	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+//	if err != nil { ... }
+//	defer srv.Close()
+//
+//	s, err := device.Open(ctx, srv.Router())
+
+//go:embed responses
+var responses embed.FS
+
+// configFile은 플랫폼 기본 running config가 들어 있는 응답 디렉터리 안의 파일입니다.
+const configFile = "config.txt"
+
+// Device는 흉내 낼 장비 한 대입니다.
+type Device struct {
+	Hostname string
+	// Platform은 scrapligo 플랫폼 이름입니다. cisco_iosxe, cisco_nxos, arista_eos를 흉내 낼 수 있습니다.
+	Platform string
+	// Username, Password가 비어 있으면 admin/admin입니다.
+	Username string
+	Password string
+	// Secret이 있으면 로그인 직후 exec 모드(>)이고, enable에 이 비밀번호가 필요합니다.
+	Secret string
+
+	// Paging이 0보다 크면 "terminal length 0"을 받기 전까지 이 줄 수마다 --More--에서 멈춥니다.
+	Paging int
+	// Latency만큼 기다렸다가 명령 결과를 보냅니다.
+	Latency time.Duration
+
+	// Responses는 명령별 출력입니다. 플랫폼 기본 응답보다 먼저 찾고, 명령은 줄여 써도 맞습니다.
+	// 출력은 text/template으로 Device를 넣어 실행합니다. (예: {{ .Hostname }})
+	Responses map[string]string
+	// Config는 처음 running config입니다. 비어 있으면 플랫폼 기본 설정입니다.
+	Config string
+	// NetconfConfig는 처음 NETCONF running 데이터스토어(XML)입니다. 비어 있으면 responses/netconf/running.xml입니다.
+	NetconfConfig string
+	// GNMIState는 처음 gNMI 상태 트리(OpenConfig JSON)입니다. 비어 있으면 responses/gnmi/state.json입니다.
+	GNMIState string
+	// Reject에 맞는 설정 줄은 장비가 거부합니다. (push 실패와 롤백 시험용)
+	// NETCONF에서는 설정 XML의 줄마다 검사합니다. running에 바로 넣는 edit-config와 validate, commit이 거부됩니다.
+	// gNMI에서는 Set으로 넣는 잎마다 "<경로> <값>"을 검사합니다.
+	Reject []*regexp.Regexp
+
+	// Addr는 SSH 서버가 들을 주소입니다. 비어 있으면 127.0.0.1의 빈 포트입니다.
+	Addr string
+}
+
+// profile은 플랫폼마다 다른 CLI 동작입니다.
+type profile struct {
+	// invalid는 알 수 없는 명령이나 거부된 설정 줄에 대한 출력입니다. (scrapligo의 failed-when-contains에 걸림)
+	invalid string
+	// header는 show running-config 출력 앞에 붙는 줄들입니다.
+	header func(config string) string
+	// 체크포인트 저장/복원/삭제 명령입니다. 첫 번째 그룹이 체크포인트 이름입니다.
+	save, restore, discard *regexp.Regexp
+	// confirm이 있으면 save 뒤에 이 확인 프롬프트를 보내고 줄 입력을 기다립니다. %s는 이름입니다.
+	confirm string
+}
+
+var profiles = map[string]profile{
+	platform.CiscoIosxe: {
+		invalid: "% Invalid input detected at '^' marker.",
+		header: func(config string) string {
+			return fmt.Sprintf("Building configuration...\n\nCurrent configuration : %d bytes\n!\n", len(config))
+		},
+		save:    regexp.MustCompile(`^copy running-config flash:(\S+)$`),
+		restore: regexp.MustCompile(`^configure replace flash:(\S+) force$`),
+		discard: regexp.MustCompile(`^delete /force flash:(\S+)$`),
+		confirm: "Destination filename [%s]? ",
+	},
+	platform.CiscoNxos: {
+		invalid: "% Invalid input detected at '^' marker.",
+		header: func(string) string {
+			return "!Command: show running-config\n\n"
+		},
+		save:    regexp.MustCompile(`^checkpoint (\S+)$`),
+		restore: regexp.MustCompile(`^rollback running-config checkpoint (\S+)$`),
+		discard: regexp.MustCompile(`^no checkpoint (\S+)$`),
+	},
+	platform.AristaEos: {
+		invalid: "% Invalid input",
+		header: func(string) string {
+			return "! Command: show running-config\n!\n"
+		},
+		save:    regexp.MustCompile(`^configure checkpoint save (\S+)$`),
+		restore: regexp.MustCompile(`^configure replace checkpoint:(\S+)$`),
+		discard: regexp.MustCompile(`^delete checkpoint:(\S+)$`),
+	},
+}
+
+// Platforms는 흉내 낼 수 있는 플랫폼 목록입니다.
+func Platforms() []string {
+	var list []string
+	for p := range profiles {
+		list = append(list, p)
+	}
+	return list
+}
+
+// render는 응답을 text/template으로 실행합니다.
+func (d *Device) render(name, text string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, d); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// response는 명령에 대한 출력을 찾습니다. Responses, 플랫폼 기본 응답 순서로 찾습니다.
+func (d *Device) response(cmd string) (string, bool, error) {
+	// 줄여 쓴 명령이 여러 응답에 맞을 수 있으므로 정확히 같은 명령을 먼저 찾습니다.
+	for _, match := range []func(string, string) bool{same, abbrev} {
+		for c, out := range d.Responses {
+			if match(cmd, c) {
+				s, err := d.render(c, out)
+				return s, true, err
+			}
+		}
+	}
+
+	dir := path.Join("responses", d.Platform)
+	entries, err := fs.ReadDir(responses, dir)
+	if err != nil {
+		return "", false, nil
+	}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".txt")
+		if e.Name() == configFile || !abbrev(cmd, strings.ReplaceAll(name, "_", " ")) {
+			continue
+		}
+		b, err := fs.ReadFile(responses, path.Join(dir, e.Name()))
+		if err != nil {
+			return "", false, err
+		}
+		s, err := d.render(e.Name(), string(b))
+		return s, true, err
+	}
+	return "", false, nil
+}
+
+// startConfig는 처음 running config입니다.
+func (d *Device) startConfig() (string, error) {
+	if d.Config != "" {
+		return d.Config, nil
+	}
+	b, err := fs.ReadFile(responses, path.Join("responses", d.Platform, configFile))
+	if err != nil {
+		return "", err
+	}
+	return d.render(configFile, string(b))
+}
+
+func same(a, b string) bool {
+	return strings.Join(strings.Fields(a), " ") == strings.Join(strings.Fields(b), " ")
+}
+
+// abbrev는 input이 full을 줄여 쓴 명령인지 확인합니다. ("sh ip int br" → "show ip interface brief")
+// 단어 수가 같고 각 단어가 full의 단어로 시작해야 합니다.
+func abbrev(input, full string) bool {
+	in, f := strings.Fields(input), strings.Fields(full)
+	if len(in) != len(f) {
+		return false
+	}
+	for i := range in {
+		if !strings.HasPrefix(strings.ToLower(f[i]), strings.ToLower(in[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// topSections는 다른 섹션 안에서 들여쓰지 않고 보내도 최상위 섹션으로 시작하는 줄입니다.
+var topSections = regexp.MustCompile(`^(interface|router|line|vrf definition|ip vrf|vrf context|vlan|ip access-list|ipv6 access-list|route-map|class-map|policy-map|key chain|management)\s`)
+
+// subSections는 들여쓰지 않은 섹션 안에서 다시 하위 섹션을 시작하는 줄입니다.
+var subSections = regexp.MustCompile(`^(address-family|vrf|template peer)\s`)
+
+// singleValued는 값이 하나뿐인 설정입니다. 새 값을 넣으면 예전 값을 지웁니다.
+var singleValued = []string{"hostname", "description", "ip address", "mtu", "speed", "duplex", "bandwidth", "ip domain name", "router-id"}
+
+// apply는 설정 줄 하나를 parent 아래에 적용합니다. 지운 경우에는 nil을 돌려줍니다.
+// "no X"는 X로 시작하는 줄을 지우고, 지울 줄이 없으면 "no X"를 그대로 남깁니다. (예: "no ip address")
+func apply(parent *confdiff.Node, text string) *confdiff.Node {
+	if neg, ok := strings.CutPrefix(text, "no "); ok {
+		n := len(parent.Children)
+		parent.RemoveFunc(func(c *confdiff.Node) bool {
+			return c.Text == neg || strings.HasPrefix(c.Text, neg+" ")
+		})
+		if len(parent.Children) < n {
+			return nil
+		}
+		return parent.Add(text)
+	}
+
+	parent.RemoveFunc(func(c *confdiff.Node) bool {
+		neg, ok := strings.CutPrefix(c.Text, "no ")
+		return ok && (text == neg || strings.HasPrefix(text, neg+" "))
+	})
+	for _, key := range singleValued {
+		if !strings.HasPrefix(text, key+" ") || strings.HasSuffix(text, " secondary") {
+			continue
+		}
+		old := func(c *confdiff.Node) bool {
+			return c.Text != text && strings.HasPrefix(c.Text, key+" ") && !strings.HasSuffix(c.Text, " secondary")
+		}
+		// 예전 값이 있던 자리에 새 값을 둡니다. (hostname이 설정 끝으로 가지 않도록)
+		i := slices.IndexFunc(parent.Children, old)
+		if i < 0 || parent.Child(text) != nil {
+			parent.RemoveFunc(old)
+			break
+		}
+		parent.RemoveFunc(old)
+		c := parent.Add(text)
+		parent.Children = slices.Insert(parent.Children[:len(parent.Children)-1], i, c)
+		return c
+	}
+	return parent.Add(text)
+}
+
+// renderConfig는 설정 트리를 show running-config 형태로 씁니다. 최상위 섹션 사이에 "!"를 넣습니다.
+func renderConfig(p profile, root *confdiff.Node) string {
+	var b strings.Builder
+	for _, c := range root.Children {
+		b.WriteString(c.Text + "\n")
+		for _, l := range c.Lines(1) {
+			b.WriteString(l + "\n")
+		}
+		if len(c.Children) > 0 {
+			b.WriteString("!\n")
+		}
+	}
+	b.WriteString("end")
+	return p.header(b.String()) + b.String()
+}
@@ -0,0 +1,681 @@
+package fakedevice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/value"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"tucker-study/01-Go-Start/gnmi"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// gNMI(gRPC)를 흉내 냅니다. SSH와 따로 자기 포트에서 받고, 인벤토리에서 mode: gnmi인 장비가 접속합니다.
+// 상태 트리는 잎 값(경로 → 값)의 모음이고 Get, Set, Subscribe(STREAM, ONCE)를 흉내 냅니다.
+// Get은 잎이 아닌 경로를 JSON_IETF 하위 트리로, Subscribe는 잎 값 하나씩 보냅니다. (실제 장비들이 흔히 하는 대로)
+// 카운터(-octets, -pkts로 끝나는 잎)는 1초마다 늘어나므로 ON_CHANGE 구독에도 계속 알림이 갑니다.
+//
+//	srv, err := fakedevice.StartGNMI(fakedevice.Device{Hostname: "rtr1"})
+//	if err != nil { ... }
+//	defer srv.Close()
+//
+//	c, err := gnmi.Dial(ctx, srv.Router(), gnmi.Options{})
+
+// gnmiState는 gNMI 기본 상태 트리입니다.
+const gnmiState = "responses/gnmi/state.json"
+
+// 기본 SAMPLE 간격과 카운터가 늘어나는 간격입니다.
+const (
+	defaultSampleInterval = 10 * time.Second
+	counterInterval       = time.Second
+)
+
+// GNMIServer는 Device 하나를 흉내 내는 gNMI 서버입니다. 여러 RPC가 상태 트리를 함께 씁니다.
+type GNMIServer struct {
+	Device Device
+
+	ln   net.Listener
+	grpc *grpc.Server
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// leaves는 경로 문자열(gnmi.PathString) → 잎입니다. 잎은 바꾸지 않고 새 잎으로 갈아 끼웁니다.
+	leaves   map[string]*leaf
+	watchers map[*watcher]bool
+	history  []string
+}
+
+type leaf struct {
+	path *gpb.Path
+	val  *gpb.TypedValue
+}
+
+// watcher는 ON_CHANGE 구독 하나입니다. 바뀐 잎 중 paths에 맞는 것을 ch로 받습니다.
+type watcher struct {
+	paths []*gpb.Path
+	ch    chan *gpb.Notification
+	done  <-chan struct{}
+}
+
+// StartGNMI는 d를 흉내 내는 gNMI 서버를 띄웁니다. Close로 닫을 때까지 접속을 받습니다.
+// Platform은 상태 트리의 software-version에만 쓰므로 아무 값이나 됩니다.
+func StartGNMI(d Device) (*GNMIServer, error) {
+	if d.Hostname == "" {
+		return nil, errors.New("fakedevice: hostname is required")
+	}
+	if d.Username == "" && d.Password == "" {
+		d.Username, d.Password = "admin", "admin"
+	}
+	if d.Addr == "" {
+		d.Addr = "127.0.0.1:0"
+	}
+
+	leaves, err := d.startGNMI()
+	if err != nil {
+		return nil, fmt.Errorf("fakedevice: %w", err)
+	}
+	s := &GNMIServer{
+		Device:   d,
+		stop:     make(chan struct{}),
+		leaves:   leaves,
+		watchers: map[*watcher]bool{},
+	}
+
+	s.ln, err = net.Listen("tcp", d.Addr)
+	if err != nil {
+		return nil, err
+	}
+	s.grpc = grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, h grpc.UnaryHandler) (any, error) {
+			if err := s.auth(ctx); err != nil {
+				return nil, err
+			}
+			return h(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, h grpc.StreamHandler) error {
+			if err := s.auth(ss.Context()); err != nil {
+				return err
+			}
+			return h(srv, ss)
+		}),
+	)
+	gpb.RegisterGNMIServer(s.grpc, &gnmiService{s})
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		s.grpc.Serve(s.ln)
+	}()
+	go func() {
+		defer s.wg.Done()
+		s.tick()
+	}()
+	return s, nil
+}
+
+// startGNMI는 처음 상태 트리를 만듭니다. Device.GNMIState가 비어 있으면 responses/gnmi/state.json입니다.
+func (d *Device) startGNMI() (map[string]*leaf, error) {
+	text := d.GNMIState
+	if text == "" {
+		b, err := fs.ReadFile(responses, gnmiState)
+		if err != nil {
+			return nil, err
+		}
+		if text, err = d.render(path.Base(gnmiState), string(b)); err != nil {
+			return nil, err
+		}
+	}
+	leaves := map[string]*leaf{}
+	n := &gpb.Notification{Update: []*gpb.Update{{Path: &gpb.Path{}, Val: &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: []byte(text)}}}}}
+	if err := addLeaves(leaves, n); err != nil {
+		return nil, fmt.Errorf("gnmi state: %w", err)
+	}
+	return leaves, nil
+}
+
+// addLeaves는 n의 값들을 잎으로 펼쳐서 leaves에 넣습니다. JSON 값은 잎 경로들로 펼칩니다.
+func addLeaves(leaves map[string]*leaf, n *gpb.Notification) error {
+	recs, err := gnmi.Flatten("", n)
+	if err != nil {
+		return err
+	}
+	for _, r := range recs {
+		p, err := gnmi.ParsePath(r.Path)
+		if err != nil {
+			return err
+		}
+		tv, err := value.FromScalar(r.Value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Path, err)
+		}
+		leaves[r.Path] = &leaf{path: p, val: tv}
+	}
+	return nil
+}
+
+// Addr는 서버가 듣고 있는 주소입니다.
+func (s *GNMIServer) Addr() netip.AddrPort {
+	return s.ln.Addr().(*net.TCPAddr).AddrPort()
+}
+
+// Router는 이 서버에 접속하는 인벤토리 장비입니다. (mode: gnmi)
+func (s *GNMIServer) Router() inventory.Router {
+	ap := s.Addr()
+	return inventory.Router{
+		Hostname: s.Device.Hostname,
+		IP:       inventory.MustParseMgmtAddr(ap.Addr().Unmap().String()),
+		Vars: inventory.Vars{
+			Platform:  s.Device.Platform,
+			Username:  s.Device.Username,
+			Password:  s.Device.Password,
+			SSHConfig: os.DevNull,
+			Port:      int(ap.Port()),
+			Mode:      inventory.ModeGNMI,
+		},
+	}
+}
+
+// History는 지금까지 받은 RPC입니다. (예: "get /interfaces", "subscribe on_change /interfaces")
+func (s *GNMIServer) History() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.history...)
+}
+
+func (s *GNMIServer) record(line string) {
+	s.mu.Lock()
+	s.history = append(s.history, line)
+	s.mu.Unlock()
+}
+
+// Value는 잎 하나의 지금 값입니다.
+func (s *GNMIServer) Value(p string) (any, bool) {
+	gp, err := gnmi.ParsePath(p)
+	if err != nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l, ok := s.leaves[gnmi.PathString(gp)]
+	if !ok {
+		return nil, false
+	}
+	v, err := value.ToScalar(l.val)
+	return v, err == nil
+}
+
+// Update는 장비에서 값이 바뀐 것처럼 잎 값을 바꾸고 ON_CHANGE 구독에 알립니다. (예: 인터페이스 oper-status)
+// v가 nil이면 p 아래의 잎을 지웁니다.
+func (s *GNMIServer) Update(p string, v any) error {
+	gp, err := gnmi.ParsePath(p)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UnixNano()
+	if v == nil {
+		s.notify(nil, s.remove(s.leaves, gp), now)
+		return nil
+	}
+	tv, err := value.FromScalar(v)
+	if err != nil {
+		return err
+	}
+	l := &leaf{path: gp, val: tv}
+	s.leaves[gnmi.PathString(gp)] = l
+	s.notify([]*leaf{l}, nil, now)
+	return nil
+}
+
+// Close는 서버와 열린 RPC를 모두 닫습니다.
+func (s *GNMIServer) Close() error {
+	close(s.stop)
+	s.grpc.Stop()
+	s.wg.Wait()
+	return nil
+}
+
+// auth는 메타데이터의 username, password를 확인합니다.
+func (s *GNMIServer) auth(ctx context.Context) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if first(md.Get("username")) != s.Device.Username || first(md.Get("password")) != s.Device.Password {
+		return status.Error(codes.Unauthenticated, "authentication failed")
+	}
+	return nil
+}
+
+func first(vals []string) string {
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+// tick은 서버를 닫을 때까지 카운터를 늘리고 ON_CHANGE 구독에 알립니다.
+func (s *GNMIServer) tick() {
+	t := time.NewTicker(counterInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-t.C:
+		}
+
+		s.mu.Lock()
+		now := time.Now().UnixNano()
+		var changed []*leaf
+		for k, l := range s.leaves {
+			name := l.path.Elem[len(l.path.Elem)-1].Name
+			if !strings.HasSuffix(name, "-octets") && !strings.HasSuffix(name, "-pkts") {
+				continue
+			}
+			n, ok := l.val.GetValue().(*gpb.TypedValue_IntVal)
+			if !ok || n.IntVal == 0 {
+				// 쓰이지 않는(0인) 인터페이스는 그대로 둡니다.
+				continue
+			}
+			step := rand.Int64N(100) + 1
+			if strings.HasSuffix(name, "-octets") {
+				step *= 512
+			}
+			nl := &leaf{path: l.path, val: &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{IntVal: n.IntVal + step}}}
+			s.leaves[k] = nl
+			changed = append(changed, nl)
+		}
+		s.notify(changed, nil, now)
+		s.mu.Unlock()
+	}
+}
+
+// notify는 바뀐 잎과 지워진 경로를 ON_CHANGE 구독에 알립니다. s.mu를 잡고 부릅니다.
+func (s *GNMIServer) notify(changed []*leaf, deleted []*gpb.Path, ts int64) {
+	for w := range s.watchers {
+		n := &gpb.Notification{Timestamp: ts}
+		for _, l := range changed {
+			if matchAny(w.paths, l.path) {
+				n.Update = append(n.Update, &gpb.Update{Path: l.path, Val: l.val})
+			}
+		}
+		for _, p := range deleted {
+			if matchAny(w.paths, p) {
+				n.Delete = append(n.Delete, p)
+			}
+		}
+		if len(n.Update) == 0 && len(n.Delete) == 0 {
+			continue
+		}
+		select {
+		case w.ch <- n:
+		case <-w.done:
+		}
+	}
+}
+
+func matchAny(patterns []*gpb.Path, p *gpb.Path) bool {
+	return slices.ContainsFunc(patterns, func(pat *gpb.Path) bool { return gnmi.Match(pat, p) })
+}
+
+// match는 pattern에 맞는 잎들을 경로 순서로 돌려줍니다. s.mu를 잡고 부릅니다.
+func (s *GNMIServer) match(pattern *gpb.Path) []*leaf {
+	var out []*leaf
+	for _, k := range slices.Sorted(maps.Keys(s.leaves)) {
+		if l := s.leaves[k]; gnmi.Match(pattern, l.path) {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// remove는 leaves에서 p 아래의 잎을 지우고 지운 경로들을 돌려줍니다.
+func (s *GNMIServer) remove(leaves map[string]*leaf, p *gpb.Path) []*gpb.Path {
+	var deleted []*gpb.Path
+	for k, l := range leaves {
+		if gnmi.Match(p, l.path) {
+			delete(leaves, k)
+			deleted = append(deleted, l.path)
+		}
+	}
+	return deleted
+}
+
+// gnmiService는 gpb.GNMIServer를 구현합니다. (GNMIServer의 공개 메서드와 이름이 겹치지 않도록 따로 둡니다)
+type gnmiService struct {
+	s *GNMIServer
+}
+
+func (g *gnmiService) Capabilities(context.Context, *gpb.CapabilityRequest) (*gpb.CapabilityResponse, error) {
+	g.s.record("capabilities")
+	return &gpb.CapabilityResponse{
+		SupportedModels: []*gpb.ModelData{
+			{Name: "openconfig-interfaces", Organization: "OpenConfig working group"},
+			{Name: "openconfig-system", Organization: "OpenConfig working group"},
+			{Name: "openconfig-network-instance", Organization: "OpenConfig working group"},
+		},
+		SupportedEncodings: []gpb.Encoding{gpb.Encoding_JSON, gpb.Encoding_JSON_IETF},
+		GNMIVersion:        "0.7.0",
+	}, nil
+}
+
+// Get은 경로마다 알림 하나를 돌려줍니다. 잎이면 값 그대로, 아니면 JSON 하위 트리입니다.
+// 와일드카드(*)가 있는 경로는 맞는 잎을 하나씩 돌려줍니다.
+func (g *gnmiService) Get(ctx context.Context, req *gpb.GetRequest) (*gpb.GetResponse, error) {
+	s := g.s
+	if enc := req.GetEncoding(); enc != gpb.Encoding_JSON && enc != gpb.Encoding_JSON_IETF {
+		return nil, status.Errorf(codes.Unimplemented, "unsupported encoding %s", enc)
+	}
+	var paths []string
+	for _, p := range req.GetPath() {
+		paths = append(paths, gnmi.PathString(gnmi.Join(req.GetPrefix(), p)))
+	}
+	s.record("get " + strings.Join(paths, " "))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rs := &gpb.GetResponse{}
+	now := time.Now().UnixNano()
+	for _, p := range req.GetPath() {
+		full := gnmi.Join(req.GetPrefix(), p)
+		leaves := s.match(full)
+		if len(leaves) == 0 {
+			return nil, status.Errorf(codes.NotFound, "%s: no data", gnmi.PathString(full))
+		}
+
+		n := &gpb.Notification{Timestamp: now, Prefix: req.GetPrefix()}
+		switch {
+		case len(leaves) == 1 && len(leaves[0].path.Elem) == len(full.Elem):
+			n.Update = append(n.Update, &gpb.Update{Path: p, Val: leaves[0].val})
+		case wildcard(full):
+			n.Prefix = nil
+			for _, l := range leaves {
+				n.Update = append(n.Update, &gpb.Update{Path: l.path, Val: l.val})
+			}
+		default:
+			b, err := subtree(len(full.Elem), leaves)
+			if err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+			tv := &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: b}}
+			if req.GetEncoding() == gpb.Encoding_JSON_IETF {
+				tv.Value = &gpb.TypedValue_JsonIetfVal{JsonIetfVal: b}
+			}
+			n.Update = append(n.Update, &gpb.Update{Path: p, Val: tv})
+		}
+		rs.Notification = append(rs.Notification, n)
+	}
+	return rs, nil
+}
+
+func wildcard(p *gpb.Path) bool {
+	for _, e := range p.Elem {
+		if e.Name == "*" || slices.Contains(slices.Collect(maps.Values(e.Key)), "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// Set은 delete, replace, update를 순서대로 상태 트리의 복사본에 적용하고, 모두 성공하면 갈아 끼웁니다.
+// Device.Reject에 맞는 값("<경로> <값>")이 하나라도 있으면 아무것도 바꾸지 않고 거부합니다.
+func (g *gnmiService) Set(ctx context.Context, req *gpb.SetRequest) (*gpb.SetResponse, error) {
+	s := g.s
+	prefix := req.GetPrefix()
+	rs := &gpb.SetResponse{Prefix: prefix}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	leaves := maps.Clone(s.leaves)
+	now := time.Now().UnixNano()
+	var deleted []*gpb.Path
+	for _, p := range req.GetDelete() {
+		s.history = append(s.history, "set delete "+gnmi.PathString(gnmi.Join(prefix, p)))
+		deleted = append(deleted, s.remove(leaves, gnmi.Join(prefix, p))...)
+		rs.Response = append(rs.Response, &gpb.UpdateResult{Path: p, Op: gpb.UpdateResult_DELETE})
+	}
+
+	before := maps.Clone(leaves)
+	apply := func(op string, u *gpb.Update) error {
+		full := gnmi.Join(prefix, u.GetPath())
+		s.history = append(s.history, "set "+op+" "+gnmi.PathString(full))
+		if op == "replace" {
+			deleted = append(deleted, s.remove(leaves, full)...)
+		}
+		fresh := map[string]*leaf{}
+		if err := addLeaves(fresh, &gpb.Notification{Prefix: full, Update: []*gpb.Update{{Path: &gpb.Path{}, Val: u.GetVal()}}}); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%s: %v", gnmi.PathString(full), err)
+		}
+		for k, l := range fresh {
+			line := fmt.Sprintf("%s %v", k, l.val.GetValue())
+			if v, err := value.ToScalar(l.val); err == nil {
+				line = fmt.Sprintf("%s %v", k, v)
+			}
+			for _, re := range s.Device.Reject {
+				if re.MatchString(line) {
+					return status.Errorf(codes.FailedPrecondition, "rejected: %s", line)
+				}
+			}
+			leaves[k] = l
+		}
+		return nil
+	}
+	for _, u := range req.GetReplace() {
+		if err := apply("replace", u); err != nil {
+			return nil, err
+		}
+		rs.Response = append(rs.Response, &gpb.UpdateResult{Path: u.GetPath(), Op: gpb.UpdateResult_REPLACE})
+	}
+	for _, u := range req.GetUpdate() {
+		if err := apply("update", u); err != nil {
+			return nil, err
+		}
+		rs.Response = append(rs.Response, &gpb.UpdateResult{Path: u.GetPath(), Op: gpb.UpdateResult_UPDATE})
+	}
+
+	// 바뀐 잎과, 지웠다가 다시 넣지 않은 잎만 알립니다.
+	var changed []*leaf
+	for k, l := range leaves {
+		if before[k] != l {
+			changed = append(changed, l)
+		}
+	}
+	deleted = slices.DeleteFunc(deleted, func(p *gpb.Path) bool {
+		_, ok := leaves[gnmi.PathString(p)]
+		return ok
+	})
+	s.leaves = leaves
+	rs.Timestamp = now
+	s.notify(changed, deleted, now)
+	return rs, nil
+}
+
+// Subscribe는 STREAM과 ONCE 구독을 받습니다. 처음 값 전체와 sync_response를 보낸 뒤
+// SAMPLE은 간격마다 맞는 잎 전체를, ON_CHANGE와 TARGET_DEFINED는 바뀐 잎만 보냅니다.
+func (g *gnmiService) Subscribe(stream gpb.GNMI_SubscribeServer) error {
+	s := g.s
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	list := req.GetSubscribe()
+	if list == nil {
+		return status.Error(codes.InvalidArgument, "first request must be a subscription list")
+	}
+	if list.Mode == gpb.SubscriptionList_POLL {
+		return status.Error(codes.Unimplemented, "poll subscriptions are not supported")
+	}
+
+	// quit은 이 RPC가 끝날 때 닫힙니다. 스트림에 보내지 못하고 끝난 경우에도 notify와 샘플러가 기다리지 않도록 합니다.
+	ctx := stream.Context()
+	quit := make(chan struct{})
+	out := make(chan *gpb.Notification, 64)
+	watch := &watcher{ch: out, done: quit}
+	var samplers sync.WaitGroup
+	defer func() {
+		close(quit)
+		samplers.Wait()
+		s.mu.Lock()
+		delete(s.watchers, watch)
+		s.mu.Unlock()
+	}()
+
+	var initial []*gpb.Notification
+	s.mu.Lock()
+	for _, sub := range list.Subscription {
+		p := gnmi.Join(list.Prefix, sub.Path)
+		mode := strings.ToLower(sub.Mode.String())
+		if list.Mode == gpb.SubscriptionList_ONCE {
+			mode = "once"
+		}
+		s.history = append(s.history, "subscribe "+mode+" "+gnmi.PathString(p))
+		if !list.UpdatesOnly {
+			initial = append(initial, s.snapshot(p))
+		}
+		if list.Mode == gpb.SubscriptionList_ONCE {
+			continue
+		}
+
+		if sub.Mode != gpb.SubscriptionMode_SAMPLE {
+			watch.paths = append(watch.paths, p)
+			continue
+		}
+		interval := time.Duration(sub.SampleInterval)
+		if interval <= 0 {
+			interval = defaultSampleInterval
+		}
+		samplers.Add(1)
+		go func() {
+			defer samplers.Done()
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			for {
+				select {
+				case <-quit:
+					return
+				case <-t.C:
+				}
+				s.mu.Lock()
+				n := s.snapshot(p)
+				s.mu.Unlock()
+				select {
+				case out <- n:
+				case <-quit:
+					return
+				}
+			}
+		}()
+	}
+	if len(watch.paths) > 0 {
+		s.watchers[watch] = true
+	}
+	s.mu.Unlock()
+
+	for _, n := range initial {
+		if len(n.Update) == 0 {
+			continue
+		}
+		if err := stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_Update{Update: n}}); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_SyncResponse{SyncResponse: true}}); err != nil {
+		return err
+	}
+	if list.Mode == gpb.SubscriptionList_ONCE {
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-out:
+			if err := stream.Send(&gpb.SubscribeResponse{Response: &gpb.SubscribeResponse_Update{Update: n}}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// snapshot은 p에 맞는 잎 전체를 알림 하나로 만듭니다. s.mu를 잡고 부릅니다.
+func (s *GNMIServer) snapshot(p *gpb.Path) *gpb.Notification {
+	n := &gpb.Notification{Timestamp: time.Now().UnixNano()}
+	for _, l := range s.match(p) {
+		n.Update = append(n.Update, &gpb.Update{Path: l.path, Val: l.val})
+	}
+	return n
+}
+
+// subtree는 leaves를 경로의 depth번째 요소 아래의 JSON 트리로 만듭니다. 키가 있는 요소는 리스트가 됩니다.
+func subtree(depth int, leaves []*leaf) ([]byte, error) {
+	root := map[string]any{}
+	for _, l := range leaves {
+		v, err := value.ToScalar(l.val)
+		if err != nil {
+			return nil, err
+		}
+		cur := root
+		rel := l.path.Elem[depth:]
+		for i, e := range rel {
+			if i == len(rel)-1 {
+				cur[e.Name] = v
+				break
+			}
+			if len(e.Key) == 0 {
+				next, _ := cur[e.Name].(map[string]any)
+				if next == nil {
+					next = map[string]any{}
+					cur[e.Name] = next
+				}
+				cur = next
+				continue
+			}
+
+			list, _ := cur[e.Name].([]any)
+			var next map[string]any
+			for _, x := range list {
+				if m := x.(map[string]any); hasKeys(m, e.Key) {
+					next = m
+					break
+				}
+			}
+			if next == nil {
+				next = map[string]any{}
+				for k, kv := range e.Key {
+					next[k] = kv
+				}
+				cur[e.Name] = append(list, next)
+			}
+			cur = next
+		}
+	}
+	return json.Marshal(root)
+}
+
+// hasKeys는 리스트 요소 m의 키 값이 key와 같은지 확인합니다.
+func hasKeys(m map[string]any, key map[string]string) bool {
+	for k, v := range key {
+		if fmt.Sprint(m[k]) != v {
+			return false
+		}
+	}
+	return true
+}
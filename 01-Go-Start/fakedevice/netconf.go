@@ -0,0 +1,463 @@
+package fakedevice
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NETCONF over SSH(RFC 6241, 6242)를 흉내 냅니다. CLI와 같은 SSH 포트에서 "netconf" 서브시스템으로 받습니다.
+// running, candidate 데이터스토어와 상태 데이터(get에만 보임), lock, commit, validate를 흉내 내고
+// 1.0(]]>]]>)과 1.1(chunked) 프레이밍을 모두 받습니다.
+// NETCONF 데이터스토어는 CLI의 running config와 따로입니다. (한쪽을 바꿔도 다른 쪽은 그대로입니다)
+
+// netconfDir는 NETCONF 기본 데이터스토어가 들어 있는 응답 디렉터리입니다.
+const netconfDir = "responses/netconf"
+
+// 서버 hello에 보내는 capability입니다.
+var netconfCapabilities = []string{
+	"urn:ietf:params:netconf:base:1.0",
+	"urn:ietf:params:netconf:base:1.1",
+	"urn:ietf:params:netconf:capability:candidate:1.0",
+	"urn:ietf:params:netconf:capability:validate:1.1",
+	"urn:ietf:params:netconf:capability:writable-running:1.0",
+	"urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20",
+	"urn:ietf:params:xml:ns:yang:ietf-system?module=ietf-system&revision=2014-08-06",
+}
+
+const (
+	delim10     = "]]>]]>"
+	capBase11   = "urn:ietf:params:netconf:base:1.1"
+	dsRunning   = "running"
+	dsCandidate = "candidate"
+)
+
+// netconfStore는 서버의 NETCONF 데이터스토어입니다. Server.mu로 보호합니다.
+type netconfStore struct {
+	running, candidate *xmlNode
+	// state는 get에만 보이는 상태 데이터입니다.
+	state *xmlNode
+	// locks는 데이터스토어마다 lock을 잡은 세션 ID입니다.
+	locks  map[string]uint32
+	lastID uint32
+}
+
+// startNetconf는 처음 NETCONF 데이터스토어를 만듭니다.
+func (d *Device) startNetconf() (*netconfStore, error) {
+	text := d.NetconfConfig
+	if text == "" {
+		b, err := fs.ReadFile(responses, path.Join(netconfDir, "running.xml"))
+		if err != nil {
+			return nil, err
+		}
+		if text, err = d.render("running.xml", string(b)); err != nil {
+			return nil, err
+		}
+	}
+	running, err := parseXML(text, nil)
+	if err != nil {
+		return nil, fmt.Errorf("netconf config: %w", err)
+	}
+
+	b, err := fs.ReadFile(responses, path.Join(netconfDir, "state.xml"))
+	if err != nil {
+		return nil, err
+	}
+	text, err = d.render("state.xml", string(b))
+	if err != nil {
+		return nil, err
+	}
+	state, err := parseXML(text, nil)
+	if err != nil {
+		return nil, fmt.Errorf("netconf state: %w", err)
+	}
+
+	return &netconfStore{running: running, candidate: running.clone(), state: state, locks: map[string]uint32{}}, nil
+}
+
+// Datastore는 NETCONF 데이터스토어(running, candidate)의 지금 내용입니다.
+func (srv *Server) Datastore(name string) string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	switch name {
+	case dsRunning:
+		return srv.nc.running.String()
+	case dsCandidate:
+		return srv.nc.candidate.String()
+	}
+	return ""
+}
+
+// rpcError는 <rpc-error>로 보내는 에러입니다. (RFC 6241 부록 A)
+type rpcError struct {
+	Type    string `xml:"error-type"`
+	Tag     string `xml:"error-tag"`
+	Sev     string `xml:"error-severity"`
+	Message string `xml:"error-message,omitempty"`
+}
+
+func (e *rpcError) Error() string { return e.Tag + ": " + e.Message }
+
+func newRPCError(typ, tag, format string, args ...any) *rpcError {
+	return &rpcError{Type: typ, Tag: tag, Sev: "error", Message: fmt.Sprintf(format, args...)}
+}
+
+// rpcMessage는 클라이언트가 보낸 <rpc>입니다.
+type rpcMessage struct {
+	XMLName   xml.Name
+	MessageID string `xml:"message-id,attr"`
+	Body      string `xml:",innerxml"`
+}
+
+// ncSession은 NETCONF 서브시스템 세션 하나입니다.
+type ncSession struct {
+	srv *Server
+	id  uint32
+	w   io.Writer
+	r   *bufio.Reader
+	// chunked는 양쪽이 base:1.1을 지원해서 hello 뒤로 chunked 프레이밍을 쓰는지입니다.
+	chunked bool
+}
+
+func newNetconfSession(srv *Server, rw io.ReadWriter) *ncSession {
+	srv.mu.Lock()
+	srv.nc.lastID++
+	id := srv.nc.lastID
+	srv.mu.Unlock()
+	return &ncSession{srv: srv, id: id, w: rw, r: bufio.NewReader(rw)}
+}
+
+// run은 hello를 주고받은 뒤 연결이 끊기거나 close-session을 받을 때까지 RPC를 처리합니다.
+// 세션이 끝나면 잡고 있던 lock을 풀고, candidate lock을 잡고 있었으면 commit하지 않은 변경을 버립니다.
+func (s *ncSession) run() {
+	defer s.release()
+
+	var hello strings.Builder
+	hello.WriteString(`<hello xmlns="` + ncBase + `"><capabilities>`)
+	for _, c := range netconfCapabilities {
+		hello.WriteString("<capability>")
+		xml.EscapeText(&hello, []byte(c))
+		hello.WriteString("</capability>")
+	}
+	fmt.Fprintf(&hello, "</capabilities><session-id>%d</session-id></hello>", s.id)
+	if err := s.write(xml.Header + hello.String()); err != nil {
+		return
+	}
+
+	msg, err := s.read()
+	if err != nil {
+		return
+	}
+	var client struct {
+		Capabilities []string `xml:"capabilities>capability"`
+	}
+	if err := xml.Unmarshal([]byte(msg), &client); err != nil {
+		return
+	}
+	s.chunked = slices.Contains(client.Capabilities, capBase11)
+
+	for {
+		msg, err := s.read()
+		if err != nil {
+			return
+		}
+		if s.srv.Device.Latency > 0 {
+			time.Sleep(s.srv.Device.Latency)
+		}
+		reply, quit := s.handle(msg)
+		if err := s.write(reply); err != nil || quit {
+			return
+		}
+	}
+}
+
+// read는 메시지 하나를 읽습니다.
+func (s *ncSession) read() (string, error) {
+	if !s.chunked {
+		var b strings.Builder
+		for !strings.HasSuffix(b.String(), delim10) {
+			part, err := s.r.ReadString('>')
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(part)
+		}
+		return strings.TrimSuffix(b.String(), delim10), nil
+	}
+
+	// 1.1: \n#<크기>\n<데이터> ... \n##\n (RFC 6242 4.2) 청크 앞뒤의 줄바꿈은 너그럽게 받습니다.
+	var b bytes.Buffer
+	for {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if c == '\n' || c == '\r' || c == ' ' {
+			continue
+		}
+		if c != '#' {
+			return "", fmt.Errorf("netconf: bad chunk header %q", c)
+		}
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "#" {
+			return b.String(), nil
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil || n <= 0 {
+			return "", fmt.Errorf("netconf: bad chunk size %q", line)
+		}
+		if _, err := io.CopyN(&b, s.r, int64(n)); err != nil {
+			return "", err
+		}
+	}
+}
+
+// write는 메시지 하나를 보냅니다.
+func (s *ncSession) write(msg string) error {
+	var err error
+	if s.chunked {
+		_, err = fmt.Fprintf(s.w, "\n#%d\n%s\n##\n", len(msg), msg)
+	} else {
+		_, err = io.WriteString(s.w, msg+delim10+"\n")
+	}
+	return err
+}
+
+// handle은 RPC 하나를 처리하고 <rpc-reply>를 만듭니다.
+func (s *ncSession) handle(msg string) (reply string, quit bool) {
+	var rpc rpcMessage
+	data, err := "", error(nil)
+	if err = xml.Unmarshal([]byte(msg), &rpc); err == nil && rpc.XMLName.Local != "rpc" {
+		err = newRPCError("rpc", "unknown-element", "expected <rpc>, got <%s>", rpc.XMLName.Local)
+	}
+	var op *xmlNode
+	ops := map[*xmlNode]string{}
+	if err == nil {
+		var body *xmlNode
+		if body, err = parseXML(rpc.Body, ops); err == nil {
+			if len(body.Children) != 1 {
+				err = newRPCError("rpc", "missing-element", "rpc must contain exactly one operation")
+			} else {
+				op = body.Children[0]
+			}
+		}
+	}
+	if err == nil {
+		s.srv.record("netconf " + op.Name.Local)
+		data, quit, err = s.operation(op, ops)
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header + `<rpc-reply xmlns="` + ncBase + `"`)
+	if rpc.MessageID != "" {
+		b.WriteString(` message-id="`)
+		xml.EscapeText(&b, []byte(rpc.MessageID))
+		b.WriteString(`"`)
+	}
+	b.WriteString(">\n")
+	switch {
+	case err != nil:
+		var re *rpcError
+		if !errors.As(err, &re) {
+			re = newRPCError("rpc", "malformed-message", "%s", err)
+		}
+		out, _ := xml.MarshalIndent(struct {
+			XMLName xml.Name `xml:"rpc-error"`
+			*rpcError
+		}{rpcError: re}, "", "  ")
+		b.Write(out)
+		b.WriteString("\n")
+	case data != "":
+		b.WriteString(data)
+	default:
+		b.WriteString("<ok/>\n")
+	}
+	b.WriteString("</rpc-reply>")
+	return b.String(), quit
+}
+
+// operation은 RPC의 동작을 실행합니다. 돌려주는 data가 비어 있으면 <ok/>입니다.
+func (s *ncSession) operation(op *xmlNode, ops map[*xmlNode]string) (data string, quit bool, err error) {
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+	nc := s.srv.nc
+
+	switch op.Name.Local {
+	case "get", "get-config":
+		ds := &xmlNode{}
+		if op.Name.Local == "get" {
+			ds.Children = append(slices.Clone(nc.running.Children), nc.state.Children...)
+		} else {
+			name, err := datastore(op, "source")
+			if err != nil {
+				return "", false, err
+			}
+			ds = nc.store(name)
+		}
+		if f := op.child("filter"); f != nil {
+			ds = filter(ds, f)
+		}
+		var b bytes.Buffer
+		b.WriteString("<data>\n")
+		ds.write(&b, 1, "")
+		b.WriteString("</data>\n")
+		return b.String(), false, nil
+
+	case "edit-config":
+		name, err := datastore(op, "target")
+		if err != nil {
+			return "", false, err
+		}
+		if err := s.checkLock(name); err != nil {
+			return "", false, err
+		}
+		config := op.child("config")
+		if config == nil {
+			return "", false, newRPCError("protocol", "missing-element", "edit-config without <config>")
+		}
+		if name == dsRunning {
+			if err := s.srv.reject(config); err != nil {
+				return "", false, err
+			}
+		}
+		defop := "merge"
+		if d := op.child("default-operation"); d != nil {
+			defop = d.Text
+		}
+		// 실패하면 아무것도 바꾸지 않습니다. (rollback-on-error와 같은 동작)
+		edited := &xmlNode{}
+		if defop != "replace" {
+			edited = nc.store(name).clone()
+		}
+		if err := merge(edited, config, ops, "merge"); err != nil {
+			return "", false, newRPCError("application", err.Error(), "edit-config failed")
+		}
+		nc.set(name, edited)
+		return "", false, nil
+
+	case "validate":
+		name, err := datastore(op, "source")
+		if err != nil {
+			return "", false, err
+		}
+		return "", false, s.srv.reject(nc.store(name))
+
+	case "commit":
+		if err := s.checkLock(dsRunning); err != nil {
+			return "", false, err
+		}
+		if err := s.srv.reject(nc.candidate); err != nil {
+			return "", false, err
+		}
+		nc.running = nc.candidate.clone()
+		return "", false, nil
+
+	case "discard-changes":
+		nc.candidate = nc.running.clone()
+		return "", false, nil
+
+	case "lock":
+		name, err := datastore(op, "target")
+		if err != nil {
+			return "", false, err
+		}
+		if owner, ok := nc.locks[name]; ok {
+			return "", false, newRPCError("protocol", "lock-denied", "%s is locked by session %d", name, owner)
+		}
+		nc.locks[name] = s.id
+		return "", false, nil
+
+	case "unlock":
+		name, err := datastore(op, "target")
+		if err != nil {
+			return "", false, err
+		}
+		if owner, ok := nc.locks[name]; !ok || owner != s.id {
+			return "", false, newRPCError("protocol", "operation-failed", "%s is not locked by this session", name)
+		}
+		delete(nc.locks, name)
+		return "", false, nil
+
+	case "close-session":
+		return "", true, nil
+	}
+	return "", false, newRPCError("protocol", "operation-not-supported", "operation %q is not supported", op.Name.Local)
+}
+
+// datastore는 <source>나 <target> 안의 데이터스토어 이름입니다. running과 candidate만 있습니다.
+func datastore(op *xmlNode, elem string) (string, error) {
+	e := op.child(elem)
+	if e == nil || len(e.Children) != 1 {
+		return "", newRPCError("protocol", "missing-element", "%s: missing <%s>", op.Name.Local, elem)
+	}
+	name := e.Children[0].Name.Local
+	if name != dsRunning && name != dsCandidate {
+		return "", newRPCError("protocol", "invalid-value", "%s: unsupported datastore %q", op.Name.Local, name)
+	}
+	return name, nil
+}
+
+func (nc *netconfStore) store(name string) *xmlNode {
+	if name == dsCandidate {
+		return nc.candidate
+	}
+	return nc.running
+}
+
+func (nc *netconfStore) set(name string, n *xmlNode) {
+	if name == dsCandidate {
+		nc.candidate = n
+	} else {
+		nc.running = n
+	}
+}
+
+// checkLock은 다른 세션이 name을 잠갔는지 확인합니다.
+func (s *ncSession) checkLock(name string) error {
+	if owner, ok := s.srv.nc.locks[name]; ok && owner != s.id {
+		return newRPCError("protocol", "in-use", "%s is locked by session %d", name, owner)
+	}
+	return nil
+}
+
+// reject는 Device.Reject에 맞는 줄이 설정에 있으면 에러입니다. 줄은 들여쓰기를 뺀 XML 한 줄입니다. (예: <description>x</description>)
+func (srv *Server) reject(n *xmlNode) error {
+	for _, line := range strings.Split(n.String(), "\n") {
+		line = strings.TrimSpace(line)
+		for _, re := range srv.Device.Reject {
+			if re.MatchString(line) {
+				return newRPCError("application", "invalid-value", "rejected: %s", line)
+			}
+		}
+	}
+	return nil
+}
+
+// release는 세션이 잡고 있던 lock을 풉니다.
+func (s *ncSession) release() {
+	s.srv.mu.Lock()
+	defer s.srv.mu.Unlock()
+	nc := s.srv.nc
+	for name, owner := range nc.locks {
+		if owner != s.id {
+			continue
+		}
+		delete(nc.locks, name)
+		if name == dsCandidate {
+			nc.candidate = nc.running.clone()
+		}
+	}
+}
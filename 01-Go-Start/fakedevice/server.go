@@ -0,0 +1,237 @@
+package fakedevice
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"tucker-study/01-Go-Start/confdiff"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// Server는 Device 하나를 흉내 내는 SSH 서버입니다. 여러 세션이 running config를 함께 씁니다.
+type Server struct {
+	Device Device
+
+	prof   profile
+	ln     net.Listener
+	config *ssh.ServerConfig
+	key    ssh.PublicKey
+
+	mu          sync.Mutex
+	running     *confdiff.Node
+	checkpoints map[string]string
+	nc          *netconfStore
+	history     []string
+	conns       map[net.Conn]bool
+	closed      bool
+	wg          sync.WaitGroup
+}
+
+// Start는 d를 흉내 내는 SSH 서버를 띄웁니다. Close로 닫을 때까지 접속을 받습니다.
+func Start(d Device) (*Server, error) {
+	prof, ok := profiles[d.Platform]
+	if !ok {
+		return nil, fmt.Errorf("fakedevice: unsupported platform %q", d.Platform)
+	}
+	if d.Hostname == "" {
+		return nil, errors.New("fakedevice: hostname is required")
+	}
+	if d.Username == "" && d.Password == "" {
+		d.Username, d.Password = "admin", "admin"
+	}
+	if d.Addr == "" {
+		d.Addr = "127.0.0.1:0"
+	}
+
+	config, err := d.startConfig()
+	if err != nil {
+		return nil, fmt.Errorf("fakedevice: %w", err)
+	}
+	nc, err := d.startNetconf()
+	if err != nil {
+		return nil, fmt.Errorf("fakedevice: %w", err)
+	}
+
+	s := &Server{
+		Device:      d,
+		prof:        prof,
+		running:     confdiff.Parse(config, nil),
+		checkpoints: map[string]string{},
+		nc:          nc,
+		conns:       map[net.Conn]bool{},
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == d.Username && string(pass) == d.Password {
+				return nil, nil
+			}
+			return nil, errors.New("authentication failed")
+		},
+	}
+	s.config.AddHostKey(signer)
+	s.key = signer.PublicKey()
+
+	s.ln, err = net.Listen("tcp", d.Addr)
+	if err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr는 서버가 듣고 있는 주소입니다.
+func (s *Server) Addr() netip.AddrPort {
+	return s.ln.Addr().(*net.TCPAddr).AddrPort()
+}
+
+// Router는 이 서버에 접속하는 인벤토리 장비입니다. 호스트 키가 매번 바뀌므로 ssh_config는 쓰지 않습니다.
+// NETCONF도 같은 포트에서 받으므로 Mode만 inventory.ModeNETCONF로 바꾸면 NETCONF로 접속합니다.
+func (s *Server) Router() inventory.Router {
+	ap := s.Addr()
+	return inventory.Router{
+		Hostname: s.Device.Hostname,
+		IP:       inventory.MustParseMgmtAddr(ap.Addr().Unmap().String()),
+		Vars: inventory.Vars{
+			Platform:  s.Device.Platform,
+			Username:  s.Device.Username,
+			Password:  s.Device.Password,
+			SSHConfig: os.DevNull,
+			Port:      int(ap.Port()),
+		},
+	}
+}
+
+// HostKey는 서버의 SSH 호스트 키입니다. Start할 때마다 새로 만듭니다.
+func (s *Server) HostKey() ssh.PublicKey {
+	return s.key
+}
+
+// RunningConfig는 지금의 running config입니다.
+func (s *Server) RunningConfig() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return renderConfig(s.prof, s.running)
+}
+
+// History는 지금까지 받은 명령과 설정 줄입니다. (모든 세션, 받은 순서)
+func (s *Server) History() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.history...)
+}
+
+// Close는 서버와 열린 세션을 모두 닫습니다.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// handle은 SSH 연결 하나를 처리합니다. 세션 채널에서 pty와 shell, netconf 서브시스템 요청만 받습니다.
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	sc, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	defer sc.Close()
+	go ssh.DiscardRequests(reqs)
+
+	var wg sync.WaitGroup
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "only session channels are supported")
+			continue
+		}
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer ch.Close()
+			for req := range chReqs {
+				switch req.Type {
+				case "pty-req", "env", "window-change":
+					req.Reply(true, nil)
+				case "shell":
+					req.Reply(true, nil)
+					go func() {
+						status := newSession(s, ch).run()
+						ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+						ch.Close()
+					}()
+				case "subsystem":
+					var sub struct{ Name string }
+					if ssh.Unmarshal(req.Payload, &sub) != nil || sub.Name != "netconf" {
+						req.Reply(false, nil)
+						continue
+					}
+					req.Reply(true, nil)
+					go func() {
+						newNetconfSession(s, ch).run()
+						ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+						ch.Close()
+					}()
+				default:
+					req.Reply(false, nil)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,262 @@
+package fakedevice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+// xmlNode는 NETCONF 데이터스토어의 XML 요소 하나입니다. 잎 요소만 Text를 가집니다.
+// Attr에는 접두사 선언(xmlns:ianaift 등)만 남깁니다. 값에 쓰인 접두사(ianaift:ethernetCsmacd)가 깨지지 않도록 합니다.
+type xmlNode struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Text     string
+	Children []*xmlNode
+}
+
+// ncBase는 NETCONF 기본 네임스페이스입니다. edit-config의 operation 속성이 이 네임스페이스에 있습니다.
+const ncBase = "urn:ietf:params:xml:ns:netconf:base:1.0"
+
+// parseXML은 XML 조각(최상위 요소가 여러 개일 수 있음)을 이름 없는 루트 아래의 트리로 읽습니다.
+// operation 속성은 ops에 요소별로 담습니다. ops가 nil이면 버립니다.
+func parseXML(text string, ops map[*xmlNode]string) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	var text0 strings.Builder
+
+	d := xml.NewDecoder(strings.NewReader(text))
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{Name: t.Name}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					n.Attr = append(n.Attr, a)
+				case a.Name.Local == "operation" && (a.Name.Space == ncBase || a.Name.Space == ""):
+					if ops != nil {
+						ops[n] = a.Value
+					}
+				}
+			}
+			top.Children = append(top.Children, n)
+			stack = append(stack, n)
+			text0.Reset()
+		case xml.CharData:
+			text0.Write(t)
+		case xml.EndElement:
+			if len(top.Children) == 0 {
+				top.Text = strings.TrimSpace(text0.String())
+			}
+			stack = stack[:len(stack)-1]
+			text0.Reset()
+		}
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("unexpected end of XML")
+	}
+	return root, nil
+}
+
+// clone은 n의 깊은 복사본입니다.
+func (n *xmlNode) clone() *xmlNode {
+	c := &xmlNode{Name: n.Name, Attr: slices.Clone(n.Attr), Text: n.Text}
+	for _, ch := range n.Children {
+		c.Children = append(c.Children, ch.clone())
+	}
+	return c
+}
+
+// child는 이름이 local인 첫 번째 자식 요소입니다.
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.Children {
+		if c.Name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+// sameNode는 a와 b가 같은 요소를 가리키는지 확인합니다. 이름이 같고, 둘 다 <name> 자식(YANG 리스트 키)이 있으면 그 값도 같아야 합니다.
+// 네임스페이스는 한쪽에만 있으면 무시합니다.
+func sameNode(a, b *xmlNode) bool {
+	if a.Name.Local != b.Name.Local {
+		return false
+	}
+	if a.Name.Space != "" && b.Name.Space != "" && a.Name.Space != b.Name.Space {
+		return false
+	}
+	ak, bk := a.child("name"), b.child("name")
+	return ak == nil || bk == nil || ak.Text == bk.Text
+}
+
+// find는 n의 자식 중 c와 같은 요소의 위치입니다. 없으면 -1입니다.
+func (n *xmlNode) find(c *xmlNode) int {
+	return slices.IndexFunc(n.Children, func(x *xmlNode) bool { return sameNode(x, c) })
+}
+
+// errDataExists, errDataMissing은 edit-config의 create, delete가 실패한 경우입니다.
+var (
+	errDataExists  = errors.New("data-exists")
+	errDataMissing = errors.New("data-missing")
+)
+
+// merge는 edit 트리를 target에 적용합니다. (RFC 6241 7.2)
+// 기본 동작은 merge이고, 요소의 operation 속성으로 replace, create, delete, remove를 지정할 수 있습니다.
+func merge(target, edit *xmlNode, ops map[*xmlNode]string, op string) error {
+	for _, e := range edit.Children {
+		eop := op
+		if o, ok := ops[e]; ok {
+			eop = o
+		}
+		i := target.find(e)
+		switch eop {
+		case "delete", "remove":
+			if i < 0 {
+				if eop == "delete" {
+					return errDataMissing
+				}
+				continue
+			}
+			target.Children = slices.Delete(target.Children, i, i+1)
+		case "create", "replace":
+			if i >= 0 && eop == "create" {
+				return errDataExists
+			}
+			fresh := &xmlNode{Name: e.Name, Attr: slices.Clone(e.Attr), Text: e.Text}
+			if err := merge(fresh, e, ops, "merge"); err != nil {
+				return err
+			}
+			if i < 0 {
+				target.Children = append(target.Children, fresh)
+			} else {
+				target.Children[i] = fresh
+			}
+		default:
+			if i < 0 {
+				target.Children = append(target.Children, &xmlNode{Name: e.Name, Attr: slices.Clone(e.Attr)})
+				i = len(target.Children) - 1
+			}
+			t := target.Children[i]
+			if len(e.Children) == 0 {
+				t.Text, t.Children = e.Text, nil
+				continue
+			}
+			if err := merge(t, e, ops, eop); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filter는 subtree 필터(RFC 6241 6)로 data에서 고른 요소들입니다. 필터에 값이 있는 잎은 내용 비교(content match)로,
+// 값이 없는 잎과 하위 요소가 있는 요소는 선택으로 봅니다.
+func filter(data, f *xmlNode) *xmlNode {
+	out := &xmlNode{Name: data.Name, Attr: data.Attr}
+	for _, d := range data.Children {
+		for _, fc := range f.Children {
+			if fc.Name.Local != d.Name.Local || (fc.Name.Space != "" && d.Name.Space != "" && fc.Name.Space != d.Name.Space) {
+				continue
+			}
+			if sel, ok := filterNode(d, fc); ok {
+				out.Children = append(out.Children, sel)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// filterNode는 필터 요소 f에 맞는 d의 부분입니다. 내용 비교에 맞지 않으면 ok가 false입니다.
+func filterNode(d, f *xmlNode) (*xmlNode, bool) {
+	if len(f.Children) == 0 {
+		if f.Text != "" && f.Text != d.Text {
+			return nil, false
+		}
+		return d.clone(), true
+	}
+
+	var selects []*xmlNode
+	for _, fc := range f.Children {
+		if len(fc.Children) > 0 || fc.Text == "" {
+			selects = append(selects, fc)
+			continue
+		}
+		dc := d.child(fc.Name.Local)
+		if dc == nil || dc.Text != fc.Text {
+			return nil, false
+		}
+	}
+	// 내용 비교만 있으면 맞는 요소 전체를 고릅니다.
+	if len(selects) == 0 {
+		return d.clone(), true
+	}
+
+	out := &xmlNode{Name: d.Name, Attr: d.Attr}
+	for _, dc := range d.Children {
+		for _, fc := range f.Children {
+			if fc.Name.Local != dc.Name.Local {
+				continue
+			}
+			if len(fc.Children) == 0 && fc.Text != "" {
+				out.Children = append(out.Children, dc.clone())
+				break
+			}
+			if sel, ok := filterNode(dc, fc); ok {
+				out.Children = append(out.Children, sel)
+				break
+			}
+		}
+	}
+	return out, true
+}
+
+// write는 루트의 자식들을 들여쓴 XML로 씁니다. 네임스페이스는 부모와 다를 때만 적습니다.
+func (n *xmlNode) write(b *bytes.Buffer, depth int, space string) {
+	for _, c := range n.Children {
+		indent := strings.Repeat("  ", depth)
+		b.WriteString(indent + "<" + c.Name.Local)
+		if c.Name.Space != "" && c.Name.Space != space {
+			b.WriteString(` xmlns="`)
+			xml.EscapeText(b, []byte(c.Name.Space))
+			b.WriteString(`"`)
+		}
+		for _, a := range c.Attr {
+			b.WriteString(" xmlns:" + a.Name.Local + `="`)
+			xml.EscapeText(b, []byte(a.Value))
+			b.WriteString(`"`)
+		}
+		switch {
+		case len(c.Children) > 0:
+			b.WriteString(">\n")
+			c.write(b, depth+1, c.Name.Space)
+			b.WriteString(indent + "</" + c.Name.Local + ">\n")
+		case c.Text != "":
+			b.WriteString(">")
+			xml.EscapeText(b, []byte(c.Text))
+			b.WriteString("</" + c.Name.Local + ">\n")
+		default:
+			b.WriteString("/>\n")
+		}
+	}
+}
+
+// String은 루트의 자식들을 XML로 씁니다.
+func (n *xmlNode) String() string {
+	var b bytes.Buffer
+	n.write(&b, 0, "")
+	return b.String()
+}
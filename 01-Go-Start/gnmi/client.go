@@ -0,0 +1,382 @@
+package gnmi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/value"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+)
+
+// 인벤토리에서 mode: gnmi인 장비에 gNMI(gRPC)로 Get, Set, Subscribe를 보냅니다.
+// 응답의 알림은 Flatten으로 잎 값(Record)마다 펼쳐서 Sink(JSON 줄, Prometheus)로 내보냅니다.
+// 실패 단계는 NETCONF와 같이 device.StageOpen(접속과 Capabilities), device.StageRPC, device.StageParse입니다.
+//
+//	c, err := gnmi.Dial(ctx, r, gnmi.Options{})
+//	if err != nil { ... }
+//	defer c.Close()
+//	recs, err := c.Get(ctx, "/interfaces/interface[name=Ethernet1]/state")
+
+// Options는 gNMI 접속 옵션입니다. 기본은 TLS 없이(평문) 접속합니다.
+type Options struct {
+	TLS bool
+	// SkipVerify이면 장비 인증서를 검사하지 않습니다.
+	SkipVerify bool
+	// CA는 장비 인증서를 검사할 CA 인증서(PEM) 파일입니다. 비어 있으면 시스템 CA입니다.
+	CA string
+}
+
+// Register는 -gnmi-tls, -gnmi-skip-verify, -gnmi-ca 플래그를 등록합니다.
+func (o *Options) Register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.TLS, "gnmi-tls", false, "connect to gNMI targets over TLS")
+	fs.BoolVar(&o.SkipVerify, "gnmi-skip-verify", false, "do not verify the target's TLS certificate")
+	fs.StringVar(&o.CA, "gnmi-ca", "", "verify the target's TLS certificate with this CA file (PEM)")
+}
+
+func (o Options) credentials() (credentials.TransportCredentials, error) {
+	if !o.TLS && !o.SkipVerify && o.CA == "" {
+		return insecure.NewCredentials(), nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: o.SkipVerify}
+	if o.CA != "" {
+		b, err := os.ReadFile(o.CA)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("%s: no certificates", o.CA)
+		}
+	}
+	return credentials.NewTLS(cfg), nil
+}
+
+// login은 인벤토리의 계정을 RPC마다 메타데이터(username, password)로 보냅니다.
+type login struct {
+	username, password string
+}
+
+func (l login) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"username": l.username, "password": l.password}, nil
+}
+
+// RequireTransportSecurity가 false이므로 평문 접속에서도 계정을 보냅니다. (랩 장비용)
+func (l login) RequireTransportSecurity() bool {
+	return false
+}
+
+// Client는 장비 한 대와의 gNMI 연결입니다.
+type Client struct {
+	Host inventory.Router
+	// Encoding은 Get과 Subscribe에 쓰는 인코딩입니다. 장비가 지원하면 JSON_IETF, 아니면 JSON입니다.
+	Encoding gpb.Encoding
+
+	conn *grpc.ClientConn
+	gnmi gpb.GNMIClient
+}
+
+// Dial은 장비에 gNMI로 접속하고 Capabilities로 인코딩을 정합니다. Port를 지정하지 않았으면 57400번 포트입니다.
+// gRPC는 처음 RPC를 보낼 때 접속하므로 접속과 로그인 실패는 Capabilities에서 StageOpen 에러로 드러납니다.
+func Dial(ctx context.Context, r inventory.Router, opts Options) (*Client, error) {
+	if r.Port == 0 {
+		r.Port = inventory.DefaultGNMIPort
+	}
+	creds, err := opts.credentials()
+	if err != nil {
+		return nil, runner.Fail(device.StageDriver, err)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if r.Username != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(login{r.Username, r.Password}))
+	}
+	conn, err := grpc.NewClient(net.JoinHostPort(r.Address(), strconv.Itoa(r.Port)), dialOpts...)
+	if err != nil {
+		return nil, runner.Fail(device.StageDriver, err)
+	}
+
+	c := &Client{Host: r, Encoding: gpb.Encoding_JSON, conn: conn, gnmi: gpb.NewGNMIClient(conn)}
+	caps, err := c.gnmi.Capabilities(ctx, &gpb.CapabilityRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, runner.Fail(device.StageOpen, ctxErr(ctx, err))
+	}
+	if slices.Contains(caps.GetSupportedEncodings(), gpb.Encoding_JSON_IETF) {
+		c.Encoding = gpb.Encoding_JSON_IETF
+	}
+	return c, nil
+}
+
+// Close는 연결을 닫습니다.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ctxErr는 ctx가 끝나서 RPC가 실패했으면 ctx의 에러를 돌려줍니다. (Report에서 timeout, canceled로 보이도록)
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// parsePaths는 문자열 경로들을 gNMI 경로로 바꿉니다.
+func parsePaths(paths []string) ([]*gpb.Path, error) {
+	out := make([]*gpb.Path, len(paths))
+	for i, s := range paths {
+		p, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = p
+	}
+	return out, nil
+}
+
+// Get은 paths의 지금 값을 잎 값마다 펼쳐서 돌려줍니다. paths가 없으면 루트(전부)입니다.
+func (c *Client) Get(ctx context.Context, paths ...string) ([]Record, error) {
+	if len(paths) == 0 {
+		paths = []string{"/"}
+	}
+	ps, err := parsePaths(paths)
+	if err != nil {
+		return nil, runner.Fail(device.StageRPC, err)
+	}
+	rs, err := c.gnmi.Get(ctx, &gpb.GetRequest{Path: ps, Encoding: c.Encoding})
+	if err != nil {
+		return nil, runner.Fail(device.StageRPC, ctxErr(ctx, err))
+	}
+	var recs []Record
+	for _, n := range rs.GetNotification() {
+		rs, err := Flatten(c.Host.Hostname, n)
+		if err != nil {
+			return recs, runner.Fail(device.StageParse, err)
+		}
+		recs = append(recs, rs...)
+	}
+	return recs, nil
+}
+
+// Update는 Set으로 넣을 값입니다.
+type Update struct {
+	Path string
+	// Value는 JSON으로 바꿀 수 있는 값입니다. 하위 트리를 넣으려면 map이나 구조체를 씁니다.
+	Value any
+}
+
+// ParseUpdate는 "path=value" 형식을 읽습니다. value가 JSON이 아니면 문자열로 봅니다.
+//
+//	/system/config/hostname=rtr1
+//	/interfaces/interface[name=Ethernet1]/config={"description":"uplink","mtu":9000}
+func ParseUpdate(s string) (Update, error) {
+	// 경로의 키에도 '='가 있으므로 [] 밖에 있는 첫 번째 '='에서 나눕니다.
+	i, depth := -1, 0
+	for j := 0; j < len(s) && i < 0; j++ {
+		switch s[j] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '=':
+			if depth == 0 {
+				i = j
+			}
+		}
+	}
+	if i < 0 {
+		return Update{}, fmt.Errorf("update %q is not path=value", s)
+	}
+	v := s[i+1:]
+	u := Update{Path: s[:i], Value: v}
+	d := json.NewDecoder(strings.NewReader(v))
+	d.UseNumber()
+	var val any
+	if d.Decode(&val) == nil && !d.More() {
+		u.Value = scalar(val)
+	}
+	return u, nil
+}
+
+// typedValue는 값을 TypedValue로 바꿉니다. 스칼라는 그대로, 나머지는 c.Encoding의 JSON입니다.
+func (c *Client) typedValue(v any) (*gpb.TypedValue, error) {
+	switch v.(type) {
+	case map[string]any, []any, nil:
+	default:
+		if tv, err := value.FromScalar(v); err == nil {
+			return tv, nil
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if c.Encoding == gpb.Encoding_JSON_IETF {
+		return &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{JsonIetfVal: b}}, nil
+	}
+	return &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{JsonVal: b}}, nil
+}
+
+func (c *Client) updates(us []Update) ([]*gpb.Update, error) {
+	var out []*gpb.Update
+	for _, u := range us {
+		p, err := ParsePath(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		tv, err := c.typedValue(u.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", u.Path, err)
+		}
+		out = append(out, &gpb.Update{Path: p, Val: tv})
+	}
+	return out, nil
+}
+
+// SetResult는 Set에서 장비가 처리한 경로 하나입니다.
+type SetResult struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+}
+
+// Set은 deletes, replaces, updates를 한 트랜잭션으로 보냅니다. 장비는 순서대로(delete, replace, update) 적용하고
+// 하나라도 실패하면 전부 되돌립니다. (gNMI 3.4.3)
+func (c *Client) Set(ctx context.Context, deletes []string, replaces, updates []Update) ([]SetResult, error) {
+	req := &gpb.SetRequest{}
+	var err error
+	if req.Delete, err = parsePaths(deletes); err != nil {
+		return nil, runner.Fail(device.StageRPC, err)
+	}
+	if req.Replace, err = c.updates(replaces); err != nil {
+		return nil, runner.Fail(device.StageRPC, err)
+	}
+	if req.Update, err = c.updates(updates); err != nil {
+		return nil, runner.Fail(device.StageRPC, err)
+	}
+
+	rs, err := c.gnmi.Set(ctx, req)
+	if err != nil {
+		return nil, runner.Fail(device.StageRPC, ctxErr(ctx, err))
+	}
+	var results []SetResult
+	for _, r := range rs.GetResponse() {
+		results = append(results, SetResult{Op: r.GetOp().String(), Path: PathString(Join(rs.GetPrefix(), r.GetPath()))})
+	}
+	return results, nil
+}
+
+// 구독 방식입니다.
+const (
+	OnChange = "on_change"
+	Sample   = "sample"
+)
+
+// Subscription은 STREAM 구독 하나입니다.
+type Subscription struct {
+	Paths []string
+	// Mode는 OnChange 또는 Sample입니다. 비어 있으면 장비가 정합니다. (TARGET_DEFINED)
+	Mode string
+	// Interval은 Sample의 보고 간격입니다.
+	Interval time.Duration
+	// UpdatesOnly이면 처음 값 전체(초기 동기화)를 받지 않고 바뀐 값만 받습니다.
+	UpdatesOnly bool
+}
+
+func (s Subscription) request(enc gpb.Encoding) (*gpb.SubscribeRequest, error) {
+	var mode gpb.SubscriptionMode
+	switch s.Mode {
+	case "":
+		mode = gpb.SubscriptionMode_TARGET_DEFINED
+	case OnChange:
+		mode = gpb.SubscriptionMode_ON_CHANGE
+	case Sample:
+		mode = gpb.SubscriptionMode_SAMPLE
+	default:
+		return nil, fmt.Errorf("unknown subscription mode %q (want %s or %s)", s.Mode, OnChange, Sample)
+	}
+	if len(s.Paths) == 0 {
+		return nil, errors.New("subscription has no paths")
+	}
+
+	list := &gpb.SubscriptionList{Mode: gpb.SubscriptionList_STREAM, Encoding: enc, UpdatesOnly: s.UpdatesOnly}
+	for _, ps := range s.Paths {
+		p, err := ParsePath(ps)
+		if err != nil {
+			return nil, err
+		}
+		list.Subscription = append(list.Subscription, &gpb.Subscription{
+			Path:           p,
+			Mode:           mode,
+			SampleInterval: uint64(s.Interval.Nanoseconds()),
+		})
+	}
+	return &gpb.SubscribeRequest{Request: &gpb.SubscribeRequest_Subscribe{Subscribe: list}}, nil
+}
+
+// Subscribe는 s를 구독하고 받은 알림을 펼쳐서 fn에 넘깁니다. 초기 동기화가 끝나면(sync_response) synced를 부릅니다.
+// ctx가 끝날 때까지 이어지고, ctx가 끝나서 멈춘 경우는 nil입니다. fn의 에러는 구독을 멈추고 그대로 돌려줍니다.
+func (c *Client) Subscribe(ctx context.Context, s Subscription, fn func([]Record) error, synced func()) error {
+	req, err := s.request(c.Encoding)
+	if err != nil {
+		return runner.Fail(device.StageRPC, err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.gnmi.Subscribe(ctx)
+	if err != nil {
+		return subscribeErr(ctx, err)
+	}
+	if err := stream.Send(req); err != nil {
+		return subscribeErr(ctx, err)
+	}
+
+	for {
+		rs, err := stream.Recv()
+		if err != nil {
+			return subscribeErr(ctx, err)
+		}
+		switch r := rs.GetResponse().(type) {
+		case *gpb.SubscribeResponse_Update:
+			recs, err := Flatten(c.Host.Hostname, r.Update)
+			if err != nil {
+				return runner.Fail(device.StageParse, err)
+			}
+			if err := fn(recs); err != nil {
+				return err
+			}
+		case *gpb.SubscribeResponse_SyncResponse:
+			if synced != nil {
+				synced()
+			}
+		case *gpb.SubscribeResponse_Error:
+			return runner.Fail(device.StageRPC, errors.New(r.Error.GetMessage()))
+		}
+	}
+}
+
+func subscribeErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		err = errors.New("target closed the subscription")
+	}
+	return runner.Fail(device.StageRPC, err)
+}
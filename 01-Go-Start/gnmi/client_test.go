@@ -0,0 +1,190 @@
+package gnmi_test
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/gnmi"
+	"tucker-study/01-Go-Start/runner"
+)
+
+const (
+	eth1        = "/interfaces/interface[name=Ethernet1]"
+	description = eth1 + "/config/description"
+	mtu         = eth1 + "/config/mtu"
+	eth2Status  = "/interfaces/interface[name=Ethernet2]/state/oper-status"
+)
+
+// dial은 흉내 gNMI 장비를 띄우고 접속합니다. 테스트가 끝나면 둘 다 닫습니다.
+func dial(t *testing.T, d fakedevice.Device) (*fakedevice.GNMIServer, *gnmi.Client) {
+	t.Helper()
+	srv, err := fakedevice.StartGNMI(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c, err := gnmi.Dial(ctx, srv.Router(), gnmi.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return srv, c
+}
+
+// find는 recs에서 path의 Record를 찾습니다.
+func find(recs []gnmi.Record, path string) (gnmi.Record, bool) {
+	i := slices.IndexFunc(recs, func(r gnmi.Record) bool { return r.Path == path })
+	if i < 0 {
+		return gnmi.Record{}, false
+	}
+	return recs[i], true
+}
+
+func TestGet(t *testing.T) {
+	_, c := dial(t, fakedevice.Device{Hostname: "rtr1", Platform: "arista_eos"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 잎은 값 그대로, 하위 트리는 잎 값마다 펼쳐서 받습니다.
+	recs, err := c.Get(ctx, "/system/config/hostname", eth1+"/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]any{
+		"/system/config/hostname":   "rtr1",
+		eth1 + "/state/oper-status": "UP",
+	} {
+		r, ok := find(recs, path)
+		if !ok {
+			t.Errorf("no record for %s in %v", path, recs)
+			continue
+		}
+		if r.Value != want || r.Host != "rtr1" {
+			t.Errorf("%s = %v from %q, want %v from rtr1", path, r.Value, r.Host, want)
+		}
+	}
+
+	if _, err := c.Get(ctx, "/no/such/path"); err == nil {
+		t.Error("Get succeeded for a missing path")
+	} else if stage := runner.StageOf(err); stage != device.StageRPC {
+		t.Errorf("stage = %q, want %q", stage, device.StageRPC)
+	}
+}
+
+func TestSet(t *testing.T) {
+	srv, c := dial(t, fakedevice.Device{Hostname: "rtr1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results, err := c.Set(ctx, nil, nil, []gnmi.Update{{Path: description, Value: "core"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []gnmi.SetResult{{Op: "UPDATE", Path: description}}; !slices.Equal(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	if v, _ := srv.Value(description); v != "core" {
+		t.Errorf("description = %v, want core", v)
+	}
+}
+
+func TestSetReject(t *testing.T) {
+	srv, c := dial(t, fakedevice.Device{
+		Hostname: "rtr1",
+		Reject:   []*regexp.Regexp{regexp.MustCompile(`/mtu 9999$`)},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 하나라도 거부되면 같은 트랜잭션의 다른 값도 바뀌지 않습니다.
+	_, err := c.Set(ctx, nil, nil, []gnmi.Update{
+		{Path: description, Value: "core"},
+		{Path: mtu, Value: int64(9999)},
+	})
+	if err == nil {
+		t.Fatal("Set succeeded with a rejected value")
+	}
+	if stage := runner.StageOf(err); stage != device.StageRPC {
+		t.Errorf("stage = %q, want %q", stage, device.StageRPC)
+	}
+	if v, _ := srv.Value(description); v != "uplink" {
+		t.Errorf("description = %v, want uplink", v)
+	}
+	if v, _ := srv.Value(mtu); v != int64(1500) {
+		t.Errorf("mtu = %v (%T), want 1500", v, v)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	srv, c := dial(t, fakedevice.Device{Hostname: "rtr1"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	recs := make(chan gnmi.Record, 16)
+	synced := make(chan struct{})
+	done := make(chan error, 1)
+	sub := gnmi.Subscription{Paths: []string{eth2Status, eth1 + "/config"}, Mode: gnmi.OnChange}
+	go func() {
+		done <- c.Subscribe(ctx, sub, func(rs []gnmi.Record) error {
+			for _, r := range rs {
+				recs <- r
+			}
+			return nil
+		}, func() { close(synced) })
+	}()
+
+	// next는 path의 Record가 올 때까지 기다립니다.
+	next := func(path string) gnmi.Record {
+		t.Helper()
+		for {
+			select {
+			case r := <-recs:
+				if r.Path == path {
+					return r
+				}
+			case err := <-done:
+				t.Fatalf("subscription ended: %v", err)
+			case <-ctx.Done():
+				t.Fatalf("no update for %s", path)
+			}
+		}
+	}
+
+	// 처음 값을 받은 뒤 sync_response가 옵니다.
+	if r := next(eth2Status); r.Value != "DOWN" {
+		t.Errorf("initial %s = %v, want DOWN", eth2Status, r.Value)
+	}
+	select {
+	case <-synced:
+	case <-ctx.Done():
+		t.Fatal("no sync_response")
+	}
+
+	if err := srv.Update(eth2Status, "UP"); err != nil {
+		t.Fatal(err)
+	}
+	if r := next(eth2Status); r.Value != "UP" {
+		t.Errorf("%s = %v, want UP", eth2Status, r.Value)
+	}
+
+	// 지운 경로는 Deleted로 옵니다.
+	if err := srv.Update(description, nil); err != nil {
+		t.Fatal(err)
+	}
+	if r := next(description); !r.Deleted {
+		t.Errorf("%s = %+v, want deleted", description, r)
+	}
+
+	// ctx가 끝나서 멈춘 구독은 에러가 아닙니다.
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Subscribe after cancel = %v", err)
+	}
+}
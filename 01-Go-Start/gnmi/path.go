@@ -0,0 +1,148 @@
+package gnmi
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ParsePath는 "/interfaces/interface[name=Ethernet1/1]/state/counters" 같은 문자열 경로를 gNMI 경로로 바꿉니다.
+// 키 값 안의 '/'는 경로 구분자로 보지 않습니다. "origin:/..."처럼 쓰면 origin을 지정합니다.
+// 빈 문자열과 "/"는 루트입니다.
+func ParsePath(s string) (*gpb.Path, error) {
+	p := &gpb.Path{}
+	if origin, rest, ok := strings.Cut(s, ":/"); ok && !strings.ContainsAny(origin, "/[") {
+		p.Origin, s = origin, "/"+rest
+	}
+
+	var elems []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth == 0 {
+				return nil, fmt.Errorf("path %q: unexpected ]", s)
+			}
+			depth--
+		case '/':
+			if depth == 0 {
+				elems = append(elems, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("path %q: missing ]", s)
+	}
+	elems = append(elems, s[start:])
+
+	for _, e := range elems {
+		if e == "" {
+			continue
+		}
+		elem, err := parseElem(e)
+		if err != nil {
+			return nil, fmt.Errorf("path %q: %w", s, err)
+		}
+		p.Elem = append(p.Elem, elem)
+	}
+	return p, nil
+}
+
+// MustParsePath는 ParsePath와 같지만 잘못된 경로이면 panic합니다. 코드에 적은 경로용입니다.
+func MustParsePath(s string) *gpb.Path {
+	p, err := ParsePath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// parseElem은 "interface[name=Gi1][unit=0]" 같은 경로 요소 하나를 읽습니다.
+func parseElem(s string) (*gpb.PathElem, error) {
+	name, rest, _ := strings.Cut(s, "[")
+	if name == "" {
+		return nil, fmt.Errorf("element %q has no name", s)
+	}
+	elem := &gpb.PathElem{Name: name}
+	if rest == "" {
+		return elem, nil
+	}
+
+	rest = "[" + rest
+	for rest != "" {
+		end := strings.Index(rest, "]")
+		if rest[0] != '[' || end < 0 {
+			return nil, fmt.Errorf("element %q: bad key", s)
+		}
+		k, v, ok := strings.Cut(rest[1:end], "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("element %q: key %q is not name=value", s, rest[1:end])
+		}
+		if elem.Key == nil {
+			elem.Key = map[string]string{}
+		}
+		elem.Key[k] = v
+		rest = rest[end+1:]
+	}
+	return elem, nil
+}
+
+// PathString은 p를 ParsePath가 읽을 수 있는 문자열로 씁니다. 키는 이름 순서입니다.
+func PathString(p *gpb.Path) string {
+	var b strings.Builder
+	if p.GetOrigin() != "" {
+		b.WriteString(p.GetOrigin() + ":")
+	}
+	for _, e := range p.GetElem() {
+		b.WriteString("/" + e.Name)
+		for _, k := range slices.Sorted(maps.Keys(e.Key)) {
+			b.WriteString("[" + k + "=" + e.Key[k] + "]")
+		}
+	}
+	if len(p.GetElem()) == 0 {
+		b.WriteString("/")
+	}
+	return b.String()
+}
+
+// Join은 prefix 뒤에 p의 요소를 붙인 경로입니다. origin과 target은 prefix의 것을 먼저 씁니다.
+func Join(prefix, p *gpb.Path) *gpb.Path {
+	if prefix == nil {
+		return p
+	}
+	out := &gpb.Path{
+		Origin: prefix.Origin,
+		Target: prefix.Target,
+		Elem:   append(slices.Clone(prefix.Elem), p.GetElem()...),
+	}
+	if out.Origin == "" {
+		out.Origin = p.GetOrigin()
+	}
+	return out
+}
+
+// Match는 경로 pattern이 p와 같거나 p의 상위 경로인지 확인합니다.
+// pattern의 요소 이름이나 키 값이 "*"이면 무엇이든 맞고, pattern에 없는 키는 보지 않습니다.
+func Match(pattern, p *gpb.Path) bool {
+	if len(pattern.GetElem()) > len(p.GetElem()) {
+		return false
+	}
+	for i, pe := range pattern.GetElem() {
+		e := p.Elem[i]
+		if pe.Name != "*" && pe.Name != e.Name {
+			return false
+		}
+		for k, v := range pe.Key {
+			if v != "*" && e.Key[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
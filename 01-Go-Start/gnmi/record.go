@@ -0,0 +1,151 @@
+package gnmi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/value"
+)
+
+// Record는 알림(Notification)의 잎 값 하나입니다. 출력(Sink)은 Record 단위로 씁니다.
+type Record struct {
+	Host      string    `json:"host"`
+	Path      string    `json:"path"`
+	Value     any       `json:"value,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Deleted는 장비에서 경로가 지워졌다는 알림입니다. Value는 비어 있습니다.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// listKeys는 JSON 값을 펼칠 때 리스트 요소의 키로 쓰는 잎 이름입니다. (OpenConfig 모델에서 흔히 쓰는 키)
+// 이 중 어느 것도 없는 리스트 요소는 "[0]"처럼 위치를 적습니다. 이런 경로는 gNMI 경로가 아닙니다.
+var listKeys = []string{"name", "index", "id", "identifier", "neighbor-address", "ip", "prefix", "vlan-id"}
+
+// Flatten은 알림을 잎 값마다 Record로 펼칩니다. 경로는 알림의 prefix를 붙인 전체 경로입니다.
+// JSON, JSON_IETF 값은 잎 경로들로 펼치고, JSON_IETF의 모듈 접두사(openconfig-interfaces:)는 떼어 냅니다.
+func Flatten(host string, n *gpb.Notification) ([]Record, error) {
+	ts := time.Unix(0, n.GetTimestamp())
+	var recs []Record
+	for _, u := range n.GetUpdate() {
+		p := PathString(Join(n.GetPrefix(), u.GetPath()))
+		switch v := u.GetVal().GetValue().(type) {
+		case *gpb.TypedValue_JsonVal:
+			rs, err := flattenJSON(host, p, v.JsonVal, ts)
+			if err != nil {
+				return recs, err
+			}
+			recs = append(recs, rs...)
+		case *gpb.TypedValue_JsonIetfVal:
+			rs, err := flattenJSON(host, p, v.JsonIetfVal, ts)
+			if err != nil {
+				return recs, err
+			}
+			recs = append(recs, rs...)
+		case *gpb.TypedValue_AsciiVal:
+			recs = append(recs, Record{Host: host, Path: p, Value: v.AsciiVal, Timestamp: ts})
+		default:
+			val, err := value.ToScalar(u.GetVal())
+			if err != nil {
+				return recs, fmt.Errorf("%s: %w", p, err)
+			}
+			recs = append(recs, Record{Host: host, Path: p, Value: val, Timestamp: ts})
+		}
+	}
+	for _, d := range n.GetDelete() {
+		recs = append(recs, Record{Host: host, Path: PathString(Join(n.GetPrefix(), d)), Timestamp: ts, Deleted: true})
+	}
+	return recs, nil
+}
+
+func flattenJSON(host, p string, b []byte, ts time.Time) ([]Record, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	var recs []Record
+	walkJSON(strings.TrimSuffix(p, "/"), v, func(path string, val any) {
+		if path == "" {
+			path = "/"
+		}
+		recs = append(recs, Record{Host: host, Path: path, Value: val, Timestamp: ts})
+	})
+	return recs, nil
+}
+
+// walkJSON은 JSON 트리의 잎마다 fn을 부릅니다. 잎 리스트(값 배열)는 하나의 값입니다.
+func walkJSON(p string, v any, fn func(string, any)) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			walkJSON(p+"/"+stripModule(k), v[k], fn)
+		}
+	case []any:
+		if !slices.ContainsFunc(v, isObject) {
+			vals := make([]any, len(v))
+			for i, e := range v {
+				vals[i] = scalar(e)
+			}
+			fn(p, vals)
+			return
+		}
+		for i, e := range v {
+			walkJSON(p+listKey(e, i), e, fn)
+		}
+	default:
+		fn(p, scalar(v))
+	}
+}
+
+func isObject(v any) bool {
+	_, ok := v.(map[string]any)
+	return ok
+}
+
+// listKey는 리스트 요소 e의 경로 키("[name=Gi1]")입니다.
+func listKey(e any, i int) string {
+	obj, _ := e.(map[string]any)
+	keys := map[string]any{}
+	for k, v := range obj {
+		if k = stripModule(k); slices.Contains(listKeys, k) && !isObject(v) {
+			keys[k] = v
+		}
+	}
+	if len(keys) == 0 {
+		return fmt.Sprintf("[%d]", i)
+	}
+	// PathString과 같은 경로가 되도록 키 이름 순서로 적습니다.
+	var b strings.Builder
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		fmt.Fprintf(&b, "[%s=%v]", k, keys[k])
+	}
+	return b.String()
+}
+
+// stripModule은 JSON_IETF 이름의 모듈 접두사를 뗍니다. ("openconfig-interfaces:interfaces" → "interfaces")
+func stripModule(name string) string {
+	if _, local, ok := strings.Cut(name, ":"); ok {
+		return local
+	}
+	return name
+}
+
+// scalar는 json.Number를 int64 또는 float64로 바꿉니다.
+func scalar(v any) any {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
@@ -0,0 +1,188 @@
+package gnmi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Sink는 Record를 내보내는 곳입니다. 여러 장비의 구독이 함께 부르므로 동시에 불러도 안전해야 합니다.
+type Sink interface {
+	Write(recs []Record) error
+}
+
+// JSONSink는 Record를 한 줄에 하나씩 JSON으로 씁니다. (JSON Lines)
+type JSONSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONSink는 w에 쓰는 JSONSink를 만듭니다. 파일에 쓰려면 os.Create로 연 파일을 넘깁니다.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{enc: json.NewEncoder(w)}
+}
+
+func (s *JSONSink) Write(recs []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range recs {
+		if err := s.enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Exporter는 잎 값의 마지막 값을 Prometheus 텍스트 형식으로 보여주는 http.Handler입니다.
+// 숫자와 bool(0, 1) 값만 내보내고, 숫자로 읽을 수 있는 문자열(JSON_IETF의 64비트 카운터)도 숫자로 봅니다.
+//
+// 메트릭 이름은 키를 뺀 경로이고, 키는 "<요소>_<키>" 레이블이 됩니다. 장비는 host 레이블입니다.
+//
+//	/interfaces/interface[name=Ethernet1]/state/counters/in-octets
+//	→ gnmi_interfaces_interface_state_counters_in_octets{host="rtr1",interface_name="Ethernet1"}
+type Exporter struct {
+	mu     sync.Mutex
+	series map[string]map[string]sample // 메트릭 이름 → 레이블 → 값
+}
+
+// sample은 시계열 하나의 마지막 값입니다. 지워진 경로 아래의 시계열을 찾을 수 있도록 장비와 경로 요소를 함께 둡니다.
+type sample struct {
+	host  string
+	elems []*gpb.PathElem
+	value float64
+}
+
+// NewExporter는 빈 Exporter를 만듭니다. http.Handle("/metrics", e)처럼 씁니다.
+func NewExporter() *Exporter {
+	return &Exporter{series: map[string]map[string]sample{}}
+}
+
+func (e *Exporter) Write(recs []Record) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, r := range recs {
+		p, err := ParsePath(r.Path)
+		if err != nil {
+			continue
+		}
+		if r.Deleted {
+			// 지워진 경로 아래의 시계열을 모두 지웁니다.
+			for n, s := range e.series {
+				maps.DeleteFunc(s, func(_ string, smp sample) bool {
+					return smp.host == r.Host && under(smp.elems, p.GetElem())
+				})
+				if len(s) == 0 {
+					delete(e.series, n)
+				}
+			}
+			continue
+		}
+		v, ok := number(r.Value)
+		if !ok {
+			continue
+		}
+		name, labels := metric(r.Host, p)
+		if e.series[name] == nil {
+			e.series[name] = map[string]sample{}
+		}
+		e.series[name][labels] = sample{host: r.Host, elems: p.GetElem(), value: v}
+	}
+	return nil
+}
+
+// under는 경로 요소 elems가 prefix와 같거나 그 아래인지 확인합니다.
+// prefix의 요소에 키가 없거나 값이 *이면 그 목록의 모든 항목과 맞습니다. (/interfaces/interface는 모든 인터페이스)
+func under(elems, prefix []*gpb.PathElem) bool {
+	if len(prefix) > len(elems) {
+		return false
+	}
+	for i, pe := range prefix {
+		if stripModule(pe.Name) != stripModule(elems[i].Name) {
+			return false
+		}
+		for k, v := range pe.Key {
+			if v != "*" && elems[i].Key[k] != v {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range slices.Sorted(maps.Keys(e.series)) {
+		fmt.Fprintf(w, "# TYPE %s untyped\n", name)
+		s := e.series[name]
+		for _, l := range slices.Sorted(maps.Keys(s)) {
+			fmt.Fprintf(w, "%s{%s} %s\n", name, l, strconv.FormatFloat(s[l].value, 'g', -1, 64))
+		}
+	}
+}
+
+// metric은 경로의 메트릭 이름과 레이블 문자열(정렬된 k="v" 목록)입니다.
+func metric(host string, p *gpb.Path) (string, string) {
+	name := []string{"gnmi"}
+	labels := []string{"host=" + quote(host)}
+	for _, e := range p.GetElem() {
+		elem := sanitize(stripModule(e.Name))
+		name = append(name, elem)
+		for k, v := range e.Key {
+			labels = append(labels, elem+"_"+sanitize(k)+"="+quote(v))
+		}
+	}
+	slices.Sort(labels)
+	return strings.Join(name, "_"), strings.Join(labels, ",")
+}
+
+// labelEscaper는 Prometheus 텍스트 형식의 레이블 값에서 이스케이프해야 하는 문자입니다.
+// strconv.Quote와 달리 ASCII가 아닌 문자는 그대로 둡니다.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quote는 레이블 값을 따옴표로 감쌉니다.
+func quote(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+// sanitize는 Prometheus 이름에 쓸 수 없는 문자를 _로 바꿉니다.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// number는 Record 값을 숫자로 바꿉니다.
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
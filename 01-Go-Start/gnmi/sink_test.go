@@ -0,0 +1,114 @@
+package gnmi_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tucker-study/01-Go-Start/gnmi"
+)
+
+const inOctets = "gnmi_interfaces_interface_state_counters_in_octets"
+
+// scrape는 e의 /metrics 출력에서 # 줄을 뺀 시계열 줄들입니다.
+func scrape(e *gnmi.Exporter) []string {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	var lines []string
+	for _, l := range strings.Split(strings.TrimSpace(w.Body.String()), "\n") {
+		if l != "" && !strings.HasPrefix(l, "#") {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func counter(host, name string, v any) gnmi.Record {
+	return gnmi.Record{Host: host, Path: "/interfaces/interface[name=" + name + "]/state/counters/in-octets", Value: v}
+}
+
+func TestExporter(t *testing.T) {
+	e := gnmi.NewExporter()
+	e.Write([]gnmi.Record{
+		counter("rtr1", "Ethernet1", int64(10)),
+		// JSON_IETF의 64비트 카운터는 문자열로 옵니다.
+		counter("rtr2", "Ethernet1", "18446744073709551615"),
+		// 숫자가 아닌 값은 내보내지 않습니다.
+		{Host: "rtr1", Path: "/interfaces/interface[name=Ethernet1]/state/oper-status", Value: "UP"},
+		{Host: "rtr1", Path: "/interfaces/interface[name=Ethernet1]/config/enabled", Value: true},
+	})
+
+	got := strings.Join(scrape(e), "\n")
+	want := strings.Join([]string{
+		`gnmi_interfaces_interface_config_enabled{host="rtr1",interface_name="Ethernet1"} 1`,
+		inOctets + `{host="rtr1",interface_name="Ethernet1"} 10`,
+		inOctets + `{host="rtr2",interface_name="Ethernet1"} 1.8446744073709552e+19`,
+	}, "\n")
+	if got != want {
+		t.Errorf("metrics:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestExporterEscape(t *testing.T) {
+	// 레이블 값의 \, ", 줄바꿈만 이스케이프하고 한글 같은 문자는 그대로 둡니다.
+	e := gnmi.NewExporter()
+	e.Write([]gnmi.Record{counter("서울\"1\n", `Gi0\1`, int64(1))})
+
+	want := inOctets + `{host="서울\"1\n",interface_name="Gi0\\1"} 1`
+	if got := scrape(e); len(got) != 1 || got[0] != want {
+		t.Errorf("metrics = %q, want %q", got, want)
+	}
+}
+
+func TestExporterDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		del  gnmi.Record
+		want []string // 남는 시계열의 레이블
+	}{
+		{
+			name: "one interface",
+			del:  gnmi.Record{Host: "rtr1", Path: "/interfaces/interface[name=Ethernet1]", Deleted: true},
+			want: []string{`host="rtr1",interface_name="Ethernet2"`, `host="rtr2",interface_name="Ethernet1"`},
+		},
+		{
+			name: "list without keys",
+			del:  gnmi.Record{Host: "rtr1", Path: "/interfaces/interface", Deleted: true},
+			want: []string{`host="rtr2",interface_name="Ethernet1"`},
+		},
+		{
+			name: "wildcard key",
+			del:  gnmi.Record{Host: "rtr2", Path: "/interfaces/interface[name=*]/state", Deleted: true},
+			want: []string{`host="rtr1",interface_name="Ethernet1"`, `host="rtr1",interface_name="Ethernet2"`},
+		},
+		{
+			name: "module prefix",
+			del:  gnmi.Record{Host: "rtr1", Path: "/openconfig-interfaces:interfaces/interface[name=Ethernet2]", Deleted: true},
+			want: []string{`host="rtr1",interface_name="Ethernet1"`, `host="rtr2",interface_name="Ethernet1"`},
+		},
+		{
+			name: "other branch",
+			del:  gnmi.Record{Host: "rtr1", Path: "/interfaces/interface[name=Ethernet1]/config", Deleted: true},
+			want: []string{`host="rtr1",interface_name="Ethernet1"`, `host="rtr1",interface_name="Ethernet2"`, `host="rtr2",interface_name="Ethernet1"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := gnmi.NewExporter()
+			e.Write([]gnmi.Record{
+				counter("rtr1", "Ethernet1", int64(1)),
+				counter("rtr1", "Ethernet2", int64(1)),
+				counter("rtr2", "Ethernet1", int64(1)),
+			})
+			e.Write([]gnmi.Record{tt.del})
+
+			var want []string
+			for _, l := range tt.want {
+				want = append(want, inOctets+"{"+l+"} 1")
+			}
+			if got := scrape(e); strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("metrics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
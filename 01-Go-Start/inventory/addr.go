@@ -0,0 +1,129 @@
+package inventory
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/netip"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MgmtAddr은 장비의 관리 주소입니다.
+// "192.0.2.1"처럼 주소만 적거나 "192.0.2.1/24"처럼 프리픽스 길이와 함께 적을 수 있고,
+// 주소만 적으면 /32(IPv6는 /128) 프리픽스로 취급합니다.
+type MgmtAddr netip.Prefix
+
+// ParseMgmtAddr은 주소 또는 프리픽스 표기의 관리 주소를 읽습니다.
+func ParseMgmtAddr(s string) (MgmtAddr, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return MgmtAddr{}, nil
+	}
+
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return MgmtAddr{}, &valueError{"ip", s, "not an IP address or prefix"}
+		}
+		return MgmtAddr(p), nil
+	}
+
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return MgmtAddr{}, &valueError{"ip", s, "not an IP address or prefix"}
+	}
+	return MgmtAddr(netip.PrefixFrom(a, a.BitLen())), nil
+}
+
+// MustParseMgmtAddr은 ParseMgmtAddr과 같지만 실패하면 panic합니다.
+func MustParseMgmtAddr(s string) MgmtAddr {
+	m, err := ParseMgmtAddr(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Addr은 관리 주소의 IP를 돌려줍니다.
+func (m MgmtAddr) Addr() netip.Addr {
+	return netip.Prefix(m).Addr()
+}
+
+// Prefix는 관리 주소가 속한 프리픽스를 돌려줍니다. 호스트 비트는 지우지 않습니다.
+func (m MgmtAddr) Prefix() netip.Prefix {
+	return netip.Prefix(m)
+}
+
+func (m MgmtAddr) IsValid() bool {
+	return netip.Prefix(m).IsValid()
+}
+
+// IsZero는 yaml.v3와 encoding/json의 omitempty/omitzero 판단에 쓰입니다.
+func (m MgmtAddr) IsZero() bool {
+	return !m.IsValid()
+}
+
+// String은 프리픽스 길이가 주소 전체 길이이면 주소만, 아니면 프리픽스 표기를 돌려줍니다.
+func (m MgmtAddr) String() string {
+	if !m.IsValid() {
+		return ""
+	}
+	p := netip.Prefix(m)
+	if p.Bits() == p.Addr().BitLen() {
+		return p.Addr().String()
+	}
+	return p.String()
+}
+
+func (m MgmtAddr) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *MgmtAddr) UnmarshalText(b []byte) error {
+	v, err := ParseMgmtAddr(string(b))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+func (m MgmtAddr) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+func (m *MgmtAddr) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return &valueError{"ip", string(b), "must be a string"}
+	}
+	return m.UnmarshalText([]byte(s))
+}
+
+func (m MgmtAddr) MarshalYAML() (interface{}, error) {
+	return m.String(), nil
+}
+
+func (m *MgmtAddr) UnmarshalYAML(node *yaml.Node) error {
+	if err := m.UnmarshalText([]byte(node.Value)); err != nil {
+		return &Error{Pos: Position{Line: node.Line, Column: node.Column}, Msg: err.Error()}
+	}
+	return nil
+}
+
+// MarshalXML은 빈 주소일 때 요소를 쓰지 않습니다.
+func (m MgmtAddr) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !m.IsValid() {
+		return nil
+	}
+	return e.EncodeElement(m.String(), start)
+}
+
+func (m *MgmtAddr) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return m.UnmarshalText([]byte(s))
+}
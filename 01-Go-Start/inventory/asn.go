@@ -0,0 +1,125 @@
+package inventory
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ASTrans는 4바이트 ASN을 모르는 장비와 통신할 때 대신 쓰는 예약 ASN(RFC 6793)입니다.
+const ASTrans ASN = 23456
+
+// ASN은 4바이트 AS 번호(RFC 6793)입니다.
+// asplain("4200000000")과 asdot("64086.59904") 표기(RFC 5396)를 모두 읽을 수 있고
+// 파일에 쓸 때는 asplain 숫자로 씁니다.
+type ASN uint32
+
+// valueError는 ASN, 관리 주소처럼 직접 구현한 Unmarshal에서 나온 에러입니다.
+// 디코딩할 때 어느 필드의 값인지를 보고 줄/열 위치를 찾습니다.
+type valueError struct {
+	field  string
+	value  string
+	reason string
+}
+
+func (e *valueError) Error() string {
+	return fmt.Sprintf("invalid %s %q: %s", e.field, e.value, e.reason)
+}
+
+// ParseASN은 asplain 또는 asdot 표기의 ASN을 읽습니다. 앞의 "AS"는 무시합니다.
+func ParseASN(s string) (ASN, error) {
+	v := strings.TrimSpace(s)
+	if len(v) > 2 && strings.EqualFold(v[:2], "AS") {
+		v = v[2:]
+	}
+
+	if high, low, ok := strings.Cut(v, "."); ok {
+		h, err := strconv.ParseUint(high, 10, 16)
+		if err != nil {
+			return 0, &valueError{"asn", s, "asdot high part must be 0-65535"}
+		}
+		l, err := strconv.ParseUint(low, 10, 16)
+		if err != nil {
+			return 0, &valueError{"asn", s, "asdot low part must be 0-65535"}
+		}
+		return ASN(h<<16 | l), nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return 0, &valueError{"asn", s, fmt.Sprintf("must be 0-%d or asdot notation", uint32(math.MaxUint32))}
+	}
+	return ASN(n), nil
+}
+
+// String은 asplain 표기를 돌려줍니다.
+func (a ASN) String() string {
+	return strconv.FormatUint(uint64(a), 10)
+}
+
+// ASDot은 asdot 표기를 돌려줍니다. 2바이트 ASN은 그대로, 4바이트 ASN은 "상위.하위"로 씁니다.
+func (a ASN) ASDot() string {
+	if !a.Is4Byte() {
+		return a.String()
+	}
+	return fmt.Sprintf("%d.%d", a>>16, a&0xffff)
+}
+
+// Is4Byte는 2바이트로 표현할 수 없는 ASN인지 확인합니다.
+func (a ASN) Is4Byte() bool {
+	return a > math.MaxUint16
+}
+
+func (a ASN) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a *ASN) UnmarshalText(b []byte) error {
+	v, err := ParseASN(string(b))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+func (a ASN) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON은 숫자(64512)와 문자열("64086.59904") 모두 받습니다.
+func (a *ASN) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		s = string(b)
+	}
+	return a.UnmarshalText([]byte(s))
+}
+
+func (a ASN) MarshalYAML() (interface{}, error) {
+	return uint32(a), nil
+}
+
+func (a *ASN) UnmarshalYAML(node *yaml.Node) error {
+	if err := a.UnmarshalText([]byte(node.Value)); err != nil {
+		return &Error{Pos: Position{Line: node.Line, Column: node.Column}, Msg: err.Error()}
+	}
+	return nil
+}
+
+func (a ASN) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(a.String(), start)
+}
+
+func (a *ASN) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	return a.UnmarshalText([]byte(s))
+}
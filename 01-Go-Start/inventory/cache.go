@@ -0,0 +1,51 @@
+package inventory
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// CachedProvider는 Provider의 결과를 TTL 동안 재사용합니다.
+// Path를 지정하면 결과를 JSON 파일로도 저장해서, 명령을 다시 실행해도 TTL 안이면
+// NetBox나 스크립트를 다시 부르지 않습니다. 파일의 수정 시각을 가져온 시각으로 봅니다.
+type CachedProvider struct {
+	Provider Provider
+	TTL      time.Duration
+	Path     string
+
+	mu      sync.Mutex
+	inv     *Inventory
+	fetched time.Time
+}
+
+func (c *CachedProvider) Inventory(ctx context.Context) (*Inventory, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inv != nil && time.Since(c.fetched) < c.TTL {
+		return c.inv, nil
+	}
+
+	if c.Path != "" {
+		if fi, err := os.Stat(c.Path); err == nil && time.Since(fi.ModTime()) < c.TTL {
+			if inv, err := ReadFile(c.Path); err == nil {
+				c.inv, c.fetched = inv, fi.ModTime()
+				return inv, nil
+			}
+		}
+	}
+
+	inv, err := c.Provider.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.inv, c.fetched = inv, time.Now()
+
+	// 캐시 파일을 쓰지 못해도 인벤토리는 이미 가져왔으므로 에러로 취급하지 않습니다.
+	if c.Path != "" {
+		_ = Save(c.Path, inv)
+	}
+	return inv, nil
+}
@@ -0,0 +1,83 @@
+package inventory_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/inventory/netboxtest"
+)
+
+func TestCachedProviderTTL(t *testing.T) {
+	h := &netboxtest.Handler{Devices: []netboxtest.Device{{Name: "rtr1"}}}
+	srv, requests := countingServer(t, h)
+
+	ttl := 200 * time.Millisecond
+	c := &inventory.CachedProvider{Provider: &inventory.NetBoxProvider{URL: srv.URL}, TTL: ttl}
+	ctx := context.Background()
+
+	for range 3 {
+		if _, err := c.Inventory(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("requests within TTL = %d, want 1", got)
+	}
+
+	// TTL이 지나면 다시 가져오므로 그사이 바뀐 장비가 보입니다.
+	h.Devices = append(h.Devices, netboxtest.Device{Name: "rtr2"})
+	time.Sleep(ttl)
+	inv, err := c.Inventory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests after TTL = %d, want 2", got)
+	}
+	if got := hostnames(inv); !slices.Equal(got, []string{"rtr1", "rtr2"}) {
+		t.Errorf("hosts = %v, want [rtr1 rtr2]", got)
+	}
+}
+
+func TestCachedProviderFile(t *testing.T) {
+	h := &netboxtest.Handler{Devices: []netboxtest.Device{{Name: "rtr1"}}}
+	srv, requests := countingServer(t, h)
+	path := filepath.Join(t.TempDir(), "cache.json")
+	ctx := context.Background()
+
+	newCache := func() *inventory.CachedProvider {
+		return &inventory.CachedProvider{Provider: &inventory.NetBoxProvider{URL: srv.URL}, TTL: time.Hour, Path: path}
+	}
+
+	if _, err := newCache().Inventory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 명령을 다시 실행한 것처럼 새 CachedProvider를 만들어도 파일이 TTL 안이면 서버에 묻지 않습니다.
+	inv, err := newCache().Inventory(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests with a fresh cache file = %d, want 1", got)
+	}
+	if got := hostnames(inv); !slices.Equal(got, []string{"rtr1"}) {
+		t.Errorf("hosts = %v, want [rtr1]", got)
+	}
+
+	// 파일이 TTL보다 오래되었으면 다시 가져옵니다.
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newCache().Inventory(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests with a stale cache file = %d, want 2", got)
+	}
+}
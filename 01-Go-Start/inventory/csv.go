@@ -0,0 +1,205 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CSV 인벤토리는 장비와 그룹을 한 표에 담습니다. type 열이 host 또는 group을 구분하고
+// 목록(groups, children)은 ';'로, 사용자 변수(vars)는 URL 쿼리 형식(k=v&k2=v2)으로 적습니다.
+//
+//	type,name,ip,asn,platform,username,password,strictkey,sshconfig,port,mode,groups,children,vars
+//	host,rtr1.example.com,192.0.2.1,,,,,,,,,site-a-edge,,
+//	group,edge,,65000,cisco_iosxe,admin,,true,,,,,site-a-edge;site-b-edge,ntp=10.0.0.1
+//
+// 읽을 때는 헤더 이름으로 열을 찾으므로 열 순서를 바꾸거나 일부만 적어도 됩니다.
+// type 열이 없으면 모두 장비로, "hostname" 열은 "name"으로 취급합니다.
+var csvHeader = []string{
+	"type", "name", "ip", "asn", "platform", "username", "password",
+	"strictkey", "sshconfig", "port", "mode", "groups", "children", "vars",
+}
+
+func csvRecord(kind, name string, ip MgmtAddr, groups, children []string, v Vars) []string {
+	var asn, strict, port, vars string
+	if v.ASN != 0 {
+		asn = v.ASN.String()
+	}
+	if v.Port != 0 {
+		port = strconv.Itoa(v.Port)
+	}
+	if v.StrictKey != nil {
+		strict = strconv.FormatBool(*v.StrictKey)
+	}
+	if len(v.Custom) > 0 {
+		q := url.Values{}
+		for k, val := range v.Custom {
+			q.Set(k, val)
+		}
+		vars = q.Encode()
+	}
+
+	return []string{
+		kind, name, ip.String(), asn, v.Platform, v.Username, v.Password,
+		strict, v.SSHConfig, port, v.Mode, strings.Join(groups, ";"), strings.Join(children, ";"), vars,
+	}
+}
+
+func encodeCSV(w io.Writer, inv *Inventory) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, r := range inv.Routers {
+		if err := cw.Write(csvRecord("host", r.Hostname, r.IP, r.Groups, nil, r.Vars)); err != nil {
+			return err
+		}
+	}
+	for _, g := range inv.Groups {
+		if err := cw.Write(csvRecord("group", g.Name, MgmtAddr{}, nil, g.Children, g.Vars)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ";")
+}
+
+func decodeCSV(r io.Reader) (*Inventory, error) {
+	cr := csv.NewReader(r)
+
+	csvError := func(err error) error {
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			return &Error{Pos: Position{Line: pe.Line, Column: pe.Column}, Msg: pe.Err.Error()}
+		}
+		return err
+	}
+
+	var inv Inventory
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &inv, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h == "hostname" {
+			h = "name"
+		}
+		known := false
+		for _, c := range csvHeader {
+			known = known || c == h
+		}
+		if !known {
+			line, col := cr.FieldPos(i)
+			return nil, &Error{Pos: Position{Line: line, Column: col}, Msg: fmt.Sprintf("unknown column %q", header[i])}
+		}
+		cols[h] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, &Error{Pos: Position{Line: 1, Column: 1}, Msg: `missing "name" column`}
+	}
+
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return &inv, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		p := positions{start: Position{Line: line, Column: 1}, fields: make(map[string]Position)}
+
+		// get은 열의 값을 돌려주고 그 위치를 key 이름으로 기록합니다.
+		get := func(column, key string) (string, Position) {
+			i, ok := cols[column]
+			if !ok {
+				return "", p.start
+			}
+			l, c := cr.FieldPos(i)
+			pos := Position{Line: l, Column: c}
+			if rec[i] != "" {
+				p.fields[key] = pos
+			}
+			return strings.TrimSpace(rec[i]), pos
+		}
+
+		kind, _ := get("type", "type")
+		nameKey := "hostname"
+		if kind == "group" {
+			nameKey = "name"
+		}
+		name, _ := get("name", nameKey)
+		groups, _ := get("groups", "groups")
+		children, _ := get("children", "children")
+
+		var v Vars
+		v.Platform, _ = get("platform", "platform")
+		v.Username, _ = get("username", "username")
+		v.Password, _ = get("password", "password")
+		v.SSHConfig, _ = get("sshconfig", "sshconfig")
+		v.Mode, _ = get("mode", "mode")
+
+		var ip MgmtAddr
+		if s, pos := get("ip", "ip"); s != "" {
+			if ip, err = ParseMgmtAddr(s); err != nil {
+				return nil, &Error{Pos: pos, Msg: err.Error()}
+			}
+		}
+		if s, pos := get("asn", "asn"); s != "" {
+			if v.ASN, err = ParseASN(s); err != nil {
+				return nil, &Error{Pos: pos, Msg: err.Error()}
+			}
+		}
+		if s, pos := get("strictkey", "strictkey"); s != "" {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("strictkey %q is not a boolean", s)}
+			}
+			v.StrictKey = &b
+		}
+		if s, pos := get("port", "port"); s != "" {
+			if v.Port, err = strconv.Atoi(s); err != nil {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("port %q is not a number", s)}
+			}
+		}
+		if s, pos := get("vars", "vars"); s != "" {
+			q, err := url.ParseQuery(s)
+			if err != nil {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("vars: %s", err)}
+			}
+			v.Custom = make(VarMap, len(q))
+			for k := range q {
+				v.Custom[k] = q.Get(k)
+			}
+		}
+
+		switch kind {
+		case "", "host":
+			inv.Routers = append(inv.Routers, Router{Hostname: name, IP: ip, Groups: splitList(groups), Vars: v})
+			inv.hostPos = append(inv.hostPos, p)
+		case "group":
+			inv.Groups = append(inv.Groups, Group{Name: name, Children: splitList(children), Vars: v})
+			inv.groupPos = append(inv.groupPos, p)
+		default:
+			return nil, &Error{Pos: p.of("type"), Msg: fmt.Sprintf("unknown type %q (want host or group)", kind)}
+		}
+	}
+}
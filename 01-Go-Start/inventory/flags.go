@@ -0,0 +1,105 @@
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Flags는 장비를 고르는 명령들(single, concurrency, netrun 등)이 함께 쓰는 명령행 플래그입니다.
+//
+//	-i input.yml -i https://netbox.example   인벤토리 source (여러 번 지정하면 Merge)
+//	-limit edge:&site-a:!rtr3                   호스트 패턴
+//	-cache-ttl 10m                              가져온 인벤토리를 캐시할 시간
+type Flags struct {
+	Sources  []string
+	Limit    string
+	CacheTTL time.Duration
+
+	defaultSource string
+}
+
+type sourcesValue struct{ f *Flags }
+
+func (s sourcesValue) String() string {
+	if s.f == nil {
+		return ""
+	}
+	return strings.Join(s.f.Sources, ",")
+}
+
+func (s sourcesValue) Set(v string) error {
+	s.f.Sources = append(s.f.Sources, v)
+	return nil
+}
+
+// Register는 fs에 플래그를 등록합니다. -i를 주지 않으면 defaultSource를 사용합니다.
+func (f *Flags) Register(fs *flag.FlagSet, defaultSource string) {
+	f.defaultSource = defaultSource
+	fs.Var(sourcesValue{f}, "i", "inventory file, executable script or NetBox URL (repeatable, default "+defaultSource+")")
+	fs.StringVar(&f.Limit, "limit", "all", "host pattern (e.g. edge:&site-a:!rtr3)")
+	fs.DurationVar(&f.CacheTTL, "cache-ttl", 0, "reuse the fetched inventory for this long")
+}
+
+// Provider는 플래그로 지정한 source를 합친 Provider를 만듭니다.
+func (f *Flags) Provider() (Provider, error) {
+	sources := f.Sources
+	if len(sources) == 0 {
+		sources = []string{f.defaultSource}
+	}
+
+	var providers []Provider
+	for _, s := range sources {
+		p, err := NewProvider(s)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	p := providers[0]
+	if len(providers) > 1 {
+		p = Merge(providers...)
+	}
+	if f.CacheTTL > 0 {
+		p = &CachedProvider{Provider: p, TTL: f.CacheTTL, Path: cachePath(sources)}
+	}
+	return p, nil
+}
+
+// cachePath는 source 목록마다 다른 캐시 파일 경로를 돌려줍니다.
+func cachePath(sources []string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(sources, "\n")))
+	dir = filepath.Join(dir, "tucker-study")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "inventory-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// Inventory는 인벤토리를 가져와 복호화합니다.
+func (f *Flags) Inventory(ctx context.Context) (*Inventory, error) {
+	p, err := f.Provider()
+	if err != nil {
+		return nil, err
+	}
+	return Fetch(ctx, p)
+}
+
+// Hosts는 인벤토리를 가져와 -limit 패턴에 맞는 장비를 돌려줍니다.
+func (f *Flags) Hosts(ctx context.Context) ([]Router, error) {
+	inv, err := f.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return inv.Select(f.Limit)
+}
@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AllGroup은 모든 장비가 암묵적으로 속하는 그룹입니다.
+// 인벤토리에 "all" 그룹을 정의하면 그 변수가 가장 먼저 적용됩니다.
+const AllGroup = "all"
+
+// groupTree는 그룹 이름으로 정의와 부모 그룹, 깊이를 찾기 위한 색인입니다.
+type groupTree struct {
+	groups  map[string]*Group
+	parents map[string][]string
+	depth   map[string]int
+}
+
+func newGroupTree(inv *Inventory) (*groupTree, error) {
+	t := &groupTree{
+		groups:  make(map[string]*Group),
+		parents: make(map[string][]string),
+		depth:   make(map[string]int),
+	}
+
+	for i := range inv.Groups {
+		g := &inv.Groups[i]
+		if _, ok := t.groups[g.Name]; ok {
+			return nil, fmt.Errorf("duplicate group %q", g.Name)
+		}
+		t.groups[g.Name] = g
+	}
+
+	for _, g := range inv.Groups {
+		for _, c := range g.Children {
+			if _, ok := t.groups[c]; !ok {
+				return nil, fmt.Errorf("group %q: unknown child group %q", g.Name, c)
+			}
+			t.parents[c] = append(t.parents[c], g.Name)
+		}
+	}
+
+	for name := range t.groups {
+		if _, err := t.depthOf(name, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// depthOf는 최상위 그룹에서 name까지의 가장 긴 거리를 계산합니다.
+// 깊은(구체적인) 그룹일수록 변수를 나중에 적용해 우선순위가 높아집니다.
+func (t *groupTree) depthOf(name string, visiting map[string]bool) (int, error) {
+	if d, ok := t.depth[name]; ok {
+		return d, nil
+	}
+	if visiting[name] {
+		return 0, fmt.Errorf("group %q: cyclic children", name)
+	}
+	visiting[name] = true
+
+	d := 0
+	for _, p := range t.parents[name] {
+		pd, err := t.depthOf(p, visiting)
+		if err != nil {
+			return 0, err
+		}
+		if pd+1 > d {
+			d = pd + 1
+		}
+	}
+
+	delete(visiting, name)
+	t.depth[name] = d
+	return d, nil
+}
+
+// ancestors는 장비가 직접 속한 그룹과 그 상위 그룹 전체를
+// 적용 순서(깊이, 이름 순)대로 돌려줍니다.
+func (t *groupTree) ancestors(r Router) ([]string, error) {
+	seen := make(map[string]bool)
+
+	var walk func(name string)
+	walk = func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		for _, p := range t.parents[name] {
+			walk(p)
+		}
+	}
+
+	for _, g := range r.Groups {
+		if _, ok := t.groups[g]; !ok && g != AllGroup {
+			return nil, fmt.Errorf("host %s: unknown group %q", r.Hostname, g)
+		}
+		walk(g)
+	}
+	delete(seen, AllGroup)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if t.depth[names[i]] != t.depth[names[j]] {
+			return t.depth[names[i]] < t.depth[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names, nil
+}
+
+// Hosts는 그룹 변수를 상속 적용한 장비 목록을 인벤토리 순서대로 돌려줍니다.
+// "all" 그룹, 상위 그룹, 하위 그룹, 장비 자신의 순서로 값을 덮어쓰며
+// 결과의 Groups에는 상위 그룹까지 포함한 전체 소속 그룹이 담깁니다.
+func (inv *Inventory) Hosts() ([]Router, error) {
+	t, err := newGroupTree(inv)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := make([]Router, 0, len(inv.Routers))
+	for _, r := range inv.Routers {
+		groups, err := t.ancestors(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var v Vars
+		if all, ok := t.groups[AllGroup]; ok {
+			v.merge(all.Vars)
+		}
+		for _, g := range groups {
+			v.merge(t.groups[g].Vars)
+		}
+		v.merge(r.Vars)
+
+		r.Vars = v
+		r.Groups = groups
+		hosts = append(hosts, r)
+	}
+	return hosts, nil
+}
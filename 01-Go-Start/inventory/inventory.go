@@ -0,0 +1,44 @@
+package inventory
+
+import "encoding/xml"
+
+// Router는 인벤토리에 등록된 장비 한 대를 나타냅니다.
+// 예제마다 따로 선언하던 접속 정보(Platform/Username/Password/StrictKey)와
+// 주소 정보(IP/ASN)를 하나의 모델로 합쳤습니다. IP와 ASN은 읽을 때 형식을 검사하는 타입입니다.
+// 그룹에서 상속받을 수 있는 값은 Vars에 모여 있고, 장비에 직접 적은 값이 그룹 값보다 우선합니다.
+type Router struct {
+	Hostname string   `json:"hostname" xml:"hostname" yaml:"hostname"`
+	IP       MgmtAddr `json:"ip,omitzero" xml:"ip,omitempty" yaml:"ip,omitempty"`
+	Groups   []string `json:"groups,omitempty" xml:"group,omitempty" yaml:"groups,omitempty"`
+	Vars     `yaml:",inline"`
+}
+
+// Address는 접속할 주소를 돌려줍니다. 관리 주소가 있으면 그 IP를, 없으면 Hostname을 사용합니다.
+func (r Router) Address() string {
+	if r.IP.IsValid() {
+		return r.IP.Addr().String()
+	}
+	return r.Hostname
+}
+
+// Group은 장비 그룹입니다. Children에 적힌 그룹은 이 그룹의 하위 그룹이 되어
+// 이 그룹의 변수를 상속받습니다.
+type Group struct {
+	Name     string   `json:"name" xml:"name,attr" yaml:"name"`
+	Children []string `json:"children,omitempty" xml:"child,omitempty" yaml:"children,omitempty"`
+	Vars     `yaml:",inline"`
+}
+
+// Inventory는 장비 목록과 그룹 정의입니다.
+// JSON/YAML에서는 "router", "groups" 키, XML에서는 <routers><router>...</router><group name="...">...</group></routers>
+// 형태로 표현됩니다.
+type Inventory struct {
+	XMLName xml.Name `json:"-" xml:"routers" yaml:"-"`
+	Routers []Router `json:"router" xml:"router" yaml:"router"`
+	Groups  []Group  `json:"groups,omitempty" xml:"group,omitempty" yaml:"groups,omitempty"`
+
+	// 디코딩할 때 기록한 원본 파일과 위치로, Validate의 에러 메시지에 사용합니다.
+	file     string
+	hostPos  []positions
+	groupPos []positions
+}
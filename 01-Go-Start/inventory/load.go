@@ -0,0 +1,267 @@
+package inventory
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"tucker-study/01-Go-Start/vault"
+)
+
+// Format은 인벤토리 파일의 형식입니다.
+type Format string
+
+const (
+	JSON Format = "json"
+	XML  Format = "xml"
+	YAML Format = "yaml"
+	CSV  Format = "csv"
+)
+
+// FormatFromPath는 파일 확장자로 인벤토리 형식을 결정합니다.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return JSON, nil
+	case ".xml":
+		return XML, nil
+	case ".yml", ".yaml":
+		return YAML, nil
+	case ".csv":
+		return CSV, nil
+	}
+	return "", fmt.Errorf("unknown inventory format: %s", path)
+}
+
+// Load는 파일을 열어 확장자에 맞는 디코더로 인벤토리를 읽습니다.
+// vault로 암호화된 값이 있으면 환경 변수의 키(vault.FromEnv)로 복호화합니다.
+func Load(path string) (*Inventory, error) {
+	inv, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Encrypted() {
+		v, err := vault.FromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("%s has encrypted values: %w", path, err)
+		}
+		if err := inv.Decrypt(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return inv, nil
+}
+
+// ReadFile은 인벤토리를 복호화하지 않고 파일에 적힌 그대로 읽습니다.
+func ReadFile(path string) (*Inventory, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	inv, err := Decode(file, format)
+	if err != nil {
+		var perr *Error
+		if errors.As(err, &perr) {
+			perr.File = path
+			return nil, perr
+		}
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	inv.file = path
+	return inv, nil
+}
+
+// Decode는 io.Reader에서 주어진 형식의 인벤토리를 읽습니다.
+// 값이 잘못된 경우 가능한 한 줄/열 정보가 담긴 *Error를 돌려줍니다.
+func Decode(r io.Reader, format Format) (*Inventory, error) {
+	switch format {
+	case JSON:
+		return decodeJSON(r)
+	case XML:
+		return decodeXML(r)
+	case YAML:
+		return decodeYAML(r)
+	case CSV:
+		return decodeCSV(r)
+	}
+	return nil, fmt.Errorf("unknown inventory format: %q", format)
+}
+
+func decodeJSON(r io.Reader) (*Inventory, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(data, &inv); err != nil {
+		var ve *valueError
+		if errors.As(err, &ve) {
+			return nil, locateJSONError(data, ve)
+		}
+		return nil, jsonError(data, err)
+	}
+	inv.hostPos, inv.groupPos, _ = jsonPositions(data)
+	return &inv, nil
+}
+
+func decodeYAML(r io.Reader) (*Inventory, error) {
+	var root yaml.Node
+	err := yaml.NewDecoder(r).Decode(&root)
+	// 빈 파일은 빈 인벤토리로 취급합니다.
+	if err == io.EOF {
+		return &Inventory{}, nil
+	}
+	if err != nil {
+		return nil, yamlError(err)
+	}
+
+	var inv Inventory
+	if err := root.Decode(&inv); err != nil {
+		return nil, yamlError(err)
+	}
+	inv.hostPos, inv.groupPos = yamlPositions(&root)
+	return &inv, nil
+}
+
+// decodeXML은 위치를 기록하기 위해 루트 아래의 <router>, <group>을 하나씩 디코딩합니다.
+func decodeXML(r io.Reader) (*Inventory, error) {
+	d := xml.NewDecoder(r)
+
+	xmlError := func(err error) error {
+		if se, ok := err.(*xml.SyntaxError); ok {
+			return &Error{Pos: Position{Line: se.Line}, Msg: se.Msg}
+		}
+		line, col := d.InputPos()
+		return &Error{Pos: Position{Line: line, Column: col}, Msg: err.Error()}
+	}
+
+	var inv Inventory
+	for inv.XMLName.Local == "" {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if start.Name.Local != "routers" {
+				return nil, xmlError(fmt.Errorf("expected <routers> but got <%s>", start.Name.Local))
+			}
+			inv.XMLName = start.Name
+		}
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, xmlError(err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			line, col := d.InputPos()
+			pos := positions{start: Position{Line: line, Column: col}}
+
+			switch t.Name.Local {
+			case "router":
+				var r Router
+				if err := d.DecodeElement(&r, &t); err != nil {
+					return nil, xmlError(err)
+				}
+				inv.Routers = append(inv.Routers, r)
+				inv.hostPos = append(inv.hostPos, pos)
+			case "group":
+				var g Group
+				if err := d.DecodeElement(&g, &t); err != nil {
+					return nil, xmlError(err)
+				}
+				inv.Groups = append(inv.Groups, g)
+				inv.groupPos = append(inv.groupPos, pos)
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, xmlError(err)
+				}
+			}
+		case xml.EndElement:
+			// </routers>
+			return &inv, nil
+		}
+	}
+}
+
+// Encode는 인벤토리를 주어진 형식으로 씁니다.
+func Encode(w io.Writer, inv *Inventory, format Format) error {
+	switch format {
+	case JSON:
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(inv)
+	case XML:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		e := xml.NewEncoder(w)
+		e.Indent("", "    ")
+		if err := e.Encode(inv); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	case YAML:
+		e := yaml.NewEncoder(w)
+		e.SetIndent(2)
+		if err := e.Encode(inv); err != nil {
+			return err
+		}
+		return e.Close()
+	case CSV:
+		return encodeCSV(w, inv)
+	}
+	return fmt.Errorf("unknown inventory format: %q", format)
+}
+
+// Save는 확장자에 맞는 형식으로 인벤토리를 파일에 씁니다.
+// 쓰는 도중 실패해도 원본이 깨지지 않도록 임시 파일에 쓴 뒤 이름을 바꿉니다.
+func Save(path string, inv *Inventory) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Encode(tmp, inv, format); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// 원본 파일의 권한을 유지합니다. 새 파일은 비밀번호가 들어 있을 수 있으니 0600으로 만듭니다.
+	mode := os.FileMode(0o600)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,175 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NetBoxTokenEnv는 NewProvider가 NetBox API 토큰을 찾는 환경 변수입니다.
+const NetBoxTokenEnv = "NETBOX_TOKEN"
+
+// NetBoxProvider는 NetBox(또는 같은 API를 흉내 내는 서버)의 /api/dcim/devices/에서 장비를 가져옵니다.
+//
+//   - name                 → Hostname
+//   - primary_ip.address   → IP (예: "192.0.2.1/24")
+//   - platform.slug        → Platform ('-'는 '_'로 바꿔 "cisco-iosxe"도 "cisco_iosxe"가 됩니다)
+//   - site, role, tags     → Groups (slug 그대로, 예: "site-a", "edge")
+//   - custom_fields.asn    → ASN
+//
+// 계정 정보는 NetBox에 없으므로 Merge로 파일 인벤토리의 그룹 변수와 합쳐서 사용합니다.
+type NetBoxProvider struct {
+	URL   string
+	Token string
+
+	// Query는 장비 목록 조회에 붙일 필터입니다. (예: status=active, site=site-a)
+	Query url.Values
+
+	// Client가 nil이면 http.DefaultClient를 사용합니다.
+	Client *http.Client
+}
+
+type netboxSlug struct {
+	Slug string `json:"slug"`
+}
+
+type netboxDevice struct {
+	Name      string `json:"name"`
+	PrimaryIP *struct {
+		Address string `json:"address"`
+	} `json:"primary_ip"`
+	Platform     *netboxSlug    `json:"platform"`
+	Site         *netboxSlug    `json:"site"`
+	Role         *netboxSlug    `json:"role"`
+	DeviceRole   *netboxSlug    `json:"device_role"` // NetBox 3.6 이전
+	Tags         []netboxSlug   `json:"tags"`
+	CustomFields map[string]any `json:"custom_fields"`
+}
+
+type netboxPage struct {
+	Next    string         `json:"next"`
+	Results []netboxDevice `json:"results"`
+}
+
+func (n *NetBoxProvider) Inventory(ctx context.Context) (*Inventory, error) {
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	q := url.Values{}
+	for k, v := range n.Query {
+		q[k] = v
+	}
+	if q.Get("limit") == "" {
+		q.Set("limit", "1000")
+	}
+	next := strings.TrimSuffix(n.URL, "/") + "/api/dcim/devices/?" + q.Encode()
+
+	inv := &Inventory{}
+	groups := make(map[string]bool)
+
+	// 결과가 많으면 NetBox는 "next"에 다음 페이지 URL을 담아 줍니다.
+	for next != "" {
+		page, err := n.get(ctx, client, next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, d := range page.Results {
+			r, err := d.router()
+			if err != nil {
+				return nil, fmt.Errorf("netbox device %s: %w", d.Name, err)
+			}
+			if r.Hostname == "" {
+				continue
+			}
+			for _, g := range r.Groups {
+				if !groups[g] {
+					groups[g] = true
+					inv.Groups = append(inv.Groups, Group{Name: g})
+				}
+			}
+			inv.Routers = append(inv.Routers, r)
+		}
+		next = page.Next
+	}
+	return inv, nil
+}
+
+func (n *NetBoxProvider) get(ctx context.Context, client *http.Client, u string) (*netboxPage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Token "+n.Token)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("netbox %s: %s: %s", u, res.Status, strings.TrimSpace(string(body)))
+	}
+
+	var page netboxPage
+	if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("netbox %s: %w", u, err)
+	}
+	return &page, nil
+}
+
+func (d netboxDevice) router() (Router, error) {
+	r := Router{Hostname: d.Name}
+
+	if d.PrimaryIP != nil && d.PrimaryIP.Address != "" {
+		ip, err := ParseMgmtAddr(d.PrimaryIP.Address)
+		if err != nil {
+			return r, err
+		}
+		r.IP = ip
+	}
+	if d.Platform != nil {
+		r.Platform = strings.ReplaceAll(d.Platform.Slug, "-", "_")
+	}
+	switch asn := d.CustomFields["asn"].(type) {
+	case float64:
+		// JSON 숫자는 float64로 디코딩되므로 정수 표기로 바꿔서 읽습니다.
+		v, err := ParseASN(strconv.FormatFloat(asn, 'f', -1, 64))
+		if err != nil {
+			return r, err
+		}
+		r.ASN = v
+	case string:
+		v, err := ParseASN(asn)
+		if err != nil {
+			return r, err
+		}
+		r.ASN = v
+	}
+
+	role := d.Role
+	if role == nil {
+		role = d.DeviceRole
+	}
+	for _, s := range []*netboxSlug{d.Site, role} {
+		if s != nil && s.Slug != "" {
+			r.Groups = appendUnique(r.Groups, s.Slug)
+		}
+	}
+	for _, t := range d.Tags {
+		r.Groups = appendUnique(r.Groups, t.Slug)
+	}
+	return r, nil
+}
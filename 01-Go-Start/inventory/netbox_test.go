@@ -0,0 +1,127 @@
+package inventory_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/inventory/netboxtest"
+)
+
+// countingServer는 Handler를 띄우고 받은 요청 수를 셉니다.
+func countingServer(t *testing.T, h *netboxtest.Handler) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n.Add(1)
+		h.ServeHTTP(w, req)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &n
+}
+
+func hostnames(inv *inventory.Inventory) []string {
+	var names []string
+	for _, r := range inv.Routers {
+		names = append(names, r.Hostname)
+	}
+	return names
+}
+
+func TestNetBoxProviderPagination(t *testing.T) {
+	var devices []netboxtest.Device
+	var want []string
+	for i := range 7 {
+		name := fmt.Sprintf("rtr%d", i+1)
+		devices = append(devices, netboxtest.Device{Name: name, Site: "site-a"})
+		want = append(want, name)
+	}
+	// 서버가 한 페이지에 3대만 돌려주므로 next를 따라 세 번 가져와야 합니다.
+	srv, requests := countingServer(t, &netboxtest.Handler{Devices: devices, MaxLimit: 3})
+
+	p := &inventory.NetBoxProvider{URL: srv.URL}
+	inv, err := p.Inventory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hostnames(inv); !slices.Equal(got, want) {
+		t.Errorf("hosts = %v, want %v", got, want)
+	}
+	if got := requests.Load(); got != 3 {
+		t.Errorf("requests = %d, want 3", got)
+	}
+	if len(inv.Groups) != 1 || inv.Groups[0].Name != "site-a" {
+		t.Errorf("groups = %v, want [site-a]", inv.Groups)
+	}
+}
+
+func TestNetBoxProviderDevice(t *testing.T) {
+	srv := netboxtest.NewServer("", []netboxtest.Device{{
+		Name:      "edge1",
+		PrimaryIP: "192.0.2.1/24",
+		Platform:  "cisco-iosxe",
+		Site:      "site-a",
+		Role:      "edge",
+		Tags:      []string{"bgp", "edge"},
+		ASN:       65001,
+	}})
+	defer srv.Close()
+
+	inv, err := (&inventory.NetBoxProvider{URL: srv.URL}).Inventory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inv.Routers) != 1 {
+		t.Fatalf("got %d routers, want 1", len(inv.Routers))
+	}
+	r := inv.Routers[0]
+	if r.Platform != "cisco_iosxe" {
+		t.Errorf("platform = %q, want cisco_iosxe", r.Platform)
+	}
+	if got := r.IP.String(); got != "192.0.2.1/24" {
+		t.Errorf("ip = %s, want 192.0.2.1/24", got)
+	}
+	if r.ASN != 65001 {
+		t.Errorf("asn = %d, want 65001", r.ASN)
+	}
+	if want := []string{"site-a", "edge", "bgp"}; !slices.Equal(r.Groups, want) {
+		t.Errorf("groups = %v, want %v", r.Groups, want)
+	}
+}
+
+func TestNetBoxProviderToken(t *testing.T) {
+	srv := netboxtest.NewServer("secret", []netboxtest.Device{{Name: "rtr1"}})
+	defer srv.Close()
+
+	tests := []struct {
+		token   string
+		wantErr bool
+	}{
+		{"secret", false},
+		{"wrong", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		p := &inventory.NetBoxProvider{URL: srv.URL, Token: tt.token}
+		inv, err := p.Inventory(context.Background())
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "403") {
+				t.Errorf("token %q: err = %v, want 403", tt.token, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("token %q: %v", tt.token, err)
+			continue
+		}
+		if got := hostnames(inv); !slices.Equal(got, []string{"rtr1"}) {
+			t.Errorf("token %q: hosts = %v", tt.token, got)
+		}
+	}
+}
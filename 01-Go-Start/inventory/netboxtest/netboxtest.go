@@ -0,0 +1,114 @@
+package netboxtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+)
+
+// netboxtest는 NetBoxProvider를 실제 NetBox 없이 확인하기 위한 흉내 서버입니다.
+// /api/dcim/devices/ 하나만 구현하며, limit/offset 페이지 나누기와 토큰 검사를 지원합니다.
+//
+//	srv := netboxtest.NewServer("secret", []netboxtest.Device{{Name: "rtr1", Site: "site-a"}})
+//	defer srv.Close()
+//	p := &inventory.NetBoxProvider{URL: srv.URL, Token: "secret"}
+
+// Device는 흉내 서버가 돌려줄 장비 한 대입니다.
+type Device struct {
+	Name      string
+	PrimaryIP string
+	Platform  string
+	Site      string
+	Role      string
+	Tags      []string
+	ASN       uint32
+}
+
+func slug(s string) any {
+	if s == "" {
+		return nil
+	}
+	return map[string]string{"slug": s}
+}
+
+func (d Device) json() map[string]any {
+	tags := []any{}
+	for _, t := range d.Tags {
+		tags = append(tags, slug(t))
+	}
+
+	var ip any
+	if d.PrimaryIP != "" {
+		ip = map[string]string{"address": d.PrimaryIP}
+	}
+
+	custom := map[string]any{}
+	if d.ASN != 0 {
+		custom["asn"] = d.ASN
+	}
+
+	return map[string]any{
+		"name":          d.Name,
+		"primary_ip":    ip,
+		"platform":      slug(d.Platform),
+		"site":          slug(d.Site),
+		"role":          slug(d.Role),
+		"tags":          tags,
+		"custom_fields": custom,
+	}
+}
+
+// Handler는 NetBox 장비 API를 흉내 내는 http.Handler입니다.
+type Handler struct {
+	Token   string
+	Devices []Device
+
+	// MaxLimit은 한 페이지에 돌려줄 최대 개수입니다. 0이면 요청한 limit을 그대로 씁니다.
+	MaxLimit int
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/api/dcim/devices/" {
+		http.NotFound(w, req)
+		return
+	}
+	if h.Token != "" && req.Header.Get("Authorization") != "Token "+h.Token {
+		http.Error(w, `{"detail": "Invalid token"}`, http.StatusForbidden)
+		return
+	}
+
+	q := req.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	if limit <= 0 || (h.MaxLimit > 0 && limit > h.MaxLimit) {
+		limit = h.MaxLimit
+	}
+	if limit <= 0 {
+		limit = len(h.Devices)
+	}
+
+	results := []any{}
+	for i := offset; i < len(h.Devices) && i < offset+limit; i++ {
+		results = append(results, h.Devices[i].json())
+	}
+
+	var next any
+	if offset+limit < len(h.Devices) {
+		q.Set("limit", strconv.Itoa(limit))
+		q.Set("offset", strconv.Itoa(offset+limit))
+		next = "http://" + req.Host + req.URL.Path + "?" + q.Encode()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"count":   len(h.Devices),
+		"next":    next,
+		"results": results,
+	})
+}
+
+// NewServer는 Handler를 띄운 httptest.Server를 돌려줍니다.
+func NewServer(token string, devices []Device) *httptest.Server {
+	return httptest.NewServer(&Handler{Token: token, Devices: devices})
+}
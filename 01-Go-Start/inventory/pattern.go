@@ -0,0 +1,135 @@
+package inventory
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Select는 호스트 패턴에 맞는 장비를 그룹 변수가 적용된 상태로 돌려줍니다.
+//
+// 패턴은 ':' 또는 ','로 구분한 항목의 나열입니다.
+//
+//	edge            edge 그룹(하위 그룹 포함)의 장비 또는 이름이 edge인 장비
+//	edge:core       합집합
+//	edge:&site-a    edge 이면서 site-a 그룹인 장비 (교집합)
+//	edge:!rtr3      edge 중에서 rtr3 제외 (차집합)
+//	rtr*            glob 패턴 (path.Match 문법)
+//
+// 장비 이름은 Hostname 전체 또는 첫 번째 레이블(rtr3.example.com의 rtr3)과 비교합니다.
+// 빈 패턴, "all", "*"는 모든 장비를 뜻하고, '!'나 '&' 항목만 있으면 모든 장비에서 시작합니다.
+func (inv *Inventory) Select(pattern string) ([]Router, error) {
+	hosts, err := inv.Hosts()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(inv.Groups)+1)
+	groups = append(groups, AllGroup)
+	for _, g := range inv.Groups {
+		groups = append(groups, g.Name)
+	}
+
+	var union, intersect, exclude []string
+	for _, term := range strings.FieldsFunc(pattern, func(r rune) bool { return r == ':' || r == ',' }) {
+		term = strings.TrimSpace(term)
+		switch {
+		case term == "":
+		case strings.HasPrefix(term, "&"):
+			intersect = append(intersect, term[1:])
+		case strings.HasPrefix(term, "!"):
+			exclude = append(exclude, term[1:])
+		default:
+			union = append(union, term)
+		}
+	}
+	if len(union) == 0 {
+		union = []string{AllGroup}
+	}
+
+	matched := make([]bool, len(hosts))
+	for _, term := range union {
+		m, err := matchTerm(term, hosts, groups)
+		if err != nil {
+			return nil, err
+		}
+		for i := range hosts {
+			matched[i] = matched[i] || m[i]
+		}
+	}
+	for _, term := range intersect {
+		m, err := matchTerm(term, hosts, groups)
+		if err != nil {
+			return nil, err
+		}
+		for i := range hosts {
+			matched[i] = matched[i] && m[i]
+		}
+	}
+	for _, term := range exclude {
+		m, err := matchTerm(term, hosts, groups)
+		if err != nil {
+			return nil, err
+		}
+		for i := range hosts {
+			matched[i] = matched[i] && !m[i]
+		}
+	}
+
+	var selected []Router
+	for i, r := range hosts {
+		if matched[i] {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// matchTerm은 항목 하나가 각 장비에 맞는지 돌려줍니다.
+// 그룹 이름이나 장비 이름 어느 쪽에도 맞지 않는 항목은 오타일 가능성이 높아 에러로 처리합니다.
+func matchTerm(term string, hosts []Router, groups []string) ([]bool, error) {
+	if term == "*" {
+		term = AllGroup
+	}
+	if _, err := path.Match(term, ""); err != nil {
+		return nil, fmt.Errorf("bad host pattern %q: %w", term, err)
+	}
+
+	var matchedGroups []string
+	for _, g := range groups {
+		if ok, _ := path.Match(term, g); ok {
+			matchedGroups = append(matchedGroups, g)
+		}
+	}
+
+	m := make([]bool, len(hosts))
+	found := len(matchedGroups) > 0
+	for i, r := range hosts {
+		short, _, _ := strings.Cut(r.Hostname, ".")
+		okFull, _ := path.Match(term, r.Hostname)
+		okShort, _ := path.Match(term, short)
+		if okFull || okShort || inGroups(r, matchedGroups) {
+			m[i] = true
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("host pattern %q matches no group or host", term)
+	}
+	return m, nil
+}
+
+func inGroups(r Router, groups []string) bool {
+	for _, g := range groups {
+		if g == AllGroup {
+			return true
+		}
+		for _, rg := range r.Groups {
+			if rg == g {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package inventory_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// patternInventory는 중첩 그룹(routers > edge, core)과 사이트 그룹이 있는 인벤토리입니다.
+func patternInventory() *inventory.Inventory {
+	return &inventory.Inventory{
+		Groups: []inventory.Group{
+			{Name: "all", Vars: inventory.Vars{Username: "admin", Custom: inventory.VarMap{"ntp": "10.0.0.1", "role": "any"}}},
+			{Name: "routers", Children: []string{"edge", "core"}, Vars: inventory.Vars{Platform: "cisco_iosxe", ASN: 65000, Custom: inventory.VarMap{"role": "router"}}},
+			{Name: "edge", Vars: inventory.Vars{Password: "edge-pw", Custom: inventory.VarMap{"role": "edge"}}},
+			{Name: "core", Vars: inventory.Vars{Custom: inventory.VarMap{"role": "core"}}},
+			{Name: "site-a", Vars: inventory.Vars{Port: 2222, Custom: inventory.VarMap{"site": "a"}}},
+		},
+		Routers: []inventory.Router{
+			{Hostname: "rtr1.example.com", Groups: []string{"edge", "site-a"}},
+			{Hostname: "rtr2", Groups: []string{"edge"}},
+			{Hostname: "rtr3", Groups: []string{"edge", "site-a"}, Vars: inventory.Vars{Platform: "arista_eos", Custom: inventory.VarMap{"site": "a3"}}},
+			{Hostname: "core1", Groups: []string{"core", "site-a"}},
+			{Hostname: "sw1"},
+		},
+	}
+}
+
+func TestSelect(t *testing.T) {
+	all := []string{"rtr1.example.com", "rtr2", "rtr3", "core1", "sw1"}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", all},
+		{"all", all},
+		{"*", all},
+		{"edge", []string{"rtr1.example.com", "rtr2", "rtr3"}},
+		// 상위 그룹은 하위 그룹의 장비를 포함합니다.
+		{"routers", []string{"rtr1.example.com", "rtr2", "rtr3", "core1"}},
+		{"edge:core", []string{"rtr1.example.com", "rtr2", "rtr3", "core1"}},
+		{"edge, core1", []string{"rtr1.example.com", "rtr2", "rtr3", "core1"}},
+		{"edge:&site-a", []string{"rtr1.example.com", "rtr3"}},
+		{"edge:&site-a:!rtr3", []string{"rtr1.example.com"}},
+		{"edge:&core", nil},
+		{"routers:!edge", []string{"core1"}},
+		{"!routers", []string{"sw1"}},
+		{"&site-a", []string{"rtr1.example.com", "rtr3", "core1"}},
+		// 장비 이름은 전체 이름과 첫 번째 레이블 모두와 비교합니다.
+		{"rtr1", []string{"rtr1.example.com"}},
+		{"rtr1.example.com", []string{"rtr1.example.com"}},
+		{"*1", []string{"rtr1.example.com", "core1", "sw1"}},
+		{"rtr*", []string{"rtr1.example.com", "rtr2", "rtr3"}},
+		{"rtr[2-3]", []string{"rtr2", "rtr3"}},
+		{"rtr[^2]", []string{"rtr1.example.com", "rtr3"}},
+		{"site-*:!rtr[1-2]", []string{"rtr3", "core1"}},
+	}
+
+	inv := patternInventory()
+	for _, tt := range tests {
+		got, err := inv.Select(tt.pattern)
+		if err != nil {
+			t.Errorf("Select(%q): %v", tt.pattern, err)
+			continue
+		}
+		if names := hostnames(&inventory.Inventory{Routers: got}); !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("Select(%q) = %q, want %q", tt.pattern, names, tt.want)
+		}
+	}
+}
+
+func TestSelectError(t *testing.T) {
+	inv := patternInventory()
+	for _, pattern := range []string{"rtr9", "edge:!typo", "edge:&nosuch", "rtr["} {
+		if got, err := inv.Select(pattern); err == nil {
+			t.Errorf("Select(%q) = %q, want an error", pattern, hostnames(&inventory.Inventory{Routers: got}))
+		}
+	}
+
+	// 정의되지 않은 그룹에 속한 장비와 순환하는 하위 그룹은 거부합니다.
+	inv.Routers[1].Groups = []string{"egde"}
+	if _, err := inv.Select("all"); err == nil || !strings.Contains(err.Error(), `unknown group "egde"`) {
+		t.Errorf("unknown group: err = %v", err)
+	}
+	inv = patternInventory()
+	inv.Groups[2].Children = []string{"routers"}
+	if _, err := inv.Select("all"); err == nil || !strings.Contains(err.Error(), "cyclic") {
+		t.Errorf("cyclic groups: err = %v", err)
+	}
+}
+
+func TestSelectVars(t *testing.T) {
+	hosts, err := patternInventory().Select("rtr3:core1:sw1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []inventory.Router{
+		{
+			// all, 상위 그룹, 하위 그룹, 장비 순으로 덮어씁니다. 깊이가 같으면 이름 순입니다.
+			Hostname: "rtr3",
+			Groups:   []string{"routers", "site-a", "edge"},
+			Vars: inventory.Vars{
+				Platform: "arista_eos", Username: "admin", Password: "edge-pw", ASN: 65000, Port: 2222,
+				Custom: inventory.VarMap{"ntp": "10.0.0.1", "role": "edge", "site": "a3"},
+			},
+		},
+		{
+			Hostname: "core1",
+			Groups:   []string{"routers", "site-a", "core"},
+			Vars: inventory.Vars{
+				Platform: "cisco_iosxe", Username: "admin", ASN: 65000, Port: 2222,
+				Custom: inventory.VarMap{"ntp": "10.0.0.1", "role": "core", "site": "a"},
+			},
+		},
+		{
+			// 그룹이 없는 장비도 all 그룹의 변수는 받습니다.
+			Hostname: "sw1",
+			Groups:   []string{},
+			Vars:     inventory.Vars{Username: "admin", Custom: inventory.VarMap{"ntp": "10.0.0.1", "role": "any"}},
+		},
+	}
+	if !reflect.DeepEqual(hosts, want) {
+		t.Errorf("Select vars:\n got %+v\nwant %+v", hosts, want)
+	}
+
+	// 상속한 변수는 인벤토리의 그룹 정의를 바꾸지 않습니다.
+	inv := patternInventory()
+	if _, err := inv.Select("all"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(inv, patternInventory()) {
+		t.Error("Select modified the inventory")
+	}
+}
@@ -0,0 +1,252 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position은 원본 파일에서의 위치(1부터 시작)입니다. 0은 알 수 없음을 뜻합니다.
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	switch {
+	case p.Line == 0:
+		return ""
+	case p.Column == 0:
+		return strconv.Itoa(p.Line)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Error는 위치 정보가 붙은 인벤토리 에러입니다.
+// 디코딩과 Validate가 돌려주며 "input.json:7:20: 메시지" 형태로 출력됩니다.
+type Error struct {
+	File string
+	Pos  Position
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	prefix := e.File
+	if pos := e.Pos.String(); pos != "" {
+		if prefix != "" {
+			prefix += ":"
+		}
+		prefix += pos
+	}
+	if prefix == "" {
+		return e.Msg
+	}
+	return prefix + ": " + e.Msg
+}
+
+// positions는 디코딩한 장비/그룹 하나의 시작 위치와 필드별 위치입니다.
+type positions struct {
+	start  Position
+	fields map[string]Position
+}
+
+// of는 필드의 위치를 돌려주고, 모르면 항목의 시작 위치를 돌려줍니다.
+func (p positions) of(field string) Position {
+	if pos, ok := p.fields[field]; ok {
+		return pos
+	}
+	return p.start
+}
+
+// offsetPosition은 바이트 오프셋을 줄/열로 바꿉니다.
+func offsetPosition(data []byte, offset int64) Position {
+	if offset < 0 || offset > int64(len(data)) {
+		return Position{}
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+	return Position{Line: line, Column: col}
+}
+
+// jsonError는 encoding/json 에러의 오프셋을 줄/열로 바꿉니다.
+func jsonError(data []byte, err error) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return &Error{Pos: offsetPosition(data, e.Offset), Msg: e.Error()}
+	case *json.UnmarshalTypeError:
+		return &Error{
+			Pos: offsetPosition(data, e.Offset),
+			Msg: fmt.Sprintf("%s: cannot use %s as %s", e.Field, e.Value, e.Type),
+		}
+	}
+	return err
+}
+
+// locateJSONError는 위치 정보가 없는 valueError가 어느 장비/그룹의 값인지 찾아
+// 해당 필드의 줄/열을 붙입니다.
+func locateJSONError(data []byte, ve *valueError) error {
+	hosts, groups, _ := jsonPositions(data)
+
+	var raw struct {
+		Routers []json.RawMessage `json:"router"`
+		Groups  []json.RawMessage `json:"groups"`
+	}
+	if err := json.Unmarshal(data, &raw); err == nil {
+		for i, m := range raw.Routers {
+			if err := json.Unmarshal(m, &Router{}); err != nil {
+				return &Error{Pos: pos(hosts, i, ve.field), Msg: ve.Error()}
+			}
+		}
+		for i, m := range raw.Groups {
+			if err := json.Unmarshal(m, &Group{}); err != nil {
+				return &Error{Pos: pos(groups, i, ve.field), Msg: ve.Error()}
+			}
+		}
+	}
+	return &Error{Msg: ve.Error()}
+}
+
+// jsonPositions는 토큰을 하나씩 읽으며 "router"와 "groups" 배열 안 객체의 위치를 기록합니다.
+// 구조가 예상과 다르면 위치 없이 돌아갑니다. 그런 문제는 json.Unmarshal이 더 좋은 메시지로 보고합니다.
+func jsonPositions(data []byte) (hosts, groups []positions, err error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+
+	// valueStart는 방금 읽은 토큰 다음의 공백과 구분자(:,)를 건너뛴 값의 시작 위치입니다.
+	valueStart := func() Position {
+		off := d.InputOffset()
+		for off < int64(len(data)) && bytes.IndexByte([]byte(" \t\r\n:,"), data[off]) >= 0 {
+			off++
+		}
+		return offsetPosition(data, off)
+	}
+
+	// skip은 다음 값 하나를 (중첩된 객체/배열 포함) 건너뜁니다.
+	skip := func() error {
+		depth := 0
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return err
+			}
+			switch tok {
+			case json.Delim('{'), json.Delim('['):
+				depth++
+			case json.Delim('}'), json.Delim(']'):
+				depth--
+			}
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+
+	objects := func() ([]positions, error) {
+		if tok, err := d.Token(); err != nil || tok != json.Delim('[') {
+			return nil, err
+		}
+		var list []positions
+		for d.More() {
+			p := positions{start: valueStart(), fields: make(map[string]Position)}
+			if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+				return nil, err
+			}
+			for d.More() {
+				key, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				p.fields[fmt.Sprint(key)] = valueStart()
+				if err := skip(); err != nil {
+					return nil, err
+				}
+			}
+			if _, err := d.Token(); err != nil {
+				return nil, err
+			}
+			list = append(list, p)
+		}
+		_, err := d.Token()
+		return list, err
+	}
+
+	if tok, err := d.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, err
+	}
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch key {
+		case "router":
+			hosts, err = objects()
+		case "groups":
+			groups, err = objects()
+		default:
+			err = skip()
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return hosts, groups, nil
+}
+
+// yamlPositions는 YAML 노드 트리에서 "router"와 "groups" 항목의 위치를 기록합니다.
+func yamlPositions(root *yaml.Node) (hosts, groups []positions) {
+	doc := root
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	if doc.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+
+	objects := func(seq *yaml.Node) []positions {
+		var list []positions
+		for _, item := range seq.Content {
+			p := positions{
+				start:  Position{Line: item.Line, Column: item.Column},
+				fields: make(map[string]Position),
+			}
+			for i := 0; i+1 < len(item.Content); i += 2 {
+				v := item.Content[i+1]
+				p.fields[item.Content[i].Value] = Position{Line: v.Line, Column: v.Column}
+			}
+			list = append(list, p)
+		}
+		return list
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		switch doc.Content[i].Value {
+		case "router":
+			hosts = objects(doc.Content[i+1])
+		case "groups":
+			groups = objects(doc.Content[i+1])
+		}
+	}
+	return hosts, groups
+}
+
+var yamlLineRe = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlError는 yaml.v3 에러 메시지의 "line N:"을 위치 정보로 바꿉니다.
+func yamlError(err error) error {
+	msg := err.Error()
+	if te, ok := err.(*yaml.TypeError); ok && len(te.Errors) > 0 {
+		msg = te.Errors[0]
+	}
+
+	m := yamlLineRe.FindStringSubmatch(msg)
+	if m == nil {
+		return err
+	}
+	line, _ := strconv.Atoi(m[1])
+	return &Error{Pos: Position{Line: line}, Msg: m[2]}
+}
@@ -0,0 +1,154 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"tucker-study/01-Go-Start/vault"
+)
+
+// Provider는 인벤토리를 가져오는 곳(파일, 스크립트, NetBox 등)입니다.
+// 돌려준 인벤토리에는 vault로 암호화된 값이 그대로 남아 있을 수 있으며, 복호화는 Fetch가 합니다.
+// 캐시 파일에 평문 비밀번호가 저장되지 않도록 하기 위해서입니다.
+type Provider interface {
+	Inventory(ctx context.Context) (*Inventory, error)
+}
+
+// NewProvider는 source 문자열에 맞는 Provider를 만듭니다.
+//
+//	input.yml, hosts.csv, ...   정적 파일 (확장자로 형식 결정)
+//	http(s)://netbox.example    NetBox 호환 REST API (토큰은 NETBOX_TOKEN 환경 변수)
+//	./inventory.sh              JSON 인벤토리를 출력하는 실행 파일
+func NewProvider(source string) (Provider, error) {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return &NetBoxProvider{URL: source, Token: os.Getenv(NetBoxTokenEnv)}, nil
+	}
+	if _, err := FormatFromPath(source); err == nil {
+		return &FileProvider{Path: source}, nil
+	}
+
+	fi, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() || fi.Mode().Perm()&0o111 == 0 {
+		return nil, fmt.Errorf("%s: not an inventory file or executable script", source)
+	}
+	return &ScriptProvider{Path: source}, nil
+}
+
+// Fetch는 Provider에서 인벤토리를 가져오고 암호화된 값을 환경 변수의 키로 복호화합니다.
+func Fetch(ctx context.Context, p Provider) (*Inventory, error) {
+	inv, err := p.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Encrypted() {
+		v, err := vault.FromEnv()
+		if err != nil {
+			return nil, fmt.Errorf("inventory has encrypted values: %w", err)
+		}
+		if err := inv.Decrypt(v); err != nil {
+			return nil, err
+		}
+	}
+	return inv, nil
+}
+
+// FileProvider는 정적 인벤토리 파일입니다.
+type FileProvider struct {
+	Path string
+}
+
+func (f *FileProvider) Inventory(ctx context.Context) (*Inventory, error) {
+	return ReadFile(f.Path)
+}
+
+// ScriptProvider는 실행하면 표준 출력으로 JSON 인벤토리를 내보내는 프로그램입니다.
+type ScriptProvider struct {
+	Path string
+	Args []string
+}
+
+func (s *ScriptProvider) Inventory(ctx context.Context) (*Inventory, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, s.Path, s.Args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("inventory script %s failed: %w: %s", s.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	inv, err := Decode(&stdout, JSON)
+	if err != nil {
+		return nil, fmt.Errorf("inventory script %s: %w", s.Path, err)
+	}
+	return inv, nil
+}
+
+// Merge는 여러 Provider의 결과를 순서대로 합칩니다.
+// 같은 장비나 그룹이 다시 나오면 뒤에 나온 값이 앞의 값을 덮어쓰므로
+// NetBox에서 가져온 장비 목록에 파일로 관리하는 그룹 변수(계정 등)를 얹을 수 있습니다.
+func Merge(providers ...Provider) Provider {
+	return mergedProvider(providers)
+}
+
+type mergedProvider []Provider
+
+func (m mergedProvider) Inventory(ctx context.Context) (*Inventory, error) {
+	out := &Inventory{}
+	hosts := make(map[string]int)
+	groups := make(map[string]int)
+
+	for _, p := range m {
+		inv, err := p.Inventory(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range inv.Routers {
+			i, ok := hosts[r.Hostname]
+			if !ok {
+				hosts[r.Hostname] = len(out.Routers)
+				out.Routers = append(out.Routers, r)
+				continue
+			}
+			if r.IP.IsValid() {
+				out.Routers[i].IP = r.IP
+			}
+			out.Routers[i].Groups = appendUnique(out.Routers[i].Groups, r.Groups...)
+			out.Routers[i].Vars.merge(r.Vars)
+		}
+
+		for _, g := range inv.Groups {
+			i, ok := groups[g.Name]
+			if !ok {
+				groups[g.Name] = len(out.Groups)
+				out.Groups = append(out.Groups, g)
+				continue
+			}
+			out.Groups[i].Children = appendUnique(out.Groups[i].Children, g.Children...)
+			out.Groups[i].Vars.merge(g.Vars)
+		}
+	}
+	return out, nil
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, l := range list {
+			found = found || l == item
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"fmt"
+
+	"tucker-study/01-Go-Start/vault"
+)
+
+// secrets는 인벤토리 안의 모든 비밀 값(장비/그룹의 Password와 사용자 변수)을 순회합니다.
+// f에는 값을 바꿀 수 있도록 포인터와 에러 메시지에 쓸 위치가 전달됩니다.
+func (inv *Inventory) secrets(f func(where string, s *string, custom bool) error) error {
+	visit := func(where string, v *Vars) error {
+		if err := f(where+": password", &v.Password, false); err != nil {
+			return err
+		}
+		for k, val := range v.Custom {
+			if err := f(where+": vars."+k, &val, true); err != nil {
+				return err
+			}
+			v.Custom[k] = val
+		}
+		return nil
+	}
+
+	for i := range inv.Routers {
+		if err := visit("host "+inv.Routers[i].Hostname, &inv.Routers[i].Vars); err != nil {
+			return err
+		}
+	}
+	for i := range inv.Groups {
+		if err := visit("group "+inv.Groups[i].Name, &inv.Groups[i].Vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encrypted는 인벤토리에 vault로 암호화된 값이 있는지 확인합니다.
+func (inv *Inventory) Encrypted() bool {
+	found := false
+	inv.secrets(func(_ string, s *string, _ bool) error {
+		found = found || vault.IsEncrypted(*s)
+		return nil
+	})
+	return found
+}
+
+// Encrypt는 평문으로 적힌 Password를 모두 암호화합니다.
+// 사용자 변수는 어떤 값이 비밀인지 알 수 없으므로 건드리지 않습니다.
+func (inv *Inventory) Encrypt(v *vault.Vault) error {
+	return inv.secrets(func(where string, s *string, custom bool) error {
+		if custom || *s == "" || vault.IsEncrypted(*s) {
+			return nil
+		}
+		enc, err := v.Encrypt(*s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		*s = enc
+		return nil
+	})
+}
+
+// Decrypt는 암호화된 값을 모두 평문으로 바꿉니다.
+func (inv *Inventory) Decrypt(v *vault.Vault) error {
+	return inv.secrets(func(where string, s *string, _ bool) error {
+		if !vault.IsEncrypted(*s) {
+			return nil
+		}
+		dec, err := v.Decrypt(*s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		*s = dec
+		return nil
+	})
+}
+
+// Rekey는 암호화된 값만 골라 old 키로 풀고 new 키로 다시 암호화합니다.
+func (inv *Inventory) Rekey(old, new *vault.Vault) error {
+	return inv.secrets(func(where string, s *string, _ bool) error {
+		if !vault.IsEncrypted(*s) {
+			return nil
+		}
+		dec, err := old.Decrypt(*s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		enc, err := new.Encrypt(dec)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		*s = enc
+		return nil
+	})
+}
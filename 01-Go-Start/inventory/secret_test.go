@@ -0,0 +1,147 @@
+package inventory_test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/vault"
+)
+
+// secretInventory는 장비와 그룹에 비밀번호가, 사용자 변수에 평문 값이 있는 인벤토리입니다.
+func secretInventory() *inventory.Inventory {
+	inv := &inventory.Inventory{
+		Routers: []inventory.Router{{Hostname: "rtr1"}, {Hostname: "rtr2"}},
+		Groups:  []inventory.Group{{Name: "edge"}},
+	}
+	inv.Routers[0].Password = "rtr1-pw"
+	inv.Routers[0].Custom = inventory.VarMap{"snmp_community": "public"}
+	inv.Groups[0].Password = "edge-pw"
+	return inv
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	v := vault.New([]byte("correct horse"))
+	inv := secretInventory()
+	if inv.Encrypted() {
+		t.Fatal("plain inventory reported as encrypted")
+	}
+	if err := inv.Encrypt(v); err != nil {
+		t.Fatal(err)
+	}
+
+	if !vault.IsEncrypted(inv.Routers[0].Password) || !vault.IsEncrypted(inv.Groups[0].Password) {
+		t.Errorf("passwords not encrypted: %q, %q", inv.Routers[0].Password, inv.Groups[0].Password)
+	}
+	// 빈 비밀번호와 사용자 변수는 그대로 둡니다.
+	if inv.Routers[1].Password != "" || inv.Routers[0].Custom["snmp_community"] != "public" {
+		t.Errorf("encrypted too much: %+v", inv.Routers)
+	}
+
+	// 이미 암호화된 값은 다시 암호화하지 않습니다.
+	enc := inv.Routers[0].Password
+	if err := inv.Encrypt(v); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Routers[0].Password != enc {
+		t.Error("Encrypt re-encrypted an encrypted value")
+	}
+
+	if err := inv.Decrypt(v); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Routers[0].Password != "rtr1-pw" || inv.Groups[0].Password != "edge-pw" || inv.Encrypted() {
+		t.Errorf("decrypted: %q, %q", inv.Routers[0].Password, inv.Groups[0].Password)
+	}
+}
+
+func TestDecryptWrongKey(t *testing.T) {
+	inv := secretInventory()
+	if err := inv.Encrypt(vault.New([]byte("correct horse"))); err != nil {
+		t.Fatal(err)
+	}
+	err := inv.Decrypt(vault.New([]byte("battery staple")))
+	if !errors.Is(err, vault.ErrDecrypt) {
+		t.Fatalf("err = %v, want ErrDecrypt", err)
+	}
+	// 어느 값에서 실패했는지 알려 줍니다.
+	if !strings.HasPrefix(err.Error(), "host rtr1: password") {
+		t.Errorf("err = %q, want the failing host", err)
+	}
+}
+
+func TestRekey(t *testing.T) {
+	old, new := vault.New([]byte("correct horse")), vault.New([]byte("battery staple"))
+	inv := secretInventory()
+	if err := inv.Encrypt(old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := inv.Rekey(old, new); err != nil {
+		t.Fatal(err)
+	}
+	if err := secretCopy(inv).Decrypt(old); !errors.Is(err, vault.ErrDecrypt) {
+		t.Errorf("old key after rekey: err = %v, want ErrDecrypt", err)
+	}
+	if err := inv.Decrypt(new); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Routers[0].Password != "rtr1-pw" || inv.Groups[0].Password != "edge-pw" {
+		t.Errorf("after rekey: %q, %q", inv.Routers[0].Password, inv.Groups[0].Password)
+	}
+
+	// 틀린 old 키이면 실패합니다.
+	inv = secretInventory()
+	if err := inv.Encrypt(old); err != nil {
+		t.Fatal(err)
+	}
+	if err := inv.Rekey(new, old); !errors.Is(err, vault.ErrDecrypt) {
+		t.Errorf("rekey with a wrong old key: err = %v, want ErrDecrypt", err)
+	}
+}
+
+// secretCopy는 비밀번호까지 복사한 인벤토리입니다. (Decrypt가 원본을 바꾸지 않도록)
+// 사용자 변수 맵은 공유하므로 여기에 암호화된 값이 없을 때만 씁니다.
+func secretCopy(inv *inventory.Inventory) *inventory.Inventory {
+	return &inventory.Inventory{
+		Routers: append([]inventory.Router(nil), inv.Routers...),
+		Groups:  append([]inventory.Group(nil), inv.Groups...),
+	}
+}
+
+func TestLoadEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.yml")
+	inv := secretInventory()
+	if err := inv.Encrypt(vault.New([]byte("correct horse"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := inventory.Save(path, inv); err != nil {
+		t.Fatal(err)
+	}
+
+	// 키가 없으면 읽지 못하고, 환경 변수의 키로 복호화해서 읽습니다.
+	t.Setenv(vault.PasswordFileEnv, "")
+	t.Setenv(vault.PasswordEnv, "")
+	if _, err := inventory.Load(path); !errors.Is(err, vault.ErrNoKey) {
+		t.Errorf("Load without a key = %v, want ErrNoKey", err)
+	}
+	t.Setenv(vault.PasswordEnv, "correct horse")
+	loaded, err := inventory.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Routers[0].Password != "rtr1-pw" || loaded.Groups[0].Password != "edge-pw" {
+		t.Errorf("loaded: %q, %q", loaded.Routers[0].Password, loaded.Groups[0].Password)
+	}
+
+	// ReadFile은 파일에 적힌 그대로(암호화된 채로) 읽습니다.
+	raw, err := inventory.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raw.Encrypted() {
+		t.Error("ReadFile decrypted the inventory")
+	}
+}
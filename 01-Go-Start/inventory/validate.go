@@ -0,0 +1,114 @@
+package inventory
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/scrapli/scrapligo/platform"
+)
+
+// KnownPlatform은 scrapligo가 내장한 플랫폼 이름인지 확인합니다.
+// 직접 만든 플랫폼 정의 파일(.yaml/.yml)을 가리키는 경우도 허용합니다.
+func KnownPlatform(name string) bool {
+	for _, p := range platform.GetPlatformNames() {
+		if p == name {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")
+}
+
+// pos는 디코딩 때 기록한 i번째 항목의 field 위치를 찾습니다.
+func pos(list []positions, i int, field string) Position {
+	if i < len(list) {
+		return list[i].of(field)
+	}
+	return Position{}
+}
+
+// Validate는 인벤토리의 문제를 모두 찾아 위치가 담긴 *Error 목록으로 돌려줍니다.
+// 중복된 장비/그룹 이름, 알 수 없는 플랫폼과 접속 방식, 유니캐스트가 아닌 관리 주소, AS_TRANS(23456),
+// 정의되지 않은 그룹 참조와 순환하는 그룹 구조를 검사합니다.
+// 형식이 잘못된 IP와 범위를 벗어난 ASN은 디코딩 단계에서 이미 거부됩니다. 문제가 없으면 nil입니다.
+func (inv *Inventory) Validate() []error {
+	var errs []error
+	add := func(p Position, format string, args ...any) {
+		errs = append(errs, &Error{File: inv.file, Pos: p, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	checkVars := func(list []positions, i int, who string, v Vars) {
+		if v.Platform != "" && !KnownPlatform(v.Platform) {
+			add(pos(list, i, "platform"), "%s: unknown platform %q", who, v.Platform)
+		}
+		if v.ASN == ASTrans {
+			add(pos(list, i, "asn"), "%s: asn %s is AS_TRANS and cannot be used as a real ASN", who, v.ASN)
+		}
+		if v.Port < 0 || v.Port > 65535 {
+			add(pos(list, i, "port"), "%s: port %d is out of range", who, v.Port)
+		}
+		if v.Mode != "" && !slices.Contains(Modes, v.Mode) {
+			add(pos(list, i, "mode"), "%s: unknown mode %q (want %s)", who, v.Mode, strings.Join(Modes, ", "))
+		}
+	}
+
+	groups := make(map[string]int)
+	for i, g := range inv.Groups {
+		switch first, dup := groups[g.Name]; {
+		case g.Name == "":
+			add(pos(inv.groupPos, i, "name"), "group without name")
+		case dup:
+			add(pos(inv.groupPos, i, "name"), "duplicate group %q (first defined at %s)", g.Name, pos(inv.groupPos, first, "name"))
+		default:
+			groups[g.Name] = i
+		}
+		checkVars(inv.groupPos, i, fmt.Sprintf("group %q", g.Name), g.Vars)
+	}
+	for i, g := range inv.Groups {
+		for _, c := range g.Children {
+			if _, ok := groups[c]; !ok {
+				add(pos(inv.groupPos, i, "children"), "group %q: unknown child group %q", g.Name, c)
+			}
+		}
+	}
+
+	hosts := make(map[string]int)
+	for i, r := range inv.Routers {
+		switch first, dup := hosts[r.Hostname]; {
+		case r.Hostname == "":
+			add(pos(inv.hostPos, i, "hostname"), "host without hostname")
+		case dup:
+			add(pos(inv.hostPos, i, "hostname"), "duplicate hostname %q (first defined at %s)", r.Hostname, pos(inv.hostPos, first, "hostname"))
+		default:
+			hosts[r.Hostname] = i
+		}
+
+		if a := r.IP.Addr(); r.IP.IsValid() && !a.IsGlobalUnicast() && !a.IsLoopback() {
+			add(pos(inv.hostPos, i, "ip"), "host %s: ip %s is not a unicast address", r.Hostname, r.IP)
+		}
+		for _, g := range r.Groups {
+			if _, ok := groups[g]; !ok && g != AllGroup {
+				add(pos(inv.hostPos, i, "groups"), "host %s: unknown group %q", r.Hostname, g)
+			}
+		}
+		checkVars(inv.hostPos, i, "host "+r.Hostname, r.Vars)
+	}
+
+	// 참조 문제가 없을 때만 순환 여부를 확인합니다. (newGroupTree가 같은 문제를 다시 보고하지 않도록)
+	if len(errs) == 0 {
+		if _, err := newGroupTree(inv); err != nil {
+			add(Position{}, "%s", err)
+		}
+	}
+
+	// 파일에 나온 순서대로 보여줍니다.
+	sort.SliceStable(errs, func(i, j int) bool {
+		a, b := errs[i].(*Error).Pos, errs[j].(*Error).Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return errs
+}
@@ -0,0 +1,145 @@
+package inventory
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// DefaultSSHConfig는 SSHConfig를 지정하지 않았을 때 사용하는 ssh_config 경로입니다.
+const DefaultSSHConfig = "ssh_config"
+
+// 장비에 접속하는 방식(Vars.Mode)입니다.
+const (
+	// ModeCLI는 scrapligo 네트워크 드라이버로 CLI 명령을 보내는 기본 방식입니다.
+	ModeCLI = "cli"
+	// ModeNETCONF는 NETCONF over SSH(RFC 6242)입니다. Port를 지정하지 않으면 830번 포트로 접속합니다.
+	ModeNETCONF = "netconf"
+	// ModeGNMI는 gRPC의 gNMI입니다. Port를 지정하지 않으면 57400번 포트로 접속합니다.
+	ModeGNMI = "gnmi"
+)
+
+// 접속 방식별 기본 포트입니다.
+const (
+	DefaultNETCONFPort = 830
+	DefaultGNMIPort    = 57400
+)
+
+// Modes는 Vars.Mode에 쓸 수 있는 값입니다.
+var Modes = []string{ModeCLI, ModeNETCONF, ModeGNMI}
+
+// Vars는 그룹에서 장비로 상속되는 변수입니다.
+// 빈 값(StrictKey는 nil)은 "지정하지 않음"을 뜻하며 상위 그룹의 값을 그대로 사용합니다.
+type Vars struct {
+	ASN       ASN    `json:"asn,omitempty" xml:"asn,omitempty" yaml:"asn,omitempty"`
+	Platform  string `json:"platform,omitempty" xml:"platform,omitempty" yaml:"platform,omitempty"`
+	Username  string `json:"username,omitempty" xml:"username,omitempty" yaml:"username,omitempty"`
+	Password  string `json:"password,omitempty" xml:"password,omitempty" yaml:"password,omitempty"`
+	StrictKey *bool  `json:"strictkey,omitempty" xml:"strictkey,omitempty" yaml:"strictkey,omitempty"`
+	SSHConfig string `json:"sshconfig,omitempty" xml:"sshconfig,omitempty" yaml:"sshconfig,omitempty"`
+	// Port는 SSH 포트입니다. 0이면 scrapligo 기본값(22)입니다.
+	Port int `json:"port,omitempty" xml:"port,omitempty" yaml:"port,omitempty"`
+	// Mode는 접속 방식(cli, netconf, gnmi)입니다. 비어 있으면 cli입니다.
+	Mode   string `json:"mode,omitempty" xml:"mode,omitempty" yaml:"mode,omitempty"`
+	Custom VarMap `json:"vars,omitempty" xml:"vars,omitempty" yaml:"vars,omitempty"`
+}
+
+// ConnMode는 접속 방식을 돌려줍니다. 지정하지 않았으면 ModeCLI입니다.
+func (v Vars) ConnMode() string {
+	if v.Mode == "" {
+		return ModeCLI
+	}
+	return v.Mode
+}
+
+// Strict는 SSH 호스트 키를 known_hosts로 확인할지입니다. 지정하지 않았으면 확인하지 않습니다.
+func (v Vars) Strict() bool {
+	return v.StrictKey != nil && *v.StrictKey
+}
+
+// SSHConfigFile은 scrapligo에 넘길 ssh_config 경로를 돌려줍니다.
+func (v Vars) SSHConfigFile() string {
+	if v.SSHConfig == "" {
+		return DefaultSSHConfig
+	}
+	return v.SSHConfig
+}
+
+// merge는 src에 지정된 값으로 v를 덮어씁니다.
+func (v *Vars) merge(src Vars) {
+	if src.ASN != 0 {
+		v.ASN = src.ASN
+	}
+	if src.Platform != "" {
+		v.Platform = src.Platform
+	}
+	if src.Username != "" {
+		v.Username = src.Username
+	}
+	if src.Password != "" {
+		v.Password = src.Password
+	}
+	if src.StrictKey != nil {
+		b := *src.StrictKey
+		v.StrictKey = &b
+	}
+	if src.SSHConfig != "" {
+		v.SSHConfig = src.SSHConfig
+	}
+	if src.Port != 0 {
+		v.Port = src.Port
+	}
+	if src.Mode != "" {
+		v.Mode = src.Mode
+	}
+	for k, val := range src.Custom {
+		if v.Custom == nil {
+			v.Custom = make(VarMap)
+		}
+		v.Custom[k] = val
+	}
+}
+
+// VarMap은 자유 형식의 사용자 변수입니다.
+// XML에서는 <vars><var name="site">a</var></vars> 형태로 표현됩니다.
+type VarMap map[string]string
+
+func (m VarMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		el := xml.StartElement{
+			Name: xml.Name{Local: "var"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: k}},
+		}
+		if err := e.EncodeElement(m[k], el); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (m *VarMap) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var vars struct {
+		Var []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"var"`
+	}
+	if err := d.DecodeElement(&vars, &start); err != nil {
+		return err
+	}
+
+	*m = make(VarMap, len(vars.Var))
+	for _, v := range vars.Var {
+		(*m)[v.Name] = v.Value
+	}
+	return nil
+}
@@ -1,36 +1,19 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-)
-
-type Router struct {
-	Hostname string `json:"hostname"`
-	IP       string `json:"ip"`
-	ASN      uint16 `json:"asn"`
-}
 
-type Inventory struct {
-	Routers []Router `json:"router"`
-}
+	"tucker-study/01-Go-Start/inventory"
+)
 
 func main() {
-	file, err := os.Open("01-Go-Start/input.json")
+	// 확장자(.json)를 보고 JSON 디코더로 인벤토리를 읽는다.
+	inv, err := inventory.Load("01-Go-Start/json-decode/input.json")
 	// 에러를 처리한다.
 	if err != nil {
-		panic("failed to open file")
+		panic(err)
 	}
-	defer file.Close()
-
-	// io.Reader 타입에서 데이터 추출
-	d := json.NewDecoder(file)
-
-	var inv Inventory
-
-	err = d.Decode(&inv)
 
 	// %v는 구조체 서식이고 +하면 id를 포함해서 출력
-	fmt.Printf("%+v\n", inv)
+	fmt.Printf("%+v\n", inv.Routers)
 }
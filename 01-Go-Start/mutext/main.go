@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
-	"github.com/scrapli/scrapligo/driver/options"
-	"github.com/scrapli/scrapligo/platform"
-	"gopkg.in/yaml.v2"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
 )
 
 func timeTrack(start time.Time) {
@@ -16,121 +23,91 @@ func timeTrack(start time.Time) {
 	fmt.Printf("This process took %s\n", elapsed)
 }
 
-type Router struct {
-	Hostname  string `yaml:"hostname"`
-	Platform  string `yaml:"platform"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	StrictKey bool   `yaml:"strictkey"`
-}
-
-type Inventory struct {
-	Routers []Router `yaml:"router"`
-}
-
 var m sync.RWMutex = sync.RWMutex{}
 
-func getVersion(r Router, out chan data, wg *sync.WaitGroup, isAlive map[string]bool) {
-	defer wg.Done()
-
-	p, err := platform.NewPlatform(
-		r.Platform,
-		r.Hostname,
-		options.WithAuthNoStrictKey(),
-		options.WithAuthUsername(r.Username),
-		options.WithAuthPassword(r.Password),
-		options.WithSSHConfigFile("ssh_config"),
-	)
-
+func getVersion(ctx context.Context, r inventory.Router) (data, error) {
+	s, err := device.Open(ctx, r)
 	if err != nil {
-		fmt.Printf("failed to create platform for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
+	defer s.Close()
 
-	d, err := p.GetNetworkDriver()
+	rs, err := s.Send("show version")
 	if err != nil {
-		fmt.Printf("failed to create driver for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
 
-	err = d.Open()
+	// 파싱에 실패해도 원본 출력은 결과에 남깁니다.
+	out := data{host: r.Hostname, Output: runner.Output{Raw: rs.Result}}
+	out.Parsed, err = s.Parse(rs)
 	if err != nil {
-		fmt.Printf("failed to open driver for %s: %+v\n", r.Hostname, err)
-		return
+		return out, err
 	}
-	defer d.Close()
 
-	rs, err := d.SendCommand("show version")
+	out.info, err = textfsm.DecodeOne[textfsm.VersionInfo](out.Parsed)
 	if err != nil {
-		fmt.Printf("failed to send command for %s: %+v\n", r.Hostname, err)
-		m.Lock()
-		isAlive[r.Hostname] = false
-		m.Unlock()
-		return
-	}
-
-	m.Lock()
-	isAlive[r.Hostname] = true
-	m.Unlock()
-
-	parsedOut, err := rs.TextFsmParse(r.Platform + "_show_version.textfsm")
-	if err != nil {
-		fmt.Printf("failed to parse command for %s: %+v\n", r.Hostname, err)
-		return
-	}
-
-	out <- data{
-		host:    r.Hostname,
-		hw:      fmt.Sprintf("%s", parsedOut[0]["HARDWARE"]),
-		version: fmt.Sprintf("%s", parsedOut[0]["VERSION"]),
-		uptime:  fmt.Sprintf("%s", parsedOut[0]["UPTIME"]),
+		return out, runner.Fail(device.StageParse, err)
 	}
+	return out, nil
 }
 
 type data struct {
-	host    string
-	hw      string
-	version string
-	uptime  string
+	host string
+	info textfsm.VersionInfo
+
+	runner.Output
 }
 
-func printer(in chan data) {
-	for out := range in {
-		fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
-			out.host, out.hw, out.version, out.uptime)
+func printResult(res runner.Result[data]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n\n", res.Host.Hostname, res.Err)
+		return
 	}
+	out := res.Value
+	fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
+		out.host, strings.Join(out.info.Hardware, ", "), out.info.Version, out.info.Uptime)
 }
 
 func main() {
 	// To time this process
-	defer timeTrack(time.Now())
-
-	src, err := os.Open("input.yml")
+	start := time.Now()
+
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	var runOpts runner.Options
+	runOpts.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Parse()
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	// 실패한 로그인과 명령은 -retries번까지 다시 시도합니다.
+	ctx, err := devFlags.Context(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer src.Close()
 
-	d := yaml.NewDecoder(src)
-
-	var inv Inventory
-	err = d.Decode(&inv)
+	hosts, err := invFlags.Hosts(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 
-	ch := make(chan data)
-
 	isAlive := make(map[string]bool)
 
-	go printer(ch)
+	// 명령에 응답한 장비(성공했거나 파싱 단계에서 실패한 장비)를 살아 있는 것으로 기록합니다.
+	job := func(ctx context.Context, r inventory.Router) (data, error) {
+		out, err := getVersion(ctx, r)
 
-	var wg sync.WaitGroup
-	for _, v := range inv.Routers {
-		wg.Add(1)
-		go getVersion(v, ch, &wg, isAlive)
+		m.Lock()
+		isAlive[r.Hostname] = err == nil || runner.StageOf(err) == device.StageParse
+		m.Unlock()
+
+		return out, err
 	}
-	wg.Wait()
+	rep := runner.Collect(runner.Run(ctx, hosts, device.Retry(job), runOpts), printResult)
 
 	m.RLock()
 	for name, v := range isAlive {
@@ -138,5 +115,13 @@ func main() {
 	}
 	m.RUnlock()
 
-	close(ch)
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	timeTrack(start)
+	os.Exit(rep.ExitCode())
 }
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"net/netip"
+	"strings"
+	"text/template"
+
+	"github.com/c-robinson/iplib"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// Funcs는 템플릿에서 쓸 수 있는 함수들입니다. 주소와 prefix 인자는 문자열, netip 값,
+// 인벤토리의 관리 주소(.IP) 중 아무거나 받습니다. 파이프로 이어 쓸 수 있도록 대상 값이 마지막 인자입니다.
+//
+//	{{ .IP | prefix | network }}          10.0.0.0/24
+//	{{ .IP | prefix | netmask }}          255.255.255.0
+//	{{ "10.0.0.0/30" | host 1 }}          10.0.0.1
+//	{{ .IP | add 1 }}                     다음 주소 (음수면 이전 주소)
+//	{{ .ASN | asdot }}                    64086.59904
+var Funcs = template.FuncMap{
+	"addr":      toAddr,
+	"prefix":    toPrefix,
+	"network":   network,
+	"prefixlen": prefixLen,
+	"netmask":   netmask,
+	"wildcard":  wildcard,
+	"host":      host,
+	"add":       add,
+	"next":      func(v any) (netip.Addr, error) { return add(1, v) },
+	"prev":      func(v any) (netip.Addr, error) { return add(-1, v) },
+	"contains":  contains,
+	"asplain":   asplain,
+	"asdot":     asdot,
+	"default":   defaultValue,
+	"join":      join,
+	"lower":     strings.ToLower,
+	"upper":     strings.ToUpper,
+}
+
+func toAddr(v any) (netip.Addr, error) {
+	switch t := v.(type) {
+	case netip.Addr:
+		return t, nil
+	case netip.Prefix:
+		return t.Addr(), nil
+	case inventory.MgmtAddr:
+		return t.Addr(), nil
+	case string:
+		if p, err := netip.ParsePrefix(t); err == nil {
+			return p.Addr(), nil
+		}
+		return netip.ParseAddr(t)
+	}
+	return netip.Addr{}, fmt.Errorf("addr: unsupported type %T", v)
+}
+
+// toPrefix는 prefix를 돌려줍니다. 길이 없는 주소는 /32(/128)로 봅니다.
+func toPrefix(v any) (netip.Prefix, error) {
+	switch t := v.(type) {
+	case netip.Prefix:
+		return t, nil
+	case netip.Addr:
+		return netip.PrefixFrom(t, t.BitLen()), nil
+	case inventory.MgmtAddr:
+		return t.Prefix(), nil
+	case string:
+		if a, err := netip.ParseAddr(t); err == nil {
+			return netip.PrefixFrom(a, a.BitLen()), nil
+		}
+		return netip.ParsePrefix(t)
+	}
+	return netip.Prefix{}, fmt.Errorf("prefix: unsupported type %T", v)
+}
+
+func network(v any) (netip.Prefix, error) {
+	p, err := toPrefix(v)
+	return p.Masked(), err
+}
+
+func prefixLen(v any) (int, error) {
+	p, err := toPrefix(v)
+	return p.Bits(), err
+}
+
+// maskBytes는 prefix 길이를 넷마스크 바이트로 바꿉니다. IPv4만 지원합니다.
+func maskBytes(v any, name string) (net.IPMask, error) {
+	p, err := toPrefix(v)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Addr().Is4() {
+		return nil, fmt.Errorf("%s: %s is not an IPv4 prefix", name, p)
+	}
+	return net.CIDRMask(p.Bits(), 32), nil
+}
+
+// netmask는 "255.255.255.0" 형태의 넷마스크입니다.
+func netmask(v any) (string, error) {
+	m, err := maskBytes(v, "netmask")
+	if err != nil {
+		return "", err
+	}
+	return net.IP(m).String(), nil
+}
+
+// wildcard는 ACL, OSPF에서 쓰는 "0.0.0.255" 형태의 와일드카드 마스크입니다.
+func wildcard(v any) (string, error) {
+	m, err := maskBytes(v, "wildcard")
+	if err != nil {
+		return "", err
+	}
+	for i := range m {
+		m[i] = ^m[i]
+	}
+	return net.IP(m).String(), nil
+}
+
+// host는 prefix의 네트워크 주소에서 n번째 주소입니다. prefix 밖이면 에러입니다.
+func host(n int, v any) (netip.Addr, error) {
+	p, err := network(v)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	a, err := add(n, p.Addr())
+	if err != nil {
+		return a, err
+	}
+	if !p.Contains(a) {
+		return netip.Addr{}, fmt.Errorf("host: %d is outside %s", n, p)
+	}
+	return a, nil
+}
+
+// add는 주소에 n을 더합니다. n이 음수면 뺍니다.
+func add(n int, v any) (netip.Addr, error) {
+	a, err := toAddr(v)
+	if err != nil {
+		return a, err
+	}
+
+	ip := net.IP(a.AsSlice())
+	switch {
+	case a.Is4() && n >= 0:
+		ip = iplib.IncrementIP4By(ip, uint32(n))
+	case a.Is4():
+		ip = iplib.DecrementIP4By(ip, uint32(-n))
+	case n >= 0:
+		ip = iplib.IncrementIP6By(ip, big.NewInt(int64(n)))
+	default:
+		ip = iplib.DecrementIP6By(ip, big.NewInt(int64(-n)))
+	}
+
+	out, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("add: cannot add %d to %s", n, a)
+	}
+	if a.Is4() {
+		out = out.Unmap()
+	}
+	// iplib은 범위를 넘으면 원래 주소를 그대로 돌려줍니다.
+	if n != 0 && out == a {
+		return netip.Addr{}, fmt.Errorf("add: %s%+d is out of range", a, n)
+	}
+	return out, nil
+}
+
+func contains(p, v any) (bool, error) {
+	pfx, err := network(p)
+	if err != nil {
+		return false, err
+	}
+	a, err := toAddr(v)
+	if err != nil {
+		return false, err
+	}
+	return pfx.Contains(a), nil
+}
+
+func toASN(v any) (inventory.ASN, error) {
+	switch t := v.(type) {
+	case inventory.ASN:
+		return t, nil
+	case int:
+		return inventory.ASN(t), nil
+	case string:
+		return inventory.ParseASN(t)
+	}
+	return 0, fmt.Errorf("asn: unsupported type %T", v)
+}
+
+func asplain(v any) (string, error) {
+	a, err := toASN(v)
+	return a.String(), err
+}
+
+func asdot(v any) (string, error) {
+	a, err := toASN(v)
+	return a.ASDot(), err
+}
+
+// defaultValue는 v가 빈 문자열이면 d를 돌려줍니다. 인벤토리에 없는 변수에는 쓸 수 없습니다.
+func defaultValue(d, v any) any {
+	if s, ok := v.(string); ok && s == "" {
+		return d
+	}
+	return v
+}
+
+func join(sep string, list []string) string {
+	return strings.Join(list, sep)
+}
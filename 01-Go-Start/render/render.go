@@ -0,0 +1,129 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/template"
+
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// 인벤토리 변수로 장비 설정을 만드는 패키지입니다.
+//
+// 템플릿은 플랫폼별 디렉터리에 둡니다. common의 템플릿은 모든 플랫폼이 함께 쓰고,
+// 같은 이름의 템플릿이 플랫폼 디렉터리에 있으면 그쪽이 우선합니다.
+//
+//	templates/
+//	  common/ntp.tmpl             {{ define "ntp" }}ntp server {{ .Vars.ntp_server }}{{ end }}
+//	  cisco_iosxe/config.tmpl     {{ template "ntp" . }}
+//	  arista_eos/config.tmpl
+const (
+	CommonDir    = "common"
+	DefaultEntry = "config.tmpl"
+)
+
+// Renderer는 Dir의 템플릿으로 장비 설정을 만듭니다. 여러 고루틴에서 함께 써도 됩니다.
+type Renderer struct {
+	Dir string
+	// Entry는 렌더링을 시작할 템플릿 이름입니다. 비어 있으면 DefaultEntry입니다.
+	Entry string
+
+	mu    sync.Mutex
+	cache map[string]*template.Template
+}
+
+// New는 dir의 템플릿을 쓰는 Renderer를 만듭니다.
+func New(dir string) *Renderer {
+	return &Renderer{Dir: dir}
+}
+
+func (r *Renderer) entry() string {
+	if r.Entry == "" {
+		return DefaultEntry
+	}
+	return r.Entry
+}
+
+// templates는 플랫폼의 템플릿 묶음을 읽습니다. 한 번 읽은 묶음은 다시 읽지 않습니다.
+func (r *Renderer) templates(platform string) (*template.Template, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t, ok := r.cache[platform]; ok {
+		return t, nil
+	}
+
+	t := template.New(platform).Funcs(Funcs).Option("missingkey=error")
+	found := false
+	for _, dir := range []string{CommonDir, platform} {
+		files, err := filepath.Glob(filepath.Join(r.Dir, dir, "*.tmpl"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			b, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := t.New(filepath.Base(f)).Parse(string(b)); err != nil {
+				return nil, err
+			}
+			found = true
+		}
+	}
+	if !found || t.Lookup(r.entry()) == nil {
+		return nil, fmt.Errorf("no template %s for platform %q in %s: %w", r.entry(), platform, r.Dir, fs.ErrNotExist)
+	}
+
+	if r.cache == nil {
+		r.cache = map[string]*template.Template{}
+	}
+	r.cache[platform] = t
+	return t, nil
+}
+
+// Data는 템플릿에 넘기는 장비 정보입니다.
+// 값이 없는 항목(IP, ASN)은 넣지 않으므로 템플릿에서 쓰면 missing 에러가 납니다.
+//
+//	.Hostname .Platform .Username .Groups   문자열 / 그룹 목록
+//	.IP                                     관리 주소 (prefix 길이 포함)
+//	.ASN                                    AS 번호
+//	.Vars.<name>                            인벤토리 vars
+func Data(h inventory.Router) map[string]any {
+	d := map[string]any{
+		"Hostname": h.Hostname,
+		"Platform": h.Platform,
+		"Username": h.Username,
+		"Groups":   h.Groups,
+		"Vars":     map[string]string(h.Custom),
+	}
+	if h.Custom == nil {
+		d["Vars"] = map[string]string{}
+	}
+	if h.IP.IsValid() {
+		d["IP"] = h.IP
+	}
+	if h.ASN != 0 {
+		d["ASN"] = h.ASN
+	}
+	return d
+}
+
+// Render는 장비 한 대의 설정을 만듭니다.
+// 템플릿이 인벤토리에 없는 변수를 쓰면 에러입니다.
+func (r *Renderer) Render(h inventory.Router) (string, error) {
+	t, err := r.templates(h.Platform)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.ExecuteTemplate(&buf, r.entry(), Data(h)); err != nil {
+		return "", fmt.Errorf("%s: %w", h.Hostname, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,81 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 실제 장비와의 scrapligo 세션을 transport 단위로 녹화해 두었다가, 장비 없이 같은 드라이버로 재생하는 패키지입니다.
+// 운영 장비에서 받은 출력으로 파싱과 Job 로직이 바뀌지 않았는지 확인할 때 씁니다.
+//
+//	$ netrun -record fixtures/ edge 'show version'     # 실제 장비에 접속하며 녹화
+//	$ netrun -replay fixtures/ -parse edge 'show version' # 장비 없이 재생
+//
+// 장비마다 <dir>/<hostname>.json 파일 하나에 그 실행에서 연 세션들을 순서대로 남깁니다.
+// 재생할 때 scrapligo가 보내는 내용이 녹화와 다르면 (명령이 바뀌었거나 순서가 다르면) 그 자리에서 에러입니다.
+// 로그인 과정(비밀번호 프롬프트)은 남기지 않고, 로그인 뒤에 보낸 비밀번호도 Secret으로만 표시합니다.
+
+// Fixture는 장비 한 대의 녹화 파일입니다.
+type Fixture struct {
+	Host     string    `json:"host"`
+	Platform string    `json:"platform"`
+	Recorded time.Time `json:"recorded"`
+	Sessions []Session `json:"sessions"`
+}
+
+// Session은 세션 하나에서 주고받은 내용입니다.
+type Session struct {
+	Events []Event `json:"events"`
+}
+
+// Event는 보낸 것(Write) 또는 받은 것(Read) 하나입니다. At은 세션을 연 뒤 지난 시간입니다.
+type Event struct {
+	At    time.Duration `json:"at_ns"`
+	Write string        `json:"write,omitempty"`
+	Read  string        `json:"read,omitempty"`
+	// Secret은 비밀번호를 보낸 자리입니다. 내용은 남기지 않고, 재생할 때는 무엇을 보내도 맞는 것으로 봅니다.
+	Secret bool `json:"secret,omitempty"`
+}
+
+// IsWrite는 보낸 이벤트인지 확인합니다.
+func (e Event) IsWrite() bool {
+	return e.Write != "" || e.Secret
+}
+
+// Path는 dir 안의 host 녹화 파일 경로입니다.
+func Path(dir, host string) string {
+	return filepath.Join(dir, host+".json")
+}
+
+// Load는 녹화 파일을 읽습니다.
+func Load(path string) (*Fixture, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// Save는 녹화 파일을 씁니다. 쓰다가 실패해도 예전 파일이 깨지지 않도록 임시 파일을 옮깁니다.
+func (f *Fixture) Save(path string) error {
+	if f.Host == "" {
+		return errors.New("fixture has no host")
+	}
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
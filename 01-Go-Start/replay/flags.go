@@ -0,0 +1,42 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"tucker-study/01-Go-Start/device"
+)
+
+// Flags는 녹화/재생 명령행 플래그입니다.
+type Flags struct {
+	Record string
+	Replay string
+	Speed  float64
+}
+
+// Register는 -record, -replay, -replay-speed 플래그를 등록합니다.
+func (f *Flags) Register(fs *flag.FlagSet) {
+	fs.StringVar(&f.Record, "record", "", "record device sessions to <dir>/<hostname>.json")
+	fs.StringVar(&f.Replay, "replay", "", "replay recorded sessions from <dir> instead of connecting to devices")
+	fs.Float64Var(&f.Speed, "replay-speed", 0, "multiply recorded delays by this factor when replaying (0 = no delay)")
+}
+
+// Context는 플래그에 맞게 세션을 녹화하거나 재생하는 ctx를 돌려줍니다. 둘 다 없으면 ctx 그대로입니다.
+func (f *Flags) Context(ctx context.Context) (context.Context, error) {
+	switch {
+	case f.Record != "" && f.Replay != "":
+		return nil, errors.New("-record and -replay cannot be used together")
+	case f.Record != "":
+		rec, err := NewRecorder(f.Record)
+		if err != nil {
+			return nil, err
+		}
+		return device.WithInterceptor(ctx, rec.Intercept), nil
+	case f.Replay != "":
+		p := NewPlayer(f.Replay)
+		p.Speed = f.Speed
+		return device.WithInterceptor(ctx, p.Intercept), nil
+	}
+	return ctx, nil
+}
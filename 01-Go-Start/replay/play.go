@@ -0,0 +1,188 @@
+package replay
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/scrapli/scrapligo/transport"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// DefaultVolatile은 재생할 때 비교하지 않는, 실행할 때마다 달라지는 부분입니다.
+// (예: deploy가 체크포인트 이름에 넣는 시각 push-20240102-150405.cfg)
+var DefaultVolatile = []*regexp.Regexp{
+	regexp.MustCompile(`\d{8}-\d{6}`),
+}
+
+// Player는 녹화 파일의 세션들을 장비 대신 재생합니다. 장비마다 녹화한 순서대로 세션을 하나씩 씁니다.
+type Player struct {
+	dir string
+	// Speed는 녹화된 시간 간격에 곱하는 값입니다. 1이면 녹화한 속도 그대로, 0이면 기다리지 않습니다.
+	Speed float64
+	// Volatile에 맞는 부분은 보낸 내용과 녹화를 비교할 때 뺍니다.
+	Volatile []*regexp.Regexp
+
+	mu       sync.Mutex
+	fixtures map[string]*Fixture
+	used     map[string]int
+}
+
+// NewPlayer는 dir의 녹화 파일을 재생하는 Player를 만듭니다. 파일은 세션을 열 때 읽습니다.
+func NewPlayer(dir string) *Player {
+	return &Player{dir: dir, Volatile: DefaultVolatile, fixtures: map[string]*Fixture{}, used: map[string]int{}}
+}
+
+// Intercept는 device.Interceptor입니다. 장비에 접속하는 대신 녹화된 다음 세션을 재생하는 transport를 돌려줍니다.
+func (p *Player) Intercept(r inventory.Router, _ transport.Implementation) (transport.Implementation, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, ok := p.fixtures[r.Hostname]
+	if !ok {
+		var err error
+		if f, err = Load(Path(p.dir, r.Hostname)); err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		p.fixtures[r.Hostname] = f
+	}
+	if f.Platform != r.Platform {
+		return nil, fmt.Errorf("replay: %s was recorded as %s, not %s", r.Hostname, f.Platform, r.Platform)
+	}
+	i := p.used[r.Hostname]
+	if i >= len(f.Sessions) {
+		return nil, fmt.Errorf("replay: %s: only %d sessions recorded", r.Hostname, len(f.Sessions))
+	}
+	p.used[r.Hostname]++
+	return newPlayback(r.Hostname, f.Sessions[i].Events, p), nil
+}
+
+// chunk는 보낼 차례가 된 녹화 출력입니다. due 전에는 읽히지 않습니다.
+type chunk struct {
+	data []byte
+	due  time.Time
+}
+
+// playback은 세션 하나를 재생하는 transport입니다.
+// 받은 Write가 녹화된 다음 Write와 같으면 그 뒤에 녹화된 Read들을 읽을 수 있게 합니다.
+type playback struct {
+	host     string
+	events   []Event
+	speed    float64
+	volatile []*regexp.Regexp
+
+	mu      sync.Mutex
+	next    int
+	pending []chunk
+	wake    chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newPlayback(host string, events []Event, p *Player) *playback {
+	return &playback{
+		host:     host,
+		events:   events,
+		speed:    p.Speed,
+		volatile: p.Volatile,
+		wake:     make(chan struct{}, 1),
+		closed:   make(chan struct{}),
+	}
+}
+
+// release는 next부터 다음 Write 전까지의 Read들을 from 이벤트 기준의 시간 간격으로 보낼 차례에 넣습니다.
+func (t *playback) release(from time.Duration) {
+	now := time.Now()
+	for ; t.next < len(t.events) && !t.events[t.next].IsWrite(); t.next++ {
+		e := t.events[t.next]
+		delay := time.Duration(float64(e.At-from) * t.speed)
+		t.pending = append(t.pending, chunk{data: []byte(e.Read), due: now.Add(delay)})
+	}
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *playback) Open(*transport.Args) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.release(0)
+	return nil
+}
+
+func (t *playback) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
+
+func (t *playback) IsAlive() bool {
+	select {
+	case <-t.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// Read는 보낼 차례가 된 출력을 최대 n바이트 돌려줍니다. 없으면 생기거나 닫힐 때까지 기다립니다.
+func (t *playback) Read(n int) ([]byte, error) {
+	for {
+		t.mu.Lock()
+		if len(t.pending) > 0 {
+			c := &t.pending[0]
+			wait := time.Until(c.due)
+			if wait <= 0 {
+				b := c.data[:min(n, len(c.data))]
+				if c.data = c.data[len(b):]; len(c.data) == 0 {
+					t.pending = t.pending[1:]
+				}
+				t.mu.Unlock()
+				return b, nil
+			}
+			t.mu.Unlock()
+			select {
+			case <-time.After(wait):
+			case <-t.closed:
+				return nil, io.EOF
+			}
+			continue
+		}
+		t.mu.Unlock()
+
+		select {
+		case <-t.wake:
+		case <-t.closed:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Write는 b가 녹화된 다음 Write와 같은지 확인합니다. 다르면 재생을 이어 갈 수 없으므로 에러입니다.
+func (t *playback) Write(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.next >= len(t.events) {
+		return fmt.Errorf("replay: %s: sent %q after the end of the recorded session", t.host, b)
+	}
+	e := t.events[t.next]
+	if !e.Secret && t.strip(e.Write) != t.strip(string(b)) {
+		return fmt.Errorf("replay: %s: sent %q, recorded %q", t.host, b, e.Write)
+	}
+	t.next++
+	t.release(e.At)
+	return nil
+}
+
+func (t *playback) strip(s string) string {
+	for _, re := range t.volatile {
+		s = re.ReplaceAllString(s, "")
+	}
+	return s
+}
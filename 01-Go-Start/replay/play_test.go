@@ -0,0 +1,122 @@
+package replay_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/replay"
+)
+
+var commands = []string{"show version", "show ip interface brief"}
+
+// run은 r에 세션 하나를 열어 commands를 보내고 출력들을 돌려줍니다.
+func run(ctx context.Context, r inventory.Router) ([]string, error) {
+	s, err := device.Open(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	var out []string
+	for _, c := range commands {
+		rs, err := s.Send(c)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, rs.Result)
+	}
+	return out, nil
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe", Username: "ops", Password: "s3cr3t-pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := srv.Router()
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rec, err := replay.NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := run(device.WithInterceptor(ctx, rec.Intercept), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	f, err := replay.Load(replay.Path(dir, "rtr1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Platform != "cisco_iosxe" || len(f.Sessions) != 1 {
+		t.Fatalf("fixture: platform %q, %d sessions; want cisco_iosxe, 1", f.Platform, len(f.Sessions))
+	}
+	b, err := os.ReadFile(replay.Path(dir, "rtr1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), r.Password) {
+		t.Error("fixture contains the login password")
+	}
+
+	// 장비를 닫은 뒤에도 같은 드라이버로 같은 출력을 받습니다.
+	p := replay.NewPlayer(dir)
+	ctx = device.WithInterceptor(ctx, p.Intercept)
+	got, err := run(ctx, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("replayed output:\n%s\nrecorded:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 녹화한 세션은 하나뿐입니다.
+	if _, err := device.Open(ctx, r); err == nil || !strings.Contains(err.Error(), "only 1 sessions recorded") {
+		t.Errorf("Open past the recording = %v", err)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := srv.Router()
+	dir := t.TempDir()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rec, err := replay.NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(device.WithInterceptor(ctx, rec.Intercept), r); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	// 녹화와 다른 명령을 보내면 그 자리에서 에러입니다.
+	s, err := device.Open(device.WithInterceptor(ctx, replay.NewPlayer(dir).Intercept), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := s.Send("show running-config"); err == nil || !strings.Contains(err.Error(), "replay") {
+		t.Errorf("Send of an unrecorded command = %v, want a replay error", err)
+	}
+
+	// 녹화와 다른 플랫폼으로는 재생하지 않습니다.
+	r.Platform = "arista_eos"
+	if _, err := device.Open(device.WithInterceptor(ctx, replay.NewPlayer(dir).Intercept), r); err == nil || !strings.Contains(err.Error(), "recorded as cisco_iosxe") {
+		t.Errorf("Open with another platform = %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package replay
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/scrapli/scrapligo/transport"
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// Recorder는 세션들을 녹화해 장비마다 녹화 파일로 씁니다.
+// 세션이 닫힐 때마다 파일을 다시 쓰므로, 중간에 멈춰도 닫힌 세션까지는 남습니다.
+type Recorder struct {
+	dir string
+
+	mu       sync.Mutex
+	fixtures map[string]*Fixture
+}
+
+// NewRecorder는 dir에 녹화 파일을 쓰는 Recorder를 만듭니다. 같은 장비의 예전 파일은 덮어씁니다.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir, fixtures: map[string]*Fixture{}}, nil
+}
+
+// Intercept는 device.Interceptor입니다. scrapligo가 만든 transport를 녹화하도록 감쌉니다.
+func (rec *Recorder) Intercept(r inventory.Router, t transport.Implementation) (transport.Implementation, error) {
+	base := &recorder{Implementation: t, rec: rec, host: r.Hostname, index: rec.open(r)}
+	// system transport처럼 채널 안에서 로그인하는 transport는 scrapligo가 타입으로 알아보므로 그대로 드러냅니다.
+	if auth, ok := t.(interface {
+		transport.InChannelAuthImplementation
+		transport.SSHImplementation
+	}); ok {
+		return &authRecorder{recorder: base, auth: auth}, nil
+	}
+	return base, nil
+}
+
+// open은 세션 자리를 잡아 둡니다. 세션은 닫히는 순서가 아니라 열린 순서대로 재생되어야 하기 때문입니다.
+// (예: deploy는 첫 세션을 연 채로 사후 점검 세션을 열고 닫습니다)
+func (rec *Recorder) open(r inventory.Router) int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	f, ok := rec.fixtures[r.Hostname]
+	if !ok {
+		f = &Fixture{Host: r.Hostname, Platform: r.Platform, Recorded: time.Now()}
+		rec.fixtures[r.Hostname] = f
+	}
+	f.Sessions = append(f.Sessions, Session{})
+	return len(f.Sessions) - 1
+}
+
+func (rec *Recorder) save(host string, index int, s Session) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	f := rec.fixtures[host]
+	f.Sessions[index] = s
+	if err := f.Save(Path(rec.dir, host)); err != nil {
+		return fmt.Errorf("record %s: %w", host, err)
+	}
+	return nil
+}
+
+// recorder는 transport 하나를 감싸 주고받은 내용을 남깁니다.
+type recorder struct {
+	transport.Implementation
+	rec   *Recorder
+	host  string
+	index int
+
+	mu       sync.Mutex
+	start    time.Time
+	password string
+	events   []Event
+	// loggedIn은 로그인이 끝났는지입니다. 그 전의 내용(비밀번호 프롬프트 등)은 버립니다.
+	loggedIn bool
+	// authReturn은 로그인 비밀번호 다음에 오는 엔터를 버려야 하는지입니다.
+	authReturn bool
+	closeOnce  sync.Once
+}
+
+func (t *recorder) Open(a *transport.Args) error {
+	t.mu.Lock()
+	t.start, t.password = time.Now(), a.Password
+	t.mu.Unlock()
+	return t.Implementation.Open(a)
+}
+
+func (t *recorder) Read(n int) ([]byte, error) {
+	b, err := t.Implementation.Read(n)
+	if len(b) > 0 {
+		t.mu.Lock()
+		t.events = append(t.events, Event{At: time.Since(t.start), Read: string(b)})
+		t.mu.Unlock()
+	}
+	return b, err
+}
+
+func (t *recorder) Write(b []byte) error {
+	if len(b) == 0 {
+		// 빈 Write는 장비에 아무것도 보내지 않으므로 남기지 않습니다. (SendInteractive의 빈 입력)
+		return t.Implementation.Write(b)
+	}
+	t.mu.Lock()
+	e := Event{At: time.Since(t.start), Write: string(b)}
+	switch {
+	case t.password != "" && e.Write == t.password && !t.loggedIn:
+		// 로그인 중입니다. 지금까지 받은 내용은 재생할 때 필요 없습니다.
+		t.events, t.authReturn = nil, true
+	case t.authReturn && (e.Write == "\n" || e.Write == "\r"):
+		t.authReturn = false
+	case t.password != "" && e.Write == t.password:
+		t.events = append(t.events, Event{At: e.At, Secret: true})
+	default:
+		t.events = append(t.events, e)
+		t.loggedIn, t.authReturn = true, false
+	}
+	t.mu.Unlock()
+	return t.Implementation.Write(b)
+}
+
+// Close는 transport를 닫고 세션을 녹화 파일에 씁니다.
+func (t *recorder) Close() error {
+	err := t.Implementation.Close()
+	t.closeOnce.Do(func() {
+		t.mu.Lock()
+		s := Session{Events: t.events}
+		t.mu.Unlock()
+		if serr := t.rec.save(t.host, t.index, s); serr != nil && err == nil {
+			err = serr
+		}
+	})
+	return err
+}
+
+// authRecorder는 채널 안에서 로그인하는 transport를 위한 recorder입니다.
+type authRecorder struct {
+	*recorder
+	auth interface {
+		transport.InChannelAuthImplementation
+		transport.SSHImplementation
+	}
+}
+
+func (t *authRecorder) GetInChannelAuthType() transport.InChannelAuthType {
+	return t.auth.GetInChannelAuthType()
+}
+
+func (t *authRecorder) GetSSHArgs() *transport.SSHArgs {
+	return t.auth.GetSSHArgs()
+}
@@ -0,0 +1,186 @@
+package runner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// HostResult는 Report에 남는 장비 한 대의 결과입니다.
+type HostResult struct {
+	Host     string                   `json:"host"`
+	Status   Status                   `json:"status"`
+	Stage    Stage                    `json:"stage,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+	Start    time.Time                `json:"start"`
+	Duration time.Duration            `json:"duration_ns"`
+	Raw      string                   `json:"raw,omitempty"`
+	Parsed   []map[string]interface{} `json:"parsed,omitempty"`
+	// Partial은 실패하거나 시간 안에 끝나지 못한 장비에서 그때까지 받은 출력입니다. (Result.Partial)
+	Partial string `json:"partial,omitempty"`
+}
+
+// Report는 작업 전체의 장비별 결과입니다.
+// 실패한 장비도 빠짐없이 남으므로 요약표와 JSON/CSV로 내보내 확인할 수 있습니다.
+type Report struct {
+	Results []HostResult `json:"results"`
+}
+
+// NewHostResult는 Result를 Report에 기록할 형태로 바꿉니다.
+func NewHostResult[T any](res Result[T]) HostResult {
+	hr := HostResult{
+		Host:     res.Host.Hostname,
+		Status:   StatusOf(res.Err),
+		Stage:    StageOf(res.Err),
+		Start:    res.Start,
+		Duration: res.Duration,
+	}
+	if res.Err != nil {
+		hr.Error = res.Err.Error()
+		hr.Partial = res.Partial
+	}
+	// 실패했더라도 그 전까지 받은 출력은 남깁니다.
+	if o, ok := any(res.Value).(hostOutputer); ok {
+		out := o.HostOutput()
+		hr.Raw, hr.Parsed = out.Raw, out.Parsed
+	}
+	return hr
+}
+
+// Collect는 결과 채널을 끝까지 읽어 Report를 만듭니다.
+// each가 nil이 아니면 결과가 도착할 때마다 호출합니다. (진행 상황 출력용)
+func Collect[T any](in <-chan Result[T], each func(Result[T])) *Report {
+	rep := &Report{}
+	for res := range in {
+		if each != nil {
+			each(res)
+		}
+		rep.Results = append(rep.Results, NewHostResult(res))
+	}
+	return rep
+}
+
+// Failed는 성공하지 못한 장비 수입니다.
+func (r *Report) Failed() int {
+	n := 0
+	for _, hr := range r.Results {
+		if hr.Status != StatusOK {
+			n++
+		}
+	}
+	return n
+}
+
+// Count는 상태가 st인 장비 수입니다.
+func (r *Report) Count(st Status) int {
+	n := 0
+	for _, hr := range r.Results {
+		if hr.Status == st {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode는 실패한 장비가 있으면 1, 아니면 0입니다.
+func (r *Report) ExitCode() int {
+	if r.Failed() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// WriteTable은 사람이 읽을 요약표를 씁니다.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "HOST\tSTATUS\tSTAGE\tDURATION\tERROR")
+	for _, hr := range r.Results {
+		stage := string(hr.Stage)
+		if stage == "" {
+			stage = "-"
+		}
+		// 여러 줄짜리 에러가 표를 깨지 않도록 첫 줄만 보여줍니다.
+		msg, _, _ := strings.Cut(hr.Error, "\n")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			hr.Host, hr.Status, stage, hr.Duration.Round(time.Millisecond), msg)
+	}
+	fmt.Fprintf(tw, "\n%d hosts, %d ok, %d failed", len(r.Results), len(r.Results)-r.Failed(), r.Failed())
+	// 실패 중 시간 안에 끝나지 못한 장비는 따로 셉니다.
+	var detail []string
+	for _, st := range []Status{StatusTimeout, StatusCanceled} {
+		if n := r.Count(st); n > 0 {
+			detail = append(detail, fmt.Sprintf("%d %s", n, st))
+		}
+	}
+	if len(detail) > 0 {
+		fmt.Fprintf(tw, " (%s)", strings.Join(detail, ", "))
+	}
+	fmt.Fprintln(tw)
+	return tw.Flush()
+}
+
+// WriteJSON은 원본/파싱 출력을 포함한 전체 결과를 JSON으로 씁니다.
+func (r *Report) WriteJSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(r)
+}
+
+// WriteCSV는 장비마다 한 줄씩 CSV로 씁니다. 파싱 결과는 JSON 문자열로 넣습니다.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"host", "status", "stage", "error", "start", "duration_ms", "raw", "parsed", "partial"})
+	for _, hr := range r.Results {
+		var parsed string
+		if hr.Parsed != nil {
+			b, err := json.Marshal(hr.Parsed)
+			if err != nil {
+				return err
+			}
+			parsed = string(b)
+		}
+		cw.Write([]string{
+			hr.Host,
+			string(hr.Status),
+			string(hr.Stage),
+			hr.Error,
+			hr.Start.Format(time.RFC3339),
+			strconv.FormatInt(hr.Duration.Milliseconds(), 10),
+			hr.Raw,
+			parsed,
+			hr.Partial,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Save는 확장자(.json, .csv)에 맞는 형식으로 파일에 씁니다.
+func (r *Report) Save(path string) error {
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		write = r.WriteJSON
+	case ".csv":
+		write = r.WriteCSV
+	default:
+		return fmt.Errorf("unknown report format: %s", path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
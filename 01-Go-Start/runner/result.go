@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Status는 장비 한 대의 작업 결과 상태입니다.
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusFailed   Status = "failed"
+	StatusTimeout  Status = "timeout"
+	StatusCanceled Status = "canceled"
+)
+
+// Stage는 작업 중 실패한 단계입니다. (예: "open", "send", "parse")
+type Stage string
+
+// StageError는 어느 단계에서 실패했는지를 기억하는 에러입니다.
+type StageError struct {
+	Stage Stage
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Stage, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Fail은 err에 실패한 단계를 붙입니다. err가 nil이면 nil입니다.
+func Fail(stage Stage, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StageError{Stage: stage, Err: err}
+}
+
+// StageOf는 에러가 난 단계를 돌려줍니다. 단계 정보가 없으면 빈 문자열입니다.
+func StageOf(err error) Stage {
+	var se *StageError
+	if errors.As(err, &se) {
+		return se.Stage
+	}
+	return ""
+}
+
+// StatusOf는 에러를 결과 상태로 분류합니다.
+func StatusOf(err error) Status {
+	switch {
+	case err == nil:
+		return StatusOK
+	case errors.Is(err, context.DeadlineExceeded):
+		return StatusTimeout
+	case errors.Is(err, context.Canceled):
+		return StatusCanceled
+	}
+	return StatusFailed
+}
+
+// Output은 장비에서 받은 원본 출력과 TextFSM 파싱 결과입니다.
+// 작업 결과 타입에 임베드하면 Report에 출력이 함께 기록됩니다.
+type Output struct {
+	Raw    string                   `json:"raw,omitempty"`
+	Parsed []map[string]interface{} `json:"parsed,omitempty"`
+}
+
+// HostOutput은 Report가 작업 결과에서 Output을 꺼낼 때 사용합니다.
+func (o Output) HostOutput() Output {
+	return o
+}
+
+type hostOutputer interface {
+	HostOutput() Output
+}
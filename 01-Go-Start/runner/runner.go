@@ -0,0 +1,236 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tucker-study/01-Go-Start/inventory"
+)
+
+// DefaultLimit은 Options.Limit을 지정하지 않았을 때 동시에 여는 최대 SSH 세션 수입니다.
+const DefaultLimit = 10
+
+// ErrAbandoned는 제한 시간이 지나 ctx가 끝났는데도 Grace 안에 돌아오지 않아 기다리지 않고 넘어간 Job입니다.
+// 결과의 Err는 ErrAbandoned와 ctx의 에러를 함께 감싸므로 StatusOf는 timeout이나 canceled입니다.
+var ErrAbandoned = errors.New("job did not return after its context ended")
+
+// Job은 장비 한 대에 대해 실행할 작업입니다.
+// ctx는 장비별 제한 시간이나 전체 취소(SIGINT)로 끝날 수 있으므로 오래 걸리는 작업은 ctx를 확인해야 합니다.
+type Job[T any] func(ctx context.Context, r inventory.Router) (T, error)
+
+// Result는 장비 한 대의 작업 결과입니다.
+type Result[T any] struct {
+	// Index는 Run에 넘긴 hosts에서의 순서입니다.
+	Index    int
+	Host     inventory.Router
+	Value    T
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+	// Partial은 Job이 Capture로 남긴 출력입니다. Job이 끝나지 못했거나(ErrAbandoned) 실패해
+	// Value에 출력이 없을 때 어디까지 받았는지 알 수 있습니다.
+	Partial string
+}
+
+// Options는 Run의 동작을 정합니다.
+type Options struct {
+	// Limit은 동시에 실행할 최대 작업 수입니다. 0이면 DefaultLimit입니다.
+	Limit int
+	// HostTimeout은 장비 한 대에 주는 시간입니다. 0이면 제한이 없습니다.
+	HostTimeout time.Duration
+	// Ordered가 true이면 결과를 hosts 순서대로 전달합니다.
+	// false이면 끝나는 대로 바로 전달합니다.
+	Ordered bool
+	// Deadline은 Run 전체에 주는 시간입니다. 지나면 실행 중인 Job의 ctx를 끝내고,
+	// 아직 시작하지 않은 장비는 timeout으로 돌려줍니다. 0이면 제한이 없습니다.
+	Deadline time.Duration
+	// Grace가 0보다 크면 ctx가 끝난 Job을 그만큼만 기다리고, 그 안에 돌아오지 않으면 ErrAbandoned로
+	// 결과를 돌려주고 넘어갑니다. 0이면 Job이 돌아올 때까지 기다립니다.
+	// 버린 Job은 뒤에서 계속 실행되고 Value도 잃으므로, 설정을 바꾸거나 되돌리는 Job(push 등)에는 쓰지 않습니다.
+	// 버린 Job의 워커는 그 Job이 돌아오거나 Run의 ctx가 끝날 때까지 다음 장비를 시작하지 않습니다.
+	Grace time.Duration
+}
+
+// Register는 Options를 명령행 플래그로 등록합니다. 이미 채워 둔 값은 플래그의 기본값이 됩니다.
+func (o *Options) Register(fs *flag.FlagSet) {
+	if o.Limit <= 0 {
+		o.Limit = DefaultLimit
+	}
+	fs.IntVar(&o.Limit, "workers", o.Limit, "maximum number of concurrent device sessions")
+	fs.DurationVar(&o.HostTimeout, "timeout", o.HostTimeout, "per-host timeout (0 = no limit)")
+	fs.BoolVar(&o.Ordered, "ordered", o.Ordered, "print results in inventory order")
+	fs.DurationVar(&o.Deadline, "deadline", o.Deadline, "overall deadline for all hosts (0 = no limit)")
+}
+
+// Run은 hosts마다 job을 실행하되 동시에 최대 opts.Limit개만 실행합니다.
+// 결과 채널은 모든 장비의 결과를 보낸 뒤 닫힙니다. ctx가 취소되거나 opts.Deadline이 지나면
+// 아직 시작하지 않은 장비는 실행하지 않고 ctx.Err()를 결과로 돌려줍니다.
+// 결과는 장비마다 한 번씩만 보내고, opts.Grace가 지나 늦게 돌아온 Job(ErrAbandoned)의 결과는 버립니다.
+func Run[T any](ctx context.Context, hosts []inventory.Router, job Job[T], opts Options) <-chan Result[T] {
+	cancel := func() {}
+	if opts.Deadline > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Deadline)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > len(hosts) {
+		limit = len(hosts)
+	}
+
+	work := make(chan int)
+	done := make(chan Result[T])
+	out := make(chan Result[T])
+
+	// 작업 배분
+	go func() {
+		defer close(work)
+		for i := range hosts {
+			work <- i
+		}
+	}()
+
+	// limit개의 워커가 작업을 하나씩 가져갑니다.
+	var wg sync.WaitGroup
+	for w := 0; w < limit; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				res, running := runOne(ctx, i, hosts[i], job, opts.HostTimeout, opts.Grace)
+				done <- res
+				// 버린 Job이 아직 실행 중이면 동시 실행 수가 Limit을 넘지 않도록 기다립니다.
+				// Run의 ctx가 끝났으면 남은 장비는 시작하지 않으므로 기다리지 않습니다.
+				if running != nil {
+					select {
+					case <-running:
+					case <-ctx.Done():
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 결과 전달. Ordered이면 앞 순서의 결과가 올 때까지 모아 둡니다.
+	go func() {
+		defer close(out)
+		defer cancel()
+		if !opts.Ordered {
+			for r := range done {
+				out <- r
+			}
+			return
+		}
+
+		pending := make(map[int]Result[T])
+		next := 0
+		for r := range done {
+			pending[r.Index] = r
+			for {
+				p, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				out <- p
+				next++
+			}
+		}
+	}()
+
+	return out
+}
+
+// runOne은 장비 한 대의 Job을 실행합니다. grace가 지나 Job을 버렸으면 running은 그 Job이 돌아올 때 닫히는 채널이고,
+// 아니면 nil입니다.
+func runOne[T any](ctx context.Context, i int, host inventory.Router, job Job[T], timeout, grace time.Duration) (res Result[T], running <-chan struct{}) {
+	res = Result[T]{Index: i, Host: host, Start: time.Now()}
+
+	if err := ctx.Err(); err != nil {
+		res.Err = fmt.Errorf("not started: %w", err)
+		return res, nil
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	c := &capture{}
+	ctx = context.WithValue(ctx, captureKey{}, c)
+	defer func() {
+		res.Duration = time.Since(res.Start)
+		res.Partial = c.String()
+	}()
+
+	if grace <= 0 {
+		res.Value, res.Err = job(ctx, host)
+		return res, nil
+	}
+
+	// Job은 따로 실행하고, ctx가 끝난 뒤 grace가 지나도 돌아오지 않으면 기다리지 않습니다.
+	// 버퍼가 있으므로 늦게 돌아온 Job도 막히지 않고 끝납니다.
+	type ret struct {
+		v   T
+		err error
+	}
+	ch := make(chan ret, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		v, err := job(ctx, host)
+		ch <- ret{v, err}
+	}()
+
+	select {
+	case r := <-ch:
+		res.Value, res.Err = r.v, r.err
+	case <-ctx.Done():
+		t := time.NewTimer(grace)
+		defer t.Stop()
+		select {
+		case r := <-ch:
+			res.Value, res.Err = r.v, r.err
+		case <-t.C:
+			res.Err = fmt.Errorf("%w (waited %s): %w", ErrAbandoned, grace, ctx.Err())
+			return res, finished
+		}
+	}
+	return res, nil
+}
+
+// capture는 Job이 Capture로 남긴 출력입니다. Job이 돌아오지 않은 동안에도 읽으므로 잠급니다.
+type capture struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (c *capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.b.String()
+}
+
+type captureKey struct{}
+
+// Capture는 Job이 받은 출력을 결과의 Partial에 덧붙입니다. 명령을 여러 개 보내는 Job이 명령마다 부르면,
+// 제한 시간에 걸려 끝나지 못해도 그때까지 받은 출력이 결과에 남습니다. Run 밖에서 부르면 아무 일도 하지 않습니다.
+func Capture(ctx context.Context, output string) {
+	c, ok := ctx.Value(captureKey{}).(*capture)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.b.WriteString(output)
+}
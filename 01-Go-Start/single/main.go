@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/scrapli/scrapligo/driver/options"
-	"github.com/scrapli/scrapligo/platform"
-	"gopkg.in/yaml.v2"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
 )
 
 func timeTrack(start time.Time) {
@@ -15,80 +22,83 @@ func timeTrack(start time.Time) {
 	fmt.Printf("This process took %s\n", elapsed)
 }
 
-type Router struct {
-	Hostname  string `yaml:"hostname"`
-	Platform  string `yaml:"platform"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	StrictKey bool   `yaml:"strictkey"`
-}
-
-type Inventory struct {
-	Router []Router `yaml:"router"`
-}
-
-func getVersion(r Router) {
-	p, err := platform.NewPlatform(
-		r.Platform,
-		r.Hostname,
-		options.WithAuthNoStrictKey(),
-		options.WithAuthUsername(r.Username),
-		options.WithAuthPassword(r.Password),
-		options.WithSSHConfigFile("ssh_config"),
-	)
-
+func getVersion(ctx context.Context, r inventory.Router) (data, error) {
+	s, err := device.Open(ctx, r)
 	if err != nil {
-		fmt.Printf("failed to create platform for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
+	defer s.Close()
 
-	d, err := p.GetNetworkDriver()
+	rs, err := s.Send("show version")
 	if err != nil {
-		fmt.Printf("failed to create driver for %s: %+v\n", r.Hostname, err)
-		return
+		return data{}, err
 	}
 
-	err = d.Open()
+	// 파싱에 실패해도 원본 출력은 결과에 남깁니다.
+	out := data{host: r.Hostname, Output: runner.Output{Raw: rs.Result}}
+	out.Parsed, err = s.Parse(rs)
 	if err != nil {
-		fmt.Printf("failed to open driver for %s: %+v\n", r.Hostname, err)
-		return
+		return out, err
 	}
-	defer d.Close()
 
-	rs, err := d.SendCommand("show version")
+	out.info, err = textfsm.DecodeOne[textfsm.VersionInfo](out.Parsed)
 	if err != nil {
-		fmt.Printf("failed to send command for %s: %+v\n", r.Hostname, err)
-		return
+		return out, runner.Fail(device.StageParse, err)
 	}
+	return out, nil
+}
 
-	parsedOut, err := rs.TextFsmParse(r.Platform + "_show_version.textfsm")
-	if err != nil {
-		fmt.Printf("failed to parse command for %s: %+v\n", r.Hostname, err)
+type data struct {
+	host string
+	info textfsm.VersionInfo
+
+	runner.Output
+}
+
+func printResult(res runner.Result[data]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n\n", res.Host.Hostname, res.Err)
 		return
 	}
-
+	out := res.Value
 	fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
-		r.Hostname, parsedOut[0]["HARDWARE"],
-		parsedOut[0]["VERSION"], parsedOut[0]["UPTIME"])
-
+		out.host, strings.Join(out.info.Hardware, ", "), out.info.Version, out.info.Uptime)
 }
 
 func main() {
-	defer timeTrack(time.Now())
+	start := time.Now()
+
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "01-Go-Start/single/input.yml")
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Parse()
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	// 실패한 로그인과 명령은 -retries번까지 다시 시도합니다.
+	ctx, err := devFlags.Context(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	src, err := os.Open("01-Go-Start/single/input.yml")
+	hosts, err := invFlags.Hosts(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer src.Close()
 
-	d := yaml.NewDecoder(src)
+	// 한 번에 한 대씩 인벤토리 순서대로 실행합니다.
+	rep := runner.Collect(runner.Run(ctx, hosts, device.Retry(getVersion), runner.Options{Limit: 1, Ordered: true}), printResult)
 
-	var inv Inventory
-	err = d.Decode(&inv)
-	if err != nil {
-		panic(err)
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
+		}
 	}
 
-	getVersion(inv.Router[0])
+	timeTrack(start)
+	os.Exit(rep.ExitCode())
 }
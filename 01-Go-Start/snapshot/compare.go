@@ -0,0 +1,386 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tucker-study/01-Go-Start/facts"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 비교할 때 명령의 종류입니다. 파싱한 템플릿 이름(<platform>_<kind>.textfsm)으로 알아보고,
+// 알 수 없는 명령은 파싱한 레코드를 줄 단위로 비교합니다.
+const (
+	KindVersion    = "show_version"
+	KindInterfaces = "show_ip_interface_brief"
+	KindBGP        = "show_ip_bgp_summary"
+	KindRoutes     = "show_ip_route_summary"
+)
+
+// Finding.Check의 값입니다.
+const (
+	CheckCommand    = "command"
+	CheckVersion    = "version"
+	CheckInterfaces = "interface"
+	CheckBGP        = "bgp"
+	CheckRoutes     = "routes"
+)
+
+// Finding.Severity의 값입니다. SeverityFail이 하나라도 있으면 검증 실패입니다.
+const (
+	SeverityFail = "fail"
+	SeverityInfo = "info"
+)
+
+// Finding은 두 스냅숏 사이의 차이 하나입니다.
+type Finding struct {
+	Check    string `json:"check"`
+	Item     string `json:"item"`
+	Before   string `json:"before,omitempty"`
+	After    string `json:"after,omitempty"`
+	Severity string `json:"severity"`
+	// Message는 Before, After로 나타내기 어려운 차이의 설명입니다.
+	Message string `json:"message,omitempty"`
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%-4s %s %s: ", f.Severity, f.Check, f.Item)
+	if f.Message != "" {
+		return s + f.Message
+	}
+	before, after := f.Before, f.After
+	if before == "" {
+		before = "(none)"
+	}
+	if after == "" {
+		after = "(none)"
+	}
+	return s + before + " -> " + after
+}
+
+// Thresholds는 경로 수가 얼마나 바뀌어야 실패로 볼지 정합니다.
+// 바뀐 수가 RouteMin보다 크고 이전 값의 RoutePercent%보다 크면 실패입니다.
+// BGP 이웃에게서 받은 prefix 수에도 같은 기준을 씁니다.
+type Thresholds struct {
+	RoutePercent float64
+	RouteMin     int
+}
+
+// DefaultThresholds는 10%가 넘게 바뀐 경우를 실패로 봅니다.
+var DefaultThresholds = Thresholds{RoutePercent: 10}
+
+// exceeds는 before에서 after로 바뀐 양이 기준을 넘는지 확인합니다.
+func (th Thresholds) exceeds(before, after int) bool {
+	limit := math.Max(float64(th.RouteMin), float64(before)*th.RoutePercent/100)
+	return math.Abs(float64(after-before)) > limit
+}
+
+// BGPNeighbor는 "show ip bgp summary"의 이웃 하나입니다.
+type BGPNeighbor struct {
+	Address string `json:"address"`
+	AS      string `json:"as"`
+	// State는 세션 상태입니다. 맺어진 세션은 "Established"이고, 아니면 장비가 보여준 상태(Idle, Active, ...)입니다.
+	State       string `json:"state"`
+	Established bool   `json:"established"`
+	// Prefixes는 맺어진 세션에서 받은 prefix 수입니다.
+	Prefixes int `json:"prefixes"`
+}
+
+type bgpRow struct {
+	Neighbor string `textfsm:"BGP_NEIGH"`
+	AS       string `textfsm:"NEIGH_AS"`
+	UpDown   string `textfsm:"UP_DOWN"`
+	// StatePfx는 세션이 맺어졌으면 받은 prefix 수이고, 아니면 상태 이름입니다.
+	StatePfx string `textfsm:"STATE_PFXRCD"`
+}
+
+func (r bgpRow) normalize() BGPNeighbor {
+	n := BGPNeighbor{Address: r.Neighbor, AS: r.AS, State: r.StatePfx}
+	if pfx, err := strconv.Atoi(r.StatePfx); err == nil {
+		n.State, n.Established, n.Prefixes = "Established", true, pfx
+	}
+	return n
+}
+
+func (n BGPNeighbor) String() string {
+	if n.Established {
+		return fmt.Sprintf("Established (%d prefixes)", n.Prefixes)
+	}
+	return n.State
+}
+
+// ParseBGP는 "show ip bgp summary"를 파싱한 레코드를 BGPNeighbor로 바꿉니다.
+func ParseBGP(records []map[string]interface{}) ([]BGPNeighbor, error) {
+	rows, err := textfsm.Decode[bgpRow](records)
+	if err != nil {
+		return nil, err
+	}
+	var ns []BGPNeighbor
+	for _, r := range rows {
+		ns = append(ns, r.normalize())
+	}
+	return ns, nil
+}
+
+// kind는 명령을 파싱한 템플릿으로 명령의 종류를 알아봅니다. 모르는 명령은 ""입니다.
+func (c Command) kind() string {
+	name := strings.TrimSuffix(c.Template, ".textfsm")
+	for _, k := range []string{KindVersion, KindInterfaces, KindBGP, KindRoutes} {
+		if strings.HasSuffix(name, "_"+k) {
+			return k
+		}
+	}
+	return ""
+}
+
+// Compare는 pre와 post 스냅숏을 명령마다 비교합니다.
+//
+//   - BGP: 맺어져 있던 이웃이 없어지거나 내려가면, 받은 prefix 수가 기준보다 많이 바뀌면 실패입니다.
+//   - 인터페이스: up/up이던 인터페이스가 없어지거나 내려가면 실패입니다.
+//   - 경로: 전체나 출처별 경로 수가 기준보다 많이 바뀌면 실패입니다.
+//   - pre에서 성공한 명령이 post에서 실패하면 실패입니다.
+//
+// 새로 올라온 이웃이나 인터페이스, 버전 변경, 알 수 없는 명령의 차이는 SeverityInfo로 알려줍니다.
+func Compare(pre, post *Snapshot, th Thresholds) ([]Finding, error) {
+	// 차이가 없어도 비교했다는 것을 알 수 있게 nil이 아닌 슬라이스를 돌려줍니다.
+	findings := []Finding{}
+	for _, a := range pre.Commands {
+		b, ok := post.Command(a.Command)
+		switch {
+		case !ok:
+			findings = append(findings, Finding{Check: CheckCommand, Item: a.Command, Severity: SeverityInfo, Message: "not in " + post.Name})
+			continue
+		case a.Error != "":
+			// 비교할 기준이 없습니다.
+			continue
+		case b.Error != "":
+			findings = append(findings, Finding{Check: CheckCommand, Item: a.Command, Severity: SeverityFail, Message: b.Error})
+			continue
+		}
+
+		var fs []Finding
+		var err error
+		switch a.kind() {
+		case KindVersion:
+			fs, err = compareVersion(a, b)
+		case KindInterfaces:
+			fs, err = compareInterfaces(a, b)
+		case KindBGP:
+			fs, err = compareBGP(a, b, th)
+		case KindRoutes:
+			fs, err = compareRoutes(a, b, th)
+		default:
+			fs, err = compareRows(a, b)
+		}
+		if err != nil {
+			return findings, fmt.Errorf("%s: %w", a.Command, err)
+		}
+		findings = append(findings, fs...)
+	}
+	for _, b := range post.Commands {
+		if _, ok := pre.Command(b.Command); !ok {
+			findings = append(findings, Finding{Check: CheckCommand, Item: b.Command, Severity: SeverityInfo, Message: "not in " + pre.Name})
+		}
+	}
+	return findings, nil
+}
+
+func compareVersion(a, b Command) ([]Finding, error) {
+	va, err := textfsm.DecodeOne[textfsm.VersionInfo](a.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := textfsm.DecodeOne[textfsm.VersionInfo](b.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	if va.Version == vb.Version {
+		return nil, nil
+	}
+	return []Finding{{Check: CheckVersion, Item: "version", Before: va.Version, After: vb.Version, Severity: SeverityInfo}}, nil
+}
+
+// ifState는 인터페이스 상태를 "관리상/동작상"으로 나타냅니다.
+func ifState(i facts.Interface) string {
+	switch {
+	case !i.AdminUp:
+		return "admin-down/down"
+	case !i.OperUp:
+		return "up/down"
+	}
+	return "up/up"
+}
+
+func compareInterfaces(a, b Command) ([]Finding, error) {
+	ia, err := facts.ParseInterfaces(a.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	ib, err := facts.ParseInterfaces(b.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	after := map[string]facts.Interface{}
+	for _, i := range ib {
+		after[i.Name] = i
+	}
+
+	var findings []Finding
+	for _, i := range ia {
+		j, ok := after[i.Name]
+		delete(after, i.Name)
+		up := i.AdminUp && i.OperUp
+		switch {
+		case !ok:
+			f := Finding{Check: CheckInterfaces, Item: i.Name, Before: ifState(i), Severity: SeverityInfo, Message: "removed"}
+			if up {
+				f.Severity = SeverityFail
+			}
+			findings = append(findings, f)
+		case ifState(i) != ifState(j):
+			f := Finding{Check: CheckInterfaces, Item: i.Name, Before: ifState(i), After: ifState(j), Severity: SeverityInfo}
+			if up {
+				f.Severity = SeverityFail
+			}
+			findings = append(findings, f)
+		}
+		if ok && i.IPv4 != j.IPv4 {
+			findings = append(findings, Finding{Check: CheckInterfaces, Item: i.Name + " ipv4", Before: i.IPv4, After: j.IPv4, Severity: SeverityInfo})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(after)) {
+		findings = append(findings, Finding{Check: CheckInterfaces, Item: name, After: ifState(after[name]), Severity: SeverityInfo, Message: "added"})
+	}
+	return findings, nil
+}
+
+func compareBGP(a, b Command, th Thresholds) ([]Finding, error) {
+	na, err := ParseBGP(a.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	nb, err := ParseBGP(b.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	after := map[string]BGPNeighbor{}
+	for _, n := range nb {
+		after[n.Address] = n
+	}
+
+	var findings []Finding
+	for _, n := range na {
+		m, ok := after[n.Address]
+		delete(after, n.Address)
+		f := Finding{Check: CheckBGP, Item: n.Address, Before: n.String(), After: m.String(), Severity: SeverityInfo}
+		switch {
+		case !ok:
+			f.After, f.Message = "", "neighbor removed, was "+n.String()
+			if n.Established {
+				f.Severity = SeverityFail
+			}
+		case n.Established && !m.Established:
+			f.Severity = SeverityFail
+		case n.Established && m.Established:
+			if n.Prefixes == m.Prefixes {
+				continue
+			}
+			if th.exceeds(n.Prefixes, m.Prefixes) {
+				f.Severity = SeverityFail
+			}
+		case n.State == m.State:
+			continue
+		}
+		findings = append(findings, f)
+	}
+	for _, addr := range slices.Sorted(maps.Keys(after)) {
+		findings = append(findings, Finding{Check: CheckBGP, Item: addr, After: after[addr].String(), Severity: SeverityInfo, Message: "neighbor added, " + after[addr].String()})
+	}
+	return findings, nil
+}
+
+func compareRoutes(a, b Command, th Thresholds) ([]Finding, error) {
+	ra, err := facts.ParseRoutes(a.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := facts.ParseRoutes(b.Parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	check := func(item string, before, after int) *Finding {
+		if before == after {
+			return nil
+		}
+		f := &Finding{Check: CheckRoutes, Item: item, Before: strconv.Itoa(before), After: strconv.Itoa(after), Severity: SeverityInfo}
+		if th.exceeds(before, after) {
+			f.Severity = SeverityFail
+		}
+		return f
+	}
+	var findings []Finding
+	if f := check("total", ra.Total, rb.Total); f != nil {
+		findings = append(findings, *f)
+	}
+	sources := slices.Sorted(maps.Keys(ra.Sources))
+	for src := range rb.Sources {
+		if _, ok := ra.Sources[src]; !ok {
+			sources = append(sources, src)
+		}
+	}
+	slices.Sort(sources)
+	for _, src := range sources {
+		if f := check(src, ra.Sources[src], rb.Sources[src]); f != nil {
+			findings = append(findings, *f)
+		}
+	}
+	return findings, nil
+}
+
+// compareRows는 종류를 모르는 명령의 레코드를 줄 단위로 비교해 없어지거나 생긴 줄의 수를 알려줍니다.
+func compareRows(a, b Command) ([]Finding, error) {
+	count := func(records []map[string]interface{}) (map[string]int, error) {
+		rows := map[string]int{}
+		for _, r := range records {
+			// 키가 정렬되므로 같은 레코드는 같은 문자열이 됩니다.
+			b, err := json.Marshal(r)
+			if err != nil {
+				return nil, err
+			}
+			rows[string(b)]++
+		}
+		return rows, nil
+	}
+	ra, err := count(a.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	rb, err := count(b.Parsed)
+	if err != nil {
+		return nil, err
+	}
+	removed, added := 0, 0
+	for r, n := range ra {
+		removed += max(n-rb[r], 0)
+	}
+	for r, n := range rb {
+		added += max(n-ra[r], 0)
+	}
+	if removed == 0 && added == 0 {
+		return nil, nil
+	}
+	return []Finding{{
+		Check:    CheckCommand,
+		Item:     a.Command,
+		Before:   fmt.Sprintf("%d rows", len(a.Parsed)),
+		After:    fmt.Sprintf("%d rows", len(b.Parsed)),
+		Severity: SeverityInfo,
+		Message:  fmt.Sprintf("%d rows removed, %d added", removed, added),
+	}}, nil
+}
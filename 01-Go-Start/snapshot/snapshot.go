@@ -0,0 +1,271 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// 작업 전후에 장비마다 이름 붙인 스냅숏(show 명령의 출력과 TextFSM 파싱 결과)을 찍고,
+// 두 스냅숏을 비교해 끊어진 BGP 이웃, 내려간 인터페이스, 기준보다 많이 바뀐 경로 수를 찾는 패키지입니다.
+// 스냅숏은 <dir>/<이름>/<hostname>.json에 저장하므로 비교할 때는 장비에 접속하지 않습니다.
+//
+//	store := &snapshot.Store{Dir: snapshot.DefaultDir}
+//	snap, raw, err := snapshot.Take(s, "pre", snapshot.DefaultCommands)
+//	err = store.Save(snap)
+//	...
+//	findings, err := store.Compare("rtr1", "pre", "post", snapshot.DefaultThresholds)
+
+// StageSnapshot은 스냅숏을 저장하거나 읽다 실패한 단계이고, StageVerify는 비교에서 문제를 찾은 경우입니다.
+const (
+	StageSnapshot runner.Stage = "snapshot"
+	StageVerify   runner.Stage = "verify"
+)
+
+// DefaultDir는 스냅숏의 기본 디렉터리입니다.
+const DefaultDir = "snapshots"
+
+// DefaultCommands는 명령을 지정하지 않았을 때 찍는 명령입니다.
+var DefaultCommands = []string{
+	"show version",
+	"show ip interface brief",
+	"show ip bgp summary",
+	"show ip route summary",
+}
+
+// Snapshot은 장비 한 대에서 한 번 찍은 스냅숏입니다.
+type Snapshot struct {
+	Name     string    `json:"name"`
+	Host     string    `json:"host"`
+	Platform string    `json:"platform"`
+	Taken    time.Time `json:"taken"`
+	Commands []Command `json:"commands"`
+}
+
+// Command는 스냅숏의 명령 하나입니다. 실패한 명령은 Error에 이유가 남습니다.
+type Command struct {
+	Command string `json:"command"`
+	// Template은 파싱한 TextFSM 템플릿입니다. 비교할 때 명령의 종류를 알아보는 데 씁니다.
+	Template string                   `json:"template,omitempty"`
+	Raw      string                   `json:"raw,omitempty"`
+	Parsed   []map[string]interface{} `json:"parsed,omitempty"`
+	Error    string                   `json:"error,omitempty"`
+}
+
+// Command는 이름이 cmd인 명령을 찾습니다.
+func (s *Snapshot) Command(cmd string) (Command, bool) {
+	cmd = strings.Join(strings.Fields(cmd), " ")
+	for _, c := range s.Commands {
+		if c.Command == cmd {
+			return c, true
+		}
+	}
+	return Command{}, false
+}
+
+// Take는 열린 세션에서 commands를 보내 스냅숏을 찍습니다. 일부 명령이 실패해도 나머지를 찍고 이유를 Command.Error에 남깁니다.
+// 레코드가 없는 출력(이웃이 없는 BGP 등)도 정상입니다. 모든 명령이 실패하면 첫 번째 에러를 돌려줍니다.
+// raw에는 명령마다 받은 출력이 남습니다.
+func Take(s *device.Session, name string, commands []string) (*Snapshot, string, error) {
+	snap := &Snapshot{Name: name, Host: s.Host.Hostname, Platform: s.Host.Platform, Taken: time.Now().UTC()}
+	ix, err := textfsm.Default()
+	if err != nil {
+		return nil, "", runner.Fail(device.StageParse, err)
+	}
+
+	var first error
+	var b strings.Builder
+	for _, cmd := range commands {
+		c := Command{Command: strings.Join(strings.Fields(cmd), " ")}
+		err := take(s, ix, &c)
+		if c.Raw != "" {
+			b.WriteString("### " + c.Command + "\n" + c.Raw + "\n")
+		}
+		if err != nil {
+			c.Error = err.Error()
+			if first == nil {
+				first = err
+			}
+		}
+		snap.Commands = append(snap.Commands, c)
+	}
+	if first != nil && !anyOK(snap.Commands) {
+		return snap, b.String(), first
+	}
+	return snap, b.String(), nil
+}
+
+// anyOK는 성공한 명령이 하나라도 있는지 확인합니다.
+func anyOK(cmds []Command) bool {
+	for _, c := range cmds {
+		if c.Error == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func take(s *device.Session, ix *textfsm.Index, c *Command) error {
+	rs, err := s.Send(c.Command)
+	if rs != nil {
+		c.Raw = rs.Result
+	}
+	if err != nil {
+		return err
+	}
+	if c.Template, err = ix.Template(s.Host.Platform, c.Command); err != nil {
+		return runner.Fail(device.StageParse, err)
+	}
+	if c.Parsed, err = ix.Parse(s.Host.Platform, c.Command, rs.Result); err != nil {
+		return runner.Fail(device.StageParse, err)
+	}
+	return nil
+}
+
+// Store는 스냅숏을 <Dir>/<이름>/<hostname>.json에 저장합니다.
+type Store struct {
+	Dir string
+}
+
+// checkName은 스냅숏 이름이 Dir 밖이나 다른 디렉터리를 가리키지 않는지 확인합니다.
+func checkName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("bad snapshot name %q", name)
+	}
+	return nil
+}
+
+func (st *Store) path(name, host string) string {
+	return filepath.Join(st.Dir, name, host+".json")
+}
+
+// Save는 snap을 저장합니다. 같은 이름으로 다시 찍으면 덮어씁니다.
+func (st *Store) Save(snap *Snapshot) error {
+	if err := checkName(snap.Name); err != nil {
+		return err
+	}
+	p := st.path(snap.Name, snap.Host)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+// Load는 host의 name 스냅숏을 읽습니다. 없으면 fs.ErrNotExist를 감싼 에러입니다.
+func (st *Store) Load(name, host string) (*Snapshot, error) {
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(st.path(name, host))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("%s: %w", st.path(name, host), err)
+	}
+	return &snap, nil
+}
+
+// Compare는 host의 pre, post 스냅숏을 읽어 비교합니다.
+func (st *Store) Compare(host, pre, post string, th Thresholds) ([]Finding, error) {
+	a, err := st.Load(pre, host)
+	if err != nil {
+		return nil, err
+	}
+	b, err := st.Load(post, host)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(a, b, th)
+}
+
+// Result는 장비 한 대의 결과입니다. 찍었으면 Snapshot이, 비교했으면 Findings가 있습니다.
+type Result struct {
+	Snapshot *Snapshot
+	Findings []Finding
+
+	runner.Output
+}
+
+// TakeJob은 장비마다 name 스냅숏을 찍어 store에 저장하는 Job을 만듭니다.
+// compare가 비어 있지 않으면 저장한 뒤 compare 스냅숏과 비교합니다. (CompareJob과 같음)
+// 모든 명령이 실패하면 ctx의 재시도 정책(device.WithRetry)에 따라 다시 찍습니다.
+func TakeJob(store *Store, name string, commands []string, compare string, th Thresholds) runner.Job[Result] {
+	return device.Retry(func(ctx context.Context, r inventory.Router) (Result, error) {
+		var res Result
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return res, err
+		}
+		defer s.Close()
+
+		if res.Snapshot, res.Raw, err = Take(s, name, commands); err != nil {
+			return res, err
+		}
+		if err := store.Save(res.Snapshot); err != nil {
+			return res, runner.Fail(StageSnapshot, err)
+		}
+		if compare == "" {
+			return res, nil
+		}
+		pre, err := store.Load(compare, r.Hostname)
+		if err != nil {
+			return res, runner.Fail(StageSnapshot, err)
+		}
+		res.Findings, err = Compare(pre, res.Snapshot, th)
+		return res, verify(res.Findings, err)
+	})
+}
+
+// CompareJob은 장비마다 저장된 pre, post 스냅숏을 비교하는 Job을 만듭니다. 장비에는 접속하지 않습니다.
+// 실패로 볼 차이(SeverityFail)가 있으면 StageVerify 에러입니다.
+func CompareJob(store *Store, pre, post string, th Thresholds) runner.Job[Result] {
+	return func(ctx context.Context, r inventory.Router) (Result, error) {
+		var res Result
+		a, err := store.Load(pre, r.Hostname)
+		if err != nil {
+			return res, runner.Fail(StageSnapshot, err)
+		}
+		b, err := store.Load(post, r.Hostname)
+		if err != nil {
+			return res, runner.Fail(StageSnapshot, err)
+		}
+		res.Findings, err = Compare(a, b, th)
+		return res, verify(res.Findings, err)
+	}
+}
+
+// verify는 실패로 볼 차이가 있으면 StageVerify 에러를 돌려줍니다.
+func verify(findings []Finding, err error) error {
+	if err != nil {
+		return runner.Fail(StageVerify, err)
+	}
+	n := 0
+	for _, f := range findings {
+		if f.Severity == SeverityFail {
+			n++
+		}
+	}
+	if n > 0 {
+		return runner.Fail(StageVerify, fmt.Errorf("%d of %d checks failed", n, len(findings)))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/snapshot"
+)
+
+// response는 가짜 장비의 기본 응답을 읽어 old를 new로 바꿉니다. (old, new, old, new, ...)
+func response(t *testing.T, command string, replace ...string) string {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("..", "fakedevice", "responses", "cisco_iosxe", strings.ReplaceAll(command, " ", "_")+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	for i := 0; i < len(replace); i += 2 {
+		if !strings.Contains(out, replace[i]) {
+			t.Fatalf("%s: no %q", command, replace[i])
+		}
+		out = strings.Replace(out, replace[i], replace[i+1], 1)
+	}
+	return out
+}
+
+// start는 rtr1을 띄웁니다. responses가 있으면 기본 응답 대신 씁니다.
+func start(t *testing.T, responses map[string]string) *fakedevice.Server {
+	t.Helper()
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "rtr1", Platform: "cisco_iosxe", Responses: responses})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+func TestTakeCompare(t *testing.T) {
+	store := &snapshot.Store{Dir: t.TempDir()}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pre := start(t, nil)
+	res, err := snapshot.TakeJob(store, "pre", snapshot.DefaultCommands, "", snapshot.DefaultThresholds)(ctx, pre.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Snapshot.Commands) != len(snapshot.DefaultCommands) || !strings.Contains(res.Raw, "### show ip bgp summary\n") {
+		t.Fatalf("pre snapshot: %+v", res.Snapshot.Commands)
+	}
+	saved, err := store.Load("pre", "rtr1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := saved.Command("show  ip bgp summary"); !ok || len(c.Parsed) != 3 || c.Template != "cisco_ios_show_ip_bgp_summary.textfsm" {
+		t.Errorf("saved bgp summary: %+v", c)
+	}
+
+	// 작업 뒤: 10.0.0.2 세션과 GigabitEthernet1이 내려가고, BGP 경로가 없어졌습니다.
+	post := start(t, map[string]string{
+		"show ip bgp summary": response(t, "show ip bgp summary",
+			"01:23:45        3", "00:00:12 Idle",
+			"never    Idle", "00:00:30        5",
+			"(Admin)", "(Admin)\n10.0.0.14       4        65005       0       0        1    0    0 00:00:05 Active"),
+		"show ip interface brief": response(t, "show ip interface brief",
+			"NVRAM  up                    up", "NVRAM  up                    down",
+			"administratively down down", "up                    up   ",
+			"Loopback0              10.255.0.1", "Loopback0              10.255.0.9"),
+		"show ip route summary": response(t, "show ip route summary",
+			"bgp 65001       0           3", "bgp 65001       0           0",
+			"Total           1           11", "Total           1           8"),
+	})
+	res, err = snapshot.TakeJob(store, "post", snapshot.DefaultCommands, "pre", snapshot.DefaultThresholds)(ctx, post.Router())
+	if runner.StageOf(err) != snapshot.StageVerify || !strings.Contains(err.Error(), "4 of 8 checks failed") {
+		t.Errorf("TakeJob err = %v, want 4 of 8 checks failed", err)
+	}
+
+	want := []string{
+		"fail interface GigabitEthernet1: up/up -> up/down",
+		"info interface GigabitEthernet2: admin-down/down -> up/up",
+		"info interface Loopback0 ipv4: 10.255.0.1 -> 10.255.0.9",
+		"fail bgp 10.0.0.2: Established (3 prefixes) -> Idle",
+		"info bgp 10.0.0.6: Idle -> Established (5 prefixes)",
+		"info bgp 10.0.0.14: neighbor added, Active",
+		"fail routes total: 12 -> 9",
+		"fail routes bgp: 3 -> 0",
+	}
+	var got []string
+	for _, f := range res.Findings {
+		got = append(got, strings.Join(strings.Fields(f.String()), " "))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 저장한 스냅숏만으로 같은 결과를 냅니다.
+	post.Close()
+	cres, err := snapshot.CompareJob(store, "pre", "post", snapshot.DefaultThresholds)(ctx, post.Router())
+	if runner.StageOf(err) != snapshot.StageVerify || !reflect.DeepEqual(cres.Findings, res.Findings) {
+		t.Errorf("CompareJob = %v, %v", cres.Findings, err)
+	}
+	// 기준을 넉넉하게 잡으면 경로 수 변화는 실패가 아닙니다.
+	findings, err := store.Compare("rtr1", "pre", "post", snapshot.Thresholds{RoutePercent: 100, RouteMin: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings {
+		if f.Check == snapshot.CheckRoutes && f.Severity == snapshot.SeverityFail {
+			t.Errorf("route finding over a loose threshold: %s", f)
+		}
+	}
+	if _, err := store.Compare("rtr1", "pre", "missing", snapshot.DefaultThresholds); !os.IsNotExist(err) {
+		t.Errorf("Compare with a missing snapshot = %v", err)
+	}
+}
+
+func TestTakePartial(t *testing.T) {
+	srv := start(t, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s, err := device.Open(ctx, srv.Router())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// 장비가 거부한 명령과 템플릿이 없는 명령은 이유를 남기고 나머지는 찍습니다.
+	snap, _, err := snapshot.Take(s, "pre", []string{"show version", "show bogus", "show running-config"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := map[string]string{}
+	for _, c := range snap.Commands {
+		errs[c.Command] = c.Error
+	}
+	if errs["show version"] != "" || errs["show bogus"] == "" || !strings.Contains(errs["show running-config"], "no template") {
+		t.Errorf("command errors = %q", errs)
+	}
+
+	// 모든 명령이 실패하면 에러입니다.
+	if _, _, err := snapshot.Take(s, "pre", []string{"show bogus"}); err == nil {
+		t.Error("Take with only failing commands succeeded")
+	}
+
+	store := &snapshot.Store{Dir: t.TempDir()}
+	for _, name := range []string{"", "..", "a/b"} {
+		snap.Name = name
+		if err := store.Save(snap); err == nil {
+			t.Errorf("Save accepted snapshot name %q", name)
+		}
+	}
+}
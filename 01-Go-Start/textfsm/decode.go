@@ -0,0 +1,113 @@
+package textfsm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// VersionInfo는 "show version"의 결과입니다. 내장 템플릿은 플랫폼이 달라도 같은 열 이름을 씁니다.
+// 장비가 알려주지 않는 값(예: arista_eos의 Hostname)은 비어 있습니다.
+type VersionInfo struct {
+	Hostname string   `textfsm:"HOSTNAME" json:"hostname,omitempty"`
+	Version  string   `textfsm:"VERSION" json:"version"`
+	Hardware []string `textfsm:"HARDWARE" json:"hardware,omitempty"`
+	Serial   []string `textfsm:"SERIAL" json:"serial,omitempty"`
+	Uptime   string   `textfsm:"UPTIME" json:"uptime,omitempty"`
+}
+
+// Decode는 파싱한 레코드들을 T의 슬라이스로 바꿉니다.
+// T의 필드는 `textfsm:"NAME"` 태그의 열과 이어지고, 레코드에 없는 열은 빈 값으로 둡니다.
+// 필드는 string, []string, 정수형만 됩니다. List 열을 string 필드에 넣으면 ", "로 이어 붙입니다.
+func Decode[T any](records []map[string]interface{}) ([]T, error) {
+	out := make([]T, len(records))
+	for i, rec := range records {
+		if err := decode(rec, reflect.ValueOf(&out[i]).Elem()); err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// DecodeOne은 첫 번째 레코드를 T로 바꿉니다. 레코드가 없으면 에러입니다.
+func DecodeOne[T any](records []map[string]interface{}) (T, error) {
+	var zero T
+	if len(records) == 0 {
+		return zero, errors.New("no records")
+	}
+	out, err := Decode[T](records[:1])
+	if err != nil {
+		return zero, err
+	}
+	return out[0], nil
+}
+
+func decode(rec map[string]interface{}, v reflect.Value) error {
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode into %s", v.Type())
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		col := t.Field(i).Tag.Get("textfsm")
+		if col == "" {
+			continue
+		}
+		val, ok := rec[col]
+		if !ok {
+			continue
+		}
+		if err := set(v.Field(i), val); err != nil {
+			return fmt.Errorf("%s: %w", col, err)
+		}
+	}
+	return nil
+}
+
+func set(f reflect.Value, val interface{}) error {
+	var list []string
+	switch val := val.(type) {
+	case string:
+		if val != "" {
+			list = []string{val}
+		}
+	case []string:
+		list = val
+	case []interface{}:
+		for _, s := range val {
+			list = append(list, fmt.Sprint(s))
+		}
+	default:
+		return fmt.Errorf("unexpected value %T", val)
+	}
+	s := strings.Join(list, ", ")
+
+	switch {
+	case f.Kind() == reflect.String:
+		f.SetString(s)
+	case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+		f.Set(reflect.ValueOf(list).Convert(f.Type()))
+	case f.CanInt():
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case f.CanUint():
+		if s == "" {
+			return nil
+		}
+		n, err := strconv.ParseUint(s, 10, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
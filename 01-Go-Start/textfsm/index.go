@@ -0,0 +1,230 @@
+package textfsm
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirikothe/gotextfsm"
+)
+
+// 명령 출력을 TextFSM 템플릿으로 파싱하는 패키지입니다.
+// ntc-templates처럼 templates/index에서 플랫폼과 명령으로 템플릿을 찾으므로 템플릿 파일 이름을 몰라도 됩니다.
+// 템플릿은 바이너리에 들어 있고(go:embed), 덮어쓰기 디렉터리를 주면 그쪽을 먼저 찾습니다.
+//
+//	덮어쓰기 디렉터리/
+//	  index                              # 있으면 이 인덱스의 줄이 내장 인덱스보다 먼저 맞춰집니다
+//	  cisco_iosxe_show_version.textfsm   # 같은 이름의 내장 템플릿 대신 쓰입니다
+
+//go:embed templates
+var embedded embed.FS
+
+// IndexFile은 템플릿 디렉터리 안의 인덱스 파일 이름입니다.
+const IndexFile = "index"
+
+// EnvDirs는 Default가 덮어쓰기 디렉터리로 쓰는 환경 변수입니다. (ntc-templates와 같은 이름, 여러 개는 ':'로 구분)
+const EnvDirs = "NET_TEXTFSM"
+
+// ErrNoTemplate은 플랫폼과 명령에 맞는 템플릿이 인덱스에 없는 경우입니다.
+var ErrNoTemplate = errors.New("no template")
+
+type entry struct {
+	template string
+	platform *regexp.Regexp
+	command  *regexp.Regexp
+}
+
+// Index는 플랫폼과 명령으로 템플릿을 찾는 인덱스입니다.
+type Index struct {
+	// sources는 템플릿 파일을 찾는 곳입니다. 덮어쓰기 디렉터리들 다음에 내장 템플릿입니다.
+	sources []fs.FS
+	entries []entry
+}
+
+// New는 dirs의 index와 템플릿을 내장 템플릿보다 먼저 쓰는 Index를 만듭니다.
+// index가 없는 디렉터리는 같은 이름의 템플릿을 덮어쓰기만 합니다.
+func New(dirs ...string) (*Index, error) {
+	builtin, err := fs.Sub(embedded, "templates")
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &Index{}
+	for _, dir := range dirs {
+		if fi, err := os.Stat(dir); err != nil {
+			return nil, err
+		} else if !fi.IsDir() {
+			return nil, fmt.Errorf("%s: not a directory", dir)
+		}
+		ix.sources = append(ix.sources, os.DirFS(dir))
+	}
+	ix.sources = append(ix.sources, builtin)
+
+	for i, src := range ix.sources {
+		b, err := fs.ReadFile(src, IndexFile)
+		if errors.Is(err, fs.ErrNotExist) && i < len(dirs) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := "builtin index"
+		if i < len(dirs) {
+			name = filepath.Join(dirs[i], IndexFile)
+		}
+		entries, err := parseIndex(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		ix.entries = append(ix.entries, entries...)
+	}
+	return ix, nil
+}
+
+// Default는 내장 템플릿과 NET_TEXTFSM의 디렉터리들로 만든 Index입니다. 처음 한 번만 읽습니다.
+var Default = sync.OnceValues(func() (*Index, error) {
+	var dirs []string
+	for _, d := range filepath.SplitList(os.Getenv(EnvDirs)) {
+		if d != "" {
+			dirs = append(dirs, d)
+		}
+	}
+	return New(dirs...)
+})
+
+// parseIndex는 "Template, Hostname, Platform, Command" 형식의 인덱스를 읽습니다.
+// 첫 줄(#과 빈 줄 제외)은 열 이름이고, Hostname 열은 쓰지 않습니다.
+func parseIndex(b []byte) ([]entry, error) {
+	var (
+		entries []entry
+		cols    map[string]int
+	)
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := splitIndexLine(line)
+
+		if cols == nil {
+			cols = map[string]int{}
+			for i, f := range fields {
+				cols[f] = i
+			}
+			for _, c := range []string{"Template", "Platform", "Command"} {
+				if _, ok := cols[c]; !ok {
+					return nil, fmt.Errorf("line %d: missing column %s", n, c)
+				}
+			}
+			continue
+		}
+
+		if len(fields) != len(cols) {
+			return nil, fmt.Errorf("line %d: want %d columns, got %d", n, len(cols), len(fields))
+		}
+		e := entry{template: fields[cols["Template"]]}
+		if strings.Contains(e.template, ":") {
+			return nil, fmt.Errorf("line %d: multiple templates are not supported", n)
+		}
+		var err error
+		if e.platform, err = regexp.Compile("^(?:" + fields[cols["Platform"]] + ")$"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if e.command, err = regexp.Compile("^(?:" + expandCommand(fields[cols["Command"]]) + ")$"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+func splitIndexLine(line string) []string {
+	fields := strings.Split(line, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+var completion = regexp.MustCompile(`\[\[(.+?)\]\]`)
+
+// expandCommand는 ntc-templates의 줄임말 표기를 정규식으로 바꿉니다. ("sh[[ow]]" → "sh(o(w)?)?")
+func expandCommand(cmd string) string {
+	return completion.ReplaceAllStringFunc(cmd, func(m string) string {
+		rest := m[2 : len(m)-2]
+		var b strings.Builder
+		for _, c := range rest {
+			b.WriteString("(?:" + regexp.QuoteMeta(string(c)))
+		}
+		b.WriteString(strings.Repeat(")?", len([]rune(rest))))
+		return b.String()
+	})
+}
+
+// Template은 플랫폼과 명령에 맞는 템플릿 이름을 찾습니다. 명령의 공백 차이는 무시합니다.
+func (ix *Index) Template(platform, command string) (string, error) {
+	command = strings.Join(strings.Fields(command), " ")
+	for _, e := range ix.entries {
+		if e.platform.MatchString(platform) && e.command.MatchString(command) {
+			return e.template, nil
+		}
+	}
+	return "", fmt.Errorf("%w for %s %q", ErrNoTemplate, platform, command)
+}
+
+// read는 덮어쓰기 디렉터리부터 차례로 템플릿 파일을 찾아 읽습니다.
+func (ix *Index) read(name string) ([]byte, error) {
+	for _, src := range ix.sources {
+		b, err := fs.ReadFile(src, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return b, err
+	}
+	return nil, fmt.Errorf("template %s: %w", name, fs.ErrNotExist)
+}
+
+// Parse는 플랫폼과 명령에 맞는 템플릿으로 output을 파싱합니다.
+// 맞는 템플릿이 없으면 ErrNoTemplate입니다.
+func (ix *Index) Parse(platform, command, output string) ([]map[string]interface{}, error) {
+	name, err := ix.Template(platform, command)
+	if err != nil {
+		return nil, err
+	}
+	b, err := ix.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return parse(name, string(b), output)
+}
+
+func parse(name, template, output string) ([]map[string]interface{}, error) {
+	fsm := gotextfsm.TextFSM{}
+	if err := fsm.ParseString(template); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p := gotextfsm.ParserOutput{}
+	if err := p.ParseTextString(output, fsm, true); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return p.Dict, nil
+}
+
+// Parse는 Default 인덱스로 output을 파싱합니다.
+func Parse(platform, command, output string) ([]map[string]interface{}, error) {
+	ix, err := Default()
+	if err != nil {
+		return nil, err
+	}
+	return ix.Parse(platform, command, output)
+}
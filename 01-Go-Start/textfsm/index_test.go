@@ -0,0 +1,204 @@
+package textfsm_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// response는 가짜 장비가 platform에서 command에 돌려주는 출력입니다.
+func response(t *testing.T, platform, command string) string {
+	t.Helper()
+	name := strings.ReplaceAll(command, " ", "_") + ".txt"
+	b, err := os.ReadFile(filepath.Join("..", "fakedevice", "responses", platform, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.ReplaceAll(string(b), "{{ .Hostname }}", "rtr1")
+}
+
+// writeFiles는 name: 내용 목록으로 디렉터리를 만듭니다.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestBuiltin(t *testing.T) {
+	// 가짜 장비의 모든 show 명령 출력에 내장 템플릿이 있고, 레코드가 나와야 합니다.
+	files, err := filepath.Glob(filepath.Join("..", "fakedevice", "responses", "*", "show_*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no responses: %v", err)
+	}
+	for _, f := range files {
+		platform := filepath.Base(filepath.Dir(f))
+		command := strings.ReplaceAll(strings.TrimSuffix(filepath.Base(f), ".txt"), "_", " ")
+		records, err := textfsm.Parse(platform, command, response(t, platform, command))
+		if err != nil {
+			t.Errorf("%s %s: %v", platform, command, err)
+		} else if len(records) == 0 {
+			t.Errorf("%s %s: no records", platform, command)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	// 플랫폼이 달라도 같은 VersionInfo로 읽힙니다.
+	tests := []struct {
+		platform string
+		want     textfsm.VersionInfo
+	}{
+		{"cisco_iosxe", textfsm.VersionInfo{
+			Hostname: "rtr1", Version: "17.9.1a", Hardware: []string{"C8000V"}, Serial: []string{"9ABCDEFGHIJ"},
+			Uptime: "1 week, 2 days, 3 hours, 4 minutes",
+		}},
+		{"cisco_nxos", textfsm.VersionInfo{
+			Hostname: "rtr1", Version: "9.3(8)", Hardware: []string{"Nexus9000 C9300v"}, Serial: []string{"9N3KD63KWT0"},
+			Uptime: "2 day(s), 3 hour(s), 4 minute(s), 5 second(s)",
+		}},
+		{"arista_eos", textfsm.VersionInfo{
+			Version: "4.30.1F", Hardware: []string{"vEOS-lab"}, Serial: []string{"5D4E1F2A3B4C"},
+			Uptime: "2 days, 3 hours and 4 minutes",
+		}},
+	}
+	for _, tt := range tests {
+		records, err := textfsm.Parse(tt.platform, "sh ver", response(t, tt.platform, "show version"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := textfsm.DecodeOne[textfsm.VersionInfo](records)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %+v, want %+v", tt.platform, got, tt.want)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	ix, err := textfsm.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		platform, command, want string
+	}{
+		{"cisco_iosxe", "show version", "cisco_iosxe_show_version.textfsm"},
+		{"cisco_iosxe", "sh ver", "cisco_iosxe_show_version.textfsm"},
+		{"cisco_iosxe", "  show   ip int   brief ", "cisco_iosxe_show_ip_interface_brief.textfsm"},
+		{"cisco_nxos", "show bgp ipv4 unicast summary", "cisco_ios_show_ip_bgp_summary.textfsm"},
+		{"arista_eos", "sho ver", "arista_eos_show_version.textfsm"},
+		{"cisco_iosxe", "show versions", ""},
+		{"cisco_iosxe", "s ver", ""},
+		{"cisco_ios", "show version", ""},
+		{"arista_eos", "show cdp neighbors detail", ""},
+	}
+	for _, tt := range tests {
+		got, err := ix.Template(tt.platform, tt.command)
+		if tt.want == "" {
+			if !errors.Is(err, textfsm.ErrNoTemplate) {
+				t.Errorf("Template(%s, %q) = %q, %v; want ErrNoTemplate", tt.platform, tt.command, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Template(%s, %q) = %q, %v; want %s", tt.platform, tt.command, got, err, tt.want)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	// 이름이 같은 템플릿은 내장 템플릿 대신 쓰고, index의 줄은 내장 인덱스보다 먼저 맞춥니다.
+	replace := writeFiles(t, map[string]string{
+		"cisco_iosxe_show_version.textfsm": "Value IMAGE (\\S+)\n\nStart\n  ^System image file is \"${IMAGE}\" -> Record\n",
+	})
+	extra := writeFiles(t, map[string]string{
+		textfsm.IndexFile: "Template, Hostname, Platform, Command\nclock.textfsm, .*, cisco_.*, sh[[ow]] clo[[ck]]\n",
+		"clock.textfsm":   "Value TIME (\\S+)\n\nStart\n  ^\\*?${TIME}\\s -> Record\n",
+	})
+	ix, err := textfsm.New(replace, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ix.Parse("cisco_iosxe", "show version", response(t, "cisco_iosxe", "show version"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []map[string]interface{}{{"IMAGE": "bootflash:packages.conf"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("overridden show version = %v, want %v", records, want)
+	}
+	records, err = ix.Parse("cisco_nxos", "sh clock", "*10:15:03.123 UTC Mon Oct 16 2023\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []map[string]interface{}{{"TIME": "10:15:03.123"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("show clock = %v, want %v", records, want)
+	}
+	// 덮어쓰지 않은 템플릿은 그대로 내장 템플릿입니다.
+	if _, err := ix.Template("cisco_iosxe", "show inventory"); err != nil {
+		t.Error(err)
+	}
+
+	for _, tt := range []struct {
+		index, want string
+	}{
+		{"Template, Platform\n", "missing column Command"},
+		{"Template, Hostname, Platform, Command\na.textfsm:b.textfsm, .*, x, show x\n", "multiple templates"},
+		{"Template, Hostname, Platform, Command\na.textfsm, .*, x\n", "want 4 columns"},
+		{"Template, Hostname, Platform, Command\na.textfsm, .*, (x, show x\n", "line 2"},
+	} {
+		dir := writeFiles(t, map[string]string{textfsm.IndexFile: tt.index})
+		if _, err := textfsm.New(dir); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("index %q: err = %v, want %q", tt.index, err, tt.want)
+		}
+	}
+	if _, err := textfsm.New(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("New accepted a missing directory")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type row struct {
+		Name  string   `textfsm:"NAME"`
+		Addrs []string `textfsm:"ADDRS"`
+		All   string   `textfsm:"ADDRS"`
+		MTU   int      `textfsm:"MTU"`
+		Peers uint16   `textfsm:"PEERS"`
+		Skip  string
+	}
+	records := []map[string]interface{}{
+		{"NAME": "Gi1", "ADDRS": []string{"10.0.0.1", "10.0.0.2"}, "MTU": "1500", "PEERS": "3"},
+		// 없는 열과 빈 숫자는 빈 값입니다.
+		{"NAME": "Gi2", "ADDRS": []interface{}{}, "MTU": ""},
+	}
+	got, err := textfsm.Decode[row](records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []row{
+		{Name: "Gi1", Addrs: []string{"10.0.0.1", "10.0.0.2"}, All: "10.0.0.1, 10.0.0.2", MTU: 1500, Peers: 3},
+		{Name: "Gi2", Addrs: nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode = %+v, want %+v", got, want)
+	}
+
+	if _, err := textfsm.Decode[row]([]map[string]interface{}{{}, {"MTU": "jumbo"}}); err == nil || !strings.Contains(err.Error(), "record 1: MTU") {
+		t.Errorf("bad number: err = %v", err)
+	}
+	if _, err := textfsm.DecodeOne[row](nil); err == nil {
+		t.Error("DecodeOne of no records succeeded")
+	}
+}
@@ -1,113 +1,150 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/scrapli/scrapligo/driver/options"
-	"github.com/scrapli/scrapligo/platform"
-	"gopkg.in/yaml.v2"
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
 )
 
-type Router struct {
-	Hostname  string `yaml:"hostname"`
-	Platform  string `yaml:"platform"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	StrictKey bool   `yaml:"strictkey"`
-}
+// 모든 장비에 동시에 show version을 보내고, 전체 제한 시간(-deadline, 기본 5초)과
+// 장비별 제한 시간(-timeout) 안에 끝난 장비와 끝나지 못한 장비를 나눠 보여주는 예제입니다.
+//
+//	$ timer -deadline 3s -timeout 2s -command "show ip interface brief"
+//
+// 제한 시간이 지나면 실행 중인 세션을 닫고, -grace 안에 돌아오지 않는 장비는 기다리지 않습니다.
+// 끝나지 못한 장비도 그때까지 받은 명령 출력은 결과(-report의 partial)에 남습니다.
+
+// listValue는 여러 번 줄 수 있는 문자열 플래그입니다.
+type listValue []string
 
-type Inventory struct {
-	Routers []Router `yaml:"router"`
+func (v *listValue) String() string {
+	return strings.Join(*v, ", ")
 }
 
-func getVersion(r Router, out chan data) {
-	p, err := platform.NewPlatform(
-		r.Platform,
-		r.Hostname,
-		options.WithAuthNoStrictKey(),
-		options.WithAuthUsername(r.Username),
-		options.WithAuthPassword(r.Password),
-		options.WithSSHConfigFile("ssh_config"),
-	)
+func (v *listValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
 
-	if err != nil {
-		fmt.Printf("failed to create platform for %s: %+v\n", r.Hostname, err)
-		return
-	}
+// getVersion은 show version을 보내고 이어서 commands를 보내는 Job을 만듭니다.
+// 받은 출력은 명령마다 runner.Capture로 남기므로 중간에 제한 시간에 걸려도 결과에 남습니다.
+func getVersion(commands []string) runner.Job[data] {
+	return func(ctx context.Context, r inventory.Router) (data, error) {
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return data{}, err
+		}
+		defer s.Close()
 
-	d, err := p.GetNetworkDriver()
-	if err != nil {
-		fmt.Printf("failed to create driver for %s: %+v\n", r.Hostname, err)
-		return
-	}
+		rs, err := s.Send("show version")
+		if err != nil {
+			return data{}, err
+		}
+		runner.Capture(ctx, "### show version\n"+rs.Result+"\n")
 
-	err = d.Open()
-	if err != nil {
-		fmt.Printf("failed to open driver for %s: %+v\n", r.Hostname, err)
-		return
-	}
-	defer d.Close()
+		// 파싱에 실패해도 원본 출력은 결과에 남깁니다.
+		out := data{host: r.Hostname, Output: runner.Output{Raw: rs.Result}}
+		out.Parsed, err = s.Parse(rs)
+		if err != nil {
+			return out, err
+		}
 
-	rs, err := d.SendCommand("show version")
-	if err != nil {
-		fmt.Printf("failed to send command for %s: %+v\n", r.Hostname, err)
-		return
-	}
+		out.info, err = textfsm.DecodeOne[textfsm.VersionInfo](out.Parsed)
+		if err != nil {
+			return out, runner.Fail(device.StageParse, err)
+		}
 
-	parsedOut, err := rs.TextFsmParse(r.Platform + "_show_version.textfsm")
-	if err != nil {
-		fmt.Printf("failed to parse command for %s: %+v\n", r.Hostname, err)
-		return
+		for _, cmd := range commands {
+			rs, err := s.Send(cmd)
+			if err != nil {
+				return out, err
+			}
+			runner.Capture(ctx, "### "+cmd+"\n"+rs.Result+"\n")
+			out.extra = append(out.extra, rs.Result)
+		}
+		return out, nil
 	}
+}
 
-	out <- data{
-		host:    r.Hostname,
-		hw:      fmt.Sprintf("%s", parsedOut[0]["HARDWARE"]),
-		version: fmt.Sprintf("%s", parsedOut[0]["VERSION"]),
-		uptime:  fmt.Sprintf("%s", parsedOut[0]["UPTIME"]),
-	}
+type data struct {
+	host  string
+	info  textfsm.VersionInfo
+	extra []string
 
+	runner.Output
 }
 
-type data struct {
-	host    string
-	hw      string
-	version string
-	uptime  string
+func printResult(res runner.Result[data]) {
+	if res.Err != nil {
+		fmt.Printf("%s: %+v\n\n", res.Host.Hostname, res.Err)
+		return
+	}
+	out := res.Value
+	fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n",
+		out.host, strings.Join(out.info.Hardware, ", "), out.info.Version, out.info.Uptime)
+	for _, e := range out.extra {
+		fmt.Println(e)
+	}
+	fmt.Println()
 }
 
 func main() {
-	src, err := os.Open("input.yml")
+	var invFlags inventory.Flags
+	invFlags.Register(flag.CommandLine, "input.yml")
+	// 이 예제는 기본으로 전체 5초 안에 끝냅니다.
+	runOpts := runner.Options{Deadline: 5 * time.Second}
+	runOpts.Register(flag.CommandLine)
+	var devFlags device.Flags
+	devFlags.Register(flag.CommandLine)
+	// 이 예제의 Job은 장비를 읽기만 하므로 제한 시간이 지나도 돌아오지 않는 장비는 -grace만큼만 기다립니다.
+	flag.DurationVar(&runOpts.Grace, "grace", 5*time.Second, "how long to wait for a timed-out host to stop before giving up on it (0 = wait)")
+	var commands listValue
+	flag.Var(&commands, "command", "command to send after show version (repeatable)")
+	reportPath := flag.String("report", "", "write per-host results to a .json or .csv file")
+	flag.Parse()
+
+	// Ctrl+C를 누르면 ctx가 끝나 아직 시작하지 않은 장비를 건너뛰고 열린 세션을 닫습니다.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	// 실패한 로그인과 명령은 -retries번까지 다시 시도합니다.
+	ctx, err := devFlags.Context(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
-	defer src.Close()
-
-	d := yaml.NewDecoder(src)
 
-	var inv Inventory
-	err = d.Decode(&inv)
+	hosts, err := invFlags.Hosts(ctx)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
 	}
 
-	ch := make(chan data)
+	// 모든 장비를 한꺼번에 시작합니다. 결과 채널은 Run이 모든 장비의 결과를 보낸 뒤 닫으므로,
+	// 제한 시간이 지나도 닫힌 채널에 보내는 일이 없습니다.
+	runOpts.Limit = len(hosts)
+	rep := runner.Collect(runner.Run(ctx, hosts, device.Retry(getVersion(commands)), runOpts), printResult)
 
-	for _, v := range inv.Routers {
-		go getVersion(v, ch)
+	// 끝나지 못한 장비에서 그때까지 받은 출력입니다.
+	for _, hr := range rep.Results {
+		if hr.Status != runner.StatusOK && hr.Partial != "" {
+			fmt.Printf("### %s: partial output (%s)\n%s\n", hr.Host, hr.Status, hr.Partial)
+		}
 	}
 
-	for {
-		select {
-		case out := <-ch:
-			fmt.Printf("Hostname: %s\nHardware: %s\nSW Version: %s\nUptime: %s\n\n",
-				out.host, out.hw, out.version, out.uptime)
-		case <-time.After(5 * time.Second):
-			close(ch)
-			fmt.Println("Timeout: 5 seconds")
-			return
+	rep.WriteTable(os.Stdout)
+	if *reportPath != "" {
+		if err := rep.Save(*reportPath); err != nil {
+			log.Fatal(err)
 		}
 	}
+	os.Exit(rep.ExitCode())
 }
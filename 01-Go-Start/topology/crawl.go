@@ -0,0 +1,211 @@
+package topology
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+
+	"tucker-study/01-Go-Start/device"
+	"tucker-study/01-Go-Start/facts"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/runner"
+	"tucker-study/01-Go-Start/textfsm"
+)
+
+// Options는 Crawl의 동작을 정합니다.
+type Options struct {
+	// Depth는 시작 장비에서 몇 홉까지 접속할지입니다. 0이면 더 찾을 장비가 없을 때까지 따라갑니다.
+	// 제한 밖의 이웃도 노드로는 남습니다.
+	Depth int
+	// Discover가 true이면 인벤토리에 없는 이웃도 관리 주소와 플랫폼을 알면 접속합니다.
+	// 접속 정보(계정, ssh_config 등)는 그 이웃을 찾은 장비의 것을 씁니다.
+	Discover bool
+	// Protocols는 이웃을 찾을 프로토콜입니다. 비어 있으면 facts.Protocols 모두입니다.
+	Protocols []string
+	// Run은 홉마다 장비들에 접속할 때 쓰는 runner 옵션입니다.
+	Run runner.Options
+}
+
+// visit은 한 홉에서 접속할 장비입니다.
+type visit struct {
+	router inventory.Router
+	depth  int
+}
+
+// Crawl은 seeds에서 시작해 이웃을 따라가며 그래프를 만듭니다. 홉마다 그 홉의 장비들에 동시에 접속합니다.
+// inv는 이웃 이름이나 관리 주소를 인벤토리 장비로 알아볼 때 씁니다. (보통 그룹 변수를 합친 Inventory.Hosts)
+// 접속하지 못한 장비는 StateUnreachable 노드가 되고, ctx가 끝나면 남은 장비는 접속하지 않고 돌아옵니다.
+func Crawl(ctx context.Context, inv []inventory.Router, seeds []inventory.Router, opts Options) *Graph {
+	protocols := opts.Protocols
+	if len(protocols) == 0 {
+		protocols = facts.Protocols
+	}
+
+	g := NewGraph()
+	// queued는 접속하기로 한 장비입니다. 같은 장비를 두 번 찾지 않습니다.
+	queued := map[string]bool{}
+	var level []visit
+	for _, r := range seeds {
+		if n, _ := g.add(routerNode(r, true)); !queued[n.ID] {
+			queued[n.ID] = true
+			level = append(level, visit{router: r})
+		}
+	}
+
+	for len(level) > 0 && ctx.Err() == nil {
+		hosts := make([]inventory.Router, len(level))
+		for i, v := range level {
+			hosts[i] = v.router
+		}
+
+		var next []visit
+		for res := range runner.Run(ctx, hosts, neighborsJob(protocols), opts.Run) {
+			from := level[res.Index]
+			n, _ := g.Node(from.router.Hostname)
+			n.State = StateCrawled
+			if res.Err != nil {
+				n.Error = res.Err.Error()
+				// 접속은 했지만 이웃을 모으지 못한 장비(LLDP/CDP가 꺼져 있는 등)는 crawled로 둡니다.
+				if stage := runner.StageOf(res.Err); stage != device.StageSend && stage != device.StageParse {
+					n.State = StateUnreachable
+				}
+			}
+
+			for _, nb := range res.Value {
+				found, r, inInventory := resolve(inv, nb, from.router)
+				// 이름도 관리 주소도 chassis ID도 없는 이웃은 노드로 만들 수 없고,
+				// 자기 자신으로 보이는 이웃(같은 장비의 다른 포트끼리 연결 등)은 링크로 그리지 않습니다.
+				if found.ID == "" || found.ID == n.ID {
+					continue
+				}
+				g.link(n.ID, nb.LocalInterface, found.ID, nb.Interface, nb.Protocol)
+
+				found.Depth = from.depth + 1
+				peer, _ := g.add(found)
+				// 먼저 본 이웃 정보에 없던 주소나 플랫폼은 채웁니다. (LLDP에는 관리 주소가 없고 CDP에는 있는 경우)
+				peer.Address = cmp.Or(peer.Address, found.Address)
+				peer.Platform = cmp.Or(peer.Platform, found.Platform)
+				if queued[peer.ID] || r == nil || (!inInventory && !opts.Discover) || (opts.Depth > 0 && peer.Depth > opts.Depth) {
+					continue
+				}
+				queued[peer.ID] = true
+				next = append(next, visit{router: *r, depth: peer.Depth})
+			}
+		}
+		level = next
+	}
+	return g
+}
+
+// neighborsJob은 장비마다 protocols의 이웃을 모읍니다. 템플릿이 없는 프로토콜(arista_eos의 CDP)은 건너뛰고,
+// 어느 프로토콜에서도 이웃을 모으지 못했을 때만 에러입니다.
+func neighborsJob(protocols []string) runner.Job[[]facts.Neighbor] {
+	return func(ctx context.Context, r inventory.Router) ([]facts.Neighbor, error) {
+		s, err := device.Open(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		defer s.Close()
+
+		var all []facts.Neighbor
+		var errs []error
+		ok := false
+		for _, p := range protocols {
+			ns, _, err := facts.Neighbors(s, p)
+			if errors.Is(err, textfsm.ErrNoTemplate) {
+				continue
+			}
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			all, ok = append(all, ns...), true
+		}
+		if !ok {
+			return all, errors.Join(errs...)
+		}
+		return all, nil
+	}
+}
+
+// routerNode는 장비의 노드를 만듭니다. 접속하기 전이므로 StateUnknown입니다.
+func routerNode(r inventory.Router, inInventory bool) *Node {
+	n := &Node{ID: r.Hostname, Platform: r.Platform, State: StateUnknown, Inventory: inInventory}
+	if r.IP.IsValid() {
+		n.Address = r.IP.Addr().String()
+	}
+	return n
+}
+
+// resolve는 이웃을 노드로 바꿉니다. 인벤토리 장비이면 그 장비를, 아니면 이웃이 알려 준 이름과 관리 주소로
+// 새 장비를 만듭니다. 접속할 수 없는 이웃(주소나 플랫폼을 모름)이면 r은 nil입니다.
+// 새 장비의 접속 정보는 이웃을 찾은 장비(parent)의 것을 물려받습니다.
+func resolve(inv []inventory.Router, nb facts.Neighbor, parent inventory.Router) (n *Node, r *inventory.Router, inInventory bool) {
+	if known, ok := lookup(inv, nb); ok {
+		return routerNode(known, true), &known, true
+	}
+
+	id := nb.Name
+	if id == "" {
+		id = cmp.Or(nb.MgmtAddress, nb.ChassisID)
+	}
+	n = &Node{ID: id, Address: nb.MgmtAddress, Platform: guessPlatform(nb), State: StateUnknown}
+	addr, err := inventory.ParseMgmtAddr(nb.MgmtAddress)
+	if err != nil || n.Platform == "" {
+		return n, nil, false
+	}
+	found := inventory.Router{Hostname: id, IP: addr, Vars: parent.Vars}
+	found.Platform = n.Platform
+	return n, &found, false
+}
+
+// lookup은 이웃을 인벤토리에서 찾습니다. hostname이 같거나(대소문자, 도메인 차이는 무시) 관리 주소가 같으면 같은 장비입니다.
+func lookup(inv []inventory.Router, nb facts.Neighbor) (inventory.Router, bool) {
+	short := func(name string) string {
+		host, _, _ := strings.Cut(name, ".")
+		return strings.ToLower(host)
+	}
+	addr, _ := netip.ParseAddr(nb.MgmtAddress)
+	for _, r := range inv {
+		if nb.Name != "" && (strings.EqualFold(r.Hostname, nb.Name) || short(r.Hostname) == short(nb.Name)) {
+			return r, true
+		}
+	}
+	for _, r := range inv {
+		if addr.IsValid() && r.IP.IsValid() && r.IP.Addr() == addr {
+			return r, true
+		}
+	}
+	return inventory.Router{}, false
+}
+
+// platformHints는 CDP Platform, LLDP System Description, CDP Version에 보이는 문자열로 scrapligo 플랫폼을 짐작합니다.
+// 앞에서부터 맞는 것을 씁니다.
+var platformHints = []struct {
+	hint     string
+	platform string
+}{
+	{"nx-os", "cisco_nxos"},
+	{"nexus", "cisco_nxos"},
+	{"n9k", "cisco_nxos"},
+	{"ios xr", "cisco_iosxr"},
+	{"ios-xr", "cisco_iosxr"},
+	{"ios xe", "cisco_iosxe"},
+	{"ios-xe", "cisco_iosxe"},
+	{"xe software", "cisco_iosxe"},
+	{"arista", "arista_eos"},
+	{"junos", "juniper_junos"},
+}
+
+// guessPlatform은 이웃의 플랫폼을 짐작합니다. 모르면 빈 문자열입니다.
+func guessPlatform(nb facts.Neighbor) string {
+	s := strings.ToLower(nb.Platform + " " + nb.Description)
+	for _, h := range platformHints {
+		if strings.Contains(s, h.hint) {
+			return h.platform
+		}
+	}
+	return ""
+}
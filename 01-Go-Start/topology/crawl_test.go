@@ -0,0 +1,165 @@
+package topology_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"tucker-study/01-Go-Start/fakedevice"
+	"tucker-study/01-Go-Start/inventory"
+	"tucker-study/01-Go-Start/topology"
+)
+
+// startNetwork는 기본 LLDP/CDP 응답으로 이어진 장비들을 띄웁니다.
+//
+//	rtr1 (cisco_iosxe) ─ rtr2.example.com, sw1
+//	rtr2 (cisco_nxos)  ─ rtr1, nx2
+//	nx2  (cisco_nxos)  ─ rtr1, nx2 (자기 자신)
+func startNetwork(t *testing.T) []inventory.Router {
+	t.Helper()
+	var inv []inventory.Router
+	for _, d := range []fakedevice.Device{
+		{Hostname: "rtr1", Platform: "cisco_iosxe"},
+		{Hostname: "rtr2", Platform: "cisco_nxos"},
+		{Hostname: "nx2", Platform: "cisco_nxos"},
+	} {
+		srv, err := fakedevice.Start(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { srv.Close() })
+		inv = append(inv, srv.Router())
+	}
+	return inv
+}
+
+// nodes는 노드를 "id state depth" 목록으로 돌려줍니다.
+func nodes(g *topology.Graph) []string {
+	var out []string
+	for _, n := range g.Nodes {
+		out = append(out, fmt.Sprintf("%s %s %d", n.ID, n.State, n.Depth))
+	}
+	return out
+}
+
+// links는 연결을 "a:if - b:if protocols" 목록으로 돌려줍니다.
+func links(g *topology.Graph) []string {
+	var out []string
+	for _, l := range g.Links {
+		out = append(out, fmt.Sprintf("%s:%s - %s:%s %s", l.Source, l.SourceInterface, l.Target, l.TargetInterface, strings.Join(l.Protocols, ",")))
+	}
+	return out
+}
+
+func TestCrawl(t *testing.T) {
+	inv := startNetwork(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	g := topology.Crawl(ctx, inv, inv[:1], topology.Options{})
+
+	// rtr2.example.com은 인벤토리의 rtr2로 알아봅니다. sw1은 관리 주소가 없어 접속하지 않습니다.
+	wantNodes := []string{"nx2 crawled 2", "rtr1 crawled 0", "rtr2 crawled 1", "sw1 unknown 1"}
+	if got := nodes(g); !reflect.DeepEqual(got, wantNodes) {
+		t.Errorf("nodes = %q, want %q", got, wantNodes)
+	}
+	// LLDP와 CDP로 본 같은 연결은 하나로 합치고, 자기 자신으로 보이는 이웃은 그리지 않습니다.
+	wantLinks := []string{
+		"rtr1:GigabitEthernet1 - rtr2:GigabitEthernet1 lldp,cdp",
+		"rtr1:GigabitEthernet3 - sw1:Ethernet1 lldp",
+		"rtr2:Ethernet1/1 - rtr1:GigabitEthernet2 lldp",
+		"rtr2:Ethernet1/2 - nx2:Ethernet1/2 cdp",
+		"nx2:Ethernet1/1 - rtr1:GigabitEthernet2 lldp",
+	}
+	if got := links(g); !reflect.DeepEqual(got, wantLinks) {
+		t.Errorf("links = %q, want %q", got, wantLinks)
+	}
+
+	sw1, _ := g.Node("sw1")
+	if sw1.Inventory || sw1.Platform != "arista_eos" || !sw1.Leaf() {
+		t.Errorf("sw1 = %+v", sw1)
+	}
+}
+
+func TestCrawlDepth(t *testing.T) {
+	inv := startNetwork(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 깊이 제한 밖의 장비는 접속하지 않고 노드로만 남습니다.
+	g := topology.Crawl(ctx, inv, inv[:1], topology.Options{Depth: 1, Protocols: []string{"cdp"}})
+	if got, want := nodes(g), []string{"nx2 unknown 2", "rtr1 crawled 0", "rtr2 crawled 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %q, want %q", got, want)
+	}
+	if got, want := links(g), []string{
+		"rtr1:GigabitEthernet1 - rtr2:GigabitEthernet1 cdp",
+		"rtr2:Ethernet1/2 - nx2:Ethernet1/2 cdp",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("links = %q, want %q", got, want)
+	}
+}
+
+func TestCrawlUnreachable(t *testing.T) {
+	inv := startNetwork(t)
+	srv, err := fakedevice.Start(fakedevice.Device{Hostname: "locked", Platform: "cisco_iosxe", Username: "ops", Password: "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	// 로그인할 수 없는 장비입니다.
+	locked := srv.Router()
+	locked.Password = "wrong"
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// 접속하지 못한 시작 장비는 unreachable 잎 노드가 되고, 다른 시작 장비는 그대로 찾습니다.
+	g := topology.Crawl(ctx, append(inv, locked), []inventory.Router{locked, inv[2]}, topology.Options{Depth: 1})
+	n, ok := g.Node("locked")
+	if !ok || n.State != topology.StateUnreachable || n.Error == "" || !n.Leaf() {
+		t.Errorf("locked = %+v", n)
+	}
+	if n, _ := g.Node("nx2"); n.State != topology.StateCrawled {
+		t.Errorf("nx2 = %+v", n)
+	}
+	if n, ok := g.Node("rtr1"); !ok || n.State != topology.StateCrawled || n.Depth != 1 {
+		t.Errorf("rtr1 = %+v", n)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	inv := startNetwork(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	g := topology.Crawl(ctx, inv, inv[:1], topology.Options{Depth: 1, Protocols: []string{"cdp"}})
+
+	for path, want := range map[string][]string{
+		"t.dot": {
+			`"rtr1" [label="rtr1\ncisco_iosxe\n127.0.0.1"];`,
+			`"nx2" [label="nx2\ncisco_nxos\n127.0.0.1", style=dashed, color=gray];`,
+			`"rtr1" -- "rtr2" [taillabel="GigabitEthernet1", headlabel="GigabitEthernet1", tooltip="cdp"];`,
+		},
+		"t.GV":      {"graph topology {"},
+		"t.json":    {`"id": "nx2"`, `"state": "unknown"`, `"source_interface": "Ethernet1/2"`},
+		"t.graphml": {"<graphml", `<node id="rtr2">`, `source="rtr2" target="nx2"`},
+	} {
+		format, err := topology.FormatFromPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := g.Write(&b, format); err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range want {
+			if !strings.Contains(b.String(), w) {
+				t.Errorf("%s: output\n%s\nwant %s", path, b.String(), w)
+			}
+		}
+	}
+	if _, err := topology.FormatFromPath("t.png"); err == nil {
+		t.Error("FormatFromPath accepted .png")
+	}
+}
@@ -0,0 +1,195 @@
+package topology
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// 그래프를 내보내는 형식입니다.
+const (
+	FormatDOT     = "dot"
+	FormatJSON    = "json"
+	FormatGraphML = "graphml"
+)
+
+// FormatFromPath는 파일 확장자로 형식을 정합니다. (.dot/.gv, .json, .graphml)
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dot", ".gv":
+		return FormatDOT, nil
+	case ".json":
+		return FormatJSON, nil
+	case ".graphml":
+		return FormatGraphML, nil
+	}
+	return "", fmt.Errorf("unknown topology format for %q (want .dot, .json or .graphml)", path)
+}
+
+// Write는 그래프를 format으로 씁니다.
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatDOT:
+		return g.WriteDOT(w)
+	case FormatJSON:
+		return g.WriteJSON(w)
+	case FormatGraphML:
+		return g.WriteGraphML(w)
+	}
+	return fmt.Errorf("unknown topology format %q", format)
+}
+
+// WriteJSON은 그래프를 {"nodes": [...], "links": [...]} 형태로 씁니다. (d3-force 등에서 바로 읽을 수 있는 형태)
+func (g *Graph) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+// WriteDOT은 그래프를 Graphviz DOT으로 씁니다. 잎 노드는 점선으로, 접속하지 못한 장비는 빨간색으로 그립니다.
+//
+//	$ topology -format dot core | dot -Tsvg > topology.svg
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("graph topology {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		label := n.ID
+		if n.Platform != "" {
+			label += "\n" + n.Platform
+		}
+		if n.Address != "" {
+			label += "\n" + n.Address
+		}
+		attrs := []string{"label=" + dotQuote(label)}
+		switch n.State {
+		case StateUnreachable:
+			attrs = append(attrs, "style=dashed", "color=red")
+		case StateUnknown:
+			attrs = append(attrs, "style=dashed", "color=gray")
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, l := range g.Links {
+		var attrs []string
+		if l.SourceInterface != "" {
+			attrs = append(attrs, "taillabel="+dotQuote(l.SourceInterface))
+		}
+		if l.TargetInterface != "" {
+			attrs = append(attrs, "headlabel="+dotQuote(l.TargetInterface))
+		}
+		attrs = append(attrs, "tooltip="+dotQuote(strings.Join(l.Protocols, ",")))
+		fmt.Fprintf(&b, "  %s -- %s [%s];\n", dotQuote(l.Source), dotQuote(l.Target), strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// dotQuote는 DOT의 따옴표 문자열입니다. 줄바꿈은 DOT 줄바꿈(\n)으로 바꿉니다.
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// GraphML 문서입니다. 노드와 연결의 속성은 <key>로 선언한 <data>로 씁니다.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLItem `xml:"node"`
+		Edges       []graphMLItem `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLItem struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// data는 빈 값을 뺀 <data> 목록입니다.
+func data(kv ...string) []graphMLData {
+	var d []graphMLData
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			d = append(d, graphMLData{Key: kv[i], Value: kv[i+1]})
+		}
+	}
+	return d
+}
+
+// WriteGraphML은 그래프를 GraphML로 씁니다. (yEd, Gephi, networkx 등에서 읽을 수 있습니다)
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "platform", For: "node", Name: "platform", Type: "string"},
+			{ID: "address", For: "node", Name: "address", Type: "string"},
+			{ID: "state", For: "node", Name: "state", Type: "string"},
+			{ID: "inventory", For: "node", Name: "inventory", Type: "boolean"},
+			{ID: "depth", For: "node", Name: "depth", Type: "int"},
+			{ID: "error", For: "node", Name: "error", Type: "string"},
+			{ID: "source_interface", For: "edge", Name: "source_interface", Type: "string"},
+			{ID: "target_interface", For: "edge", Name: "target_interface", Type: "string"},
+			{ID: "protocols", For: "edge", Name: "protocols", Type: "string"},
+		},
+	}
+	doc.Graph.ID = "topology"
+	doc.Graph.EdgeDefault = "undirected"
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLItem{
+			ID: n.ID,
+			Data: data(
+				"platform", n.Platform,
+				"address", n.Address,
+				"state", string(n.State),
+				"inventory", fmt.Sprint(n.Inventory),
+				"depth", fmt.Sprint(n.Depth),
+				"error", n.Error,
+			),
+		})
+	}
+	for i, l := range g.Links {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLItem{
+			ID:     fmt.Sprintf("e%d", i),
+			Source: l.Source,
+			Target: l.Target,
+			Data: data(
+				"source_interface", l.SourceInterface,
+				"target_interface", l.TargetInterface,
+				"protocols", strings.Join(l.Protocols, ","),
+			),
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
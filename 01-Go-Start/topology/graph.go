@@ -0,0 +1,102 @@
+package topology
+
+import (
+	"slices"
+	"strings"
+)
+
+// 인벤토리의 시작 장비에서 LLDP/CDP 이웃을 따라가며 네트워크를 찾아, 장비(Node)와 연결(Link)의 그래프로 만드는 패키지입니다.
+// 그래프는 Graphviz DOT, JSON(nodes/links), GraphML로 내보낼 수 있습니다.
+//
+//	g := topology.Crawl(ctx, inv, seeds, topology.Options{Depth: 3})
+//	g.WriteDOT(os.Stdout)
+//
+// 접속하지 못한 장비(StateUnreachable)와 접속할 방법을 모르는 이웃(StateUnknown)도 그래프에 남지만,
+// 그 너머는 찾지 않으므로 잎(leaf) 노드가 됩니다.
+
+// State는 노드를 어디까지 알아냈는지입니다.
+type State string
+
+const (
+	// StateCrawled는 접속해서 이웃을 모은 장비입니다.
+	StateCrawled State = "crawled"
+	// StateUnreachable은 접속하려 했지만 실패한 장비입니다.
+	StateUnreachable State = "unreachable"
+	// StateUnknown은 이웃으로만 보였고 접속하지 않은 장비입니다. (인벤토리에 없거나, 주소나 플랫폼을 모르거나, 깊이 제한 밖)
+	StateUnknown State = "unknown"
+)
+
+// Node는 그래프의 장비 하나입니다. ID는 인벤토리 hostname이거나, 인벤토리에 없으면 이웃이 알려 준 이름입니다.
+type Node struct {
+	ID       string `json:"id"`
+	Platform string `json:"platform,omitempty"`
+	Address  string `json:"address,omitempty"`
+	State    State  `json:"state"`
+	// Inventory는 인벤토리에 있는 장비인지입니다.
+	Inventory bool `json:"inventory"`
+	// Depth는 시작 장비에서 몇 홉 떨어져 있는지입니다.
+	Depth int    `json:"depth"`
+	Error string `json:"error,omitempty"`
+}
+
+// Leaf는 그 너머를 찾지 않은 노드인지 확인합니다.
+func (n *Node) Leaf() bool {
+	return n.State != StateCrawled
+}
+
+// Link는 두 장비 사이의 연결 하나입니다. 양쪽에서 본 같은 연결은 하나로 합칩니다.
+type Link struct {
+	Source          string `json:"source"`
+	SourceInterface string `json:"source_interface,omitempty"`
+	Target          string `json:"target"`
+	TargetInterface string `json:"target_interface,omitempty"`
+	// Protocols는 이 연결을 본 프로토콜입니다. (lldp, cdp)
+	Protocols []string `json:"protocols"`
+}
+
+// Graph는 찾은 장비와 연결입니다. Nodes는 ID 순서, Links는 찾은 순서입니다.
+type Graph struct {
+	Nodes []*Node `json:"nodes"`
+	Links []*Link `json:"links"`
+
+	nodes map[string]*Node
+	links map[string]*Link
+}
+
+// NewGraph는 빈 그래프를 만듭니다.
+func NewGraph() *Graph {
+	return &Graph{nodes: map[string]*Node{}, links: map[string]*Link{}}
+}
+
+// Node는 ID가 id인 노드를 찾습니다.
+func (g *Graph) Node(id string) (*Node, bool) {
+	n, ok := g.nodes[id]
+	return n, ok
+}
+
+// add는 노드를 찾고, 없으면 n을 넣습니다. 새로 넣었는지도 돌려줍니다.
+func (g *Graph) add(n *Node) (*Node, bool) {
+	if have, ok := g.nodes[n.ID]; ok {
+		return have, false
+	}
+	g.nodes[n.ID] = n
+	i, _ := slices.BinarySearchFunc(g.Nodes, n.ID, func(a *Node, id string) int { return strings.Compare(a.ID, id) })
+	g.Nodes = slices.Insert(g.Nodes, i, n)
+	return n, true
+}
+
+// link는 연결을 넣습니다. 이미 본 연결이면(반대쪽에서 본 것도) 프로토콜만 더합니다.
+func (g *Graph) link(src, srcIf, dst, dstIf, protocol string) {
+	key := func(a, ai, b, bi string) string { return a + "\x00" + ai + "\x00" + b + "\x00" + bi }
+	for _, k := range []string{key(src, srcIf, dst, dstIf), key(dst, dstIf, src, srcIf)} {
+		if l, ok := g.links[k]; ok {
+			if !slices.Contains(l.Protocols, protocol) {
+				l.Protocols = append(l.Protocols, protocol)
+			}
+			return
+		}
+	}
+	l := &Link{Source: src, SourceInterface: srcIf, Target: dst, TargetInterface: dstIf, Protocols: []string{protocol}}
+	g.links[key(src, srcIf, dst, dstIf)] = l
+	g.Links = append(g.Links, l)
+}
@@ -0,0 +1,174 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// 인벤토리 파일에 평문 비밀번호 대신 넣을 수 있는 암호화 값 형식입니다.
+//
+//	vault:v1:<base64(salt | nonce | AES-256-GCM ciphertext)>
+//
+// 키는 패스프레이즈(또는 키 파일 내용)와 값마다 다른 salt로 scrypt를 돌려 만듭니다.
+// 한 줄짜리 문자열이라 YAML/JSON/XML 어디에나 그대로 넣을 수 있습니다.
+const (
+	Prefix = "vault:v1:"
+
+	// PasswordEnv와 PasswordFileEnv는 FromEnv가 키를 찾는 환경 변수입니다.
+	PasswordEnv     = "INVENTORY_VAULT_PASSWORD"
+	PasswordFileEnv = "INVENTORY_VAULT_PASSWORD_FILE"
+
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt 파라미터 (2017년 기준 권장값)
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	ErrNoKey   = errors.New("vault: no key (set " + PasswordFileEnv + " or " + PasswordEnv + ")")
+	ErrDecrypt = errors.New("vault: decryption failed (wrong key or corrupted value)")
+	ErrFormat  = errors.New("vault: malformed value")
+)
+
+// IsEncrypted는 s가 vault 형식의 값인지 확인합니다.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Vault는 하나의 비밀(패스프레이즈 또는 키 파일)로 값을 암호화/복호화합니다.
+// scrypt는 일부러 느리게 만든 함수라 salt별로 만든 키를 캐시합니다.
+type Vault struct {
+	secret []byte
+
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// New는 주어진 비밀로 Vault를 만듭니다.
+func New(secret []byte) *Vault {
+	return &Vault{secret: secret, keys: make(map[string][]byte)}
+}
+
+// FromKeyFile은 키 파일의 내용을 비밀로 사용합니다. 끝의 줄바꿈은 무시합니다.
+func FromKeyFile(path string) (*Vault, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("vault: failed to read key file: %w", err)
+	}
+
+	secret := strings.TrimRight(string(b), "\r\n")
+	if secret == "" {
+		return nil, fmt.Errorf("vault: key file %s is empty", path)
+	}
+	return New([]byte(secret)), nil
+}
+
+// FromEnv는 환경 변수에서 키를 찾습니다. 키 파일 경로가 패스프레이즈보다 우선합니다.
+func FromEnv() (*Vault, error) {
+	if path := os.Getenv(PasswordFileEnv); path != "" {
+		return FromKeyFile(path)
+	}
+	if pass := os.Getenv(PasswordEnv); pass != "" {
+		return New([]byte(pass)), nil
+	}
+	return nil, ErrNoKey
+}
+
+func (v *Vault) key(salt []byte) ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if k, ok := v.keys[string(salt)]; ok {
+		return k, nil
+	}
+
+	k, err := scrypt.Key(v.secret, salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+	v.keys[string(salt)] = k
+	return k, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt는 평문을 vault 형식의 문자열로 암호화합니다.
+func (v *Vault) Encrypt(plaintext string) (string, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key, err := v.key(salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	// salt를 추가 인증 데이터로 넣어 salt만 바꿔치기하는 것도 막습니다.
+	out := append(salt, nonce...)
+	out = gcm.Seal(out, nonce, []byte(plaintext), salt)
+
+	return Prefix + base64.StdEncoding.EncodeToString(out), nil
+}
+
+// Decrypt는 vault 형식의 문자열을 복호화합니다.
+func (v *Vault) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", ErrFormat
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, Prefix))
+	if err != nil {
+		return "", ErrFormat
+	}
+	if len(raw) < saltSize {
+		return "", ErrFormat
+	}
+	salt, rest := raw[:saltSize], raw[saltSize:]
+
+	key, err := v.key(salt)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return "", ErrFormat
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, salt)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,109 @@
+package vault_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tucker-study/01-Go-Start/vault"
+)
+
+func mustEncrypt(t *testing.T, v *vault.Vault, plaintext string) string {
+	t.Helper()
+	enc, err := v.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestRoundTrip(t *testing.T) {
+	v := vault.New([]byte("correct horse"))
+	for _, plaintext := range []string{"", "cisco123", "비밀번호 with spaces\nand newline"} {
+		enc := mustEncrypt(t, v, plaintext)
+		if !vault.IsEncrypted(enc) || strings.Contains(enc, plaintext) && plaintext != "" {
+			t.Errorf("Encrypt(%q) = %q", plaintext, enc)
+		}
+		// 같은 키의 새 Vault로도 풀립니다. (salt마다 키를 새로 만듭니다)
+		got, err := vault.New([]byte("correct horse")).Decrypt(enc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, got)
+		}
+	}
+
+	// salt와 nonce가 매번 달라서 같은 평문도 다른 값이 됩니다.
+	if a, b := mustEncrypt(t, v, "cisco123"), mustEncrypt(t, v, "cisco123"); a == b {
+		t.Error("two encryptions of the same value are identical")
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	enc := mustEncrypt(t, vault.New([]byte("correct horse")), "cisco123")
+	if _, err := vault.New([]byte("battery staple")).Decrypt(enc); !errors.Is(err, vault.ErrDecrypt) {
+		t.Errorf("Decrypt with a wrong key = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestTampered(t *testing.T) {
+	v := vault.New([]byte("correct horse"))
+	enc := mustEncrypt(t, v, "cisco123")
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(enc, vault.Prefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// salt(앞 16바이트), nonce, 암호문 어느 바이트를 바꿔도 풀리지 않습니다.
+	for _, i := range []int{0, 16, len(raw) - 1} {
+		b := append([]byte(nil), raw...)
+		b[i] ^= 1
+		if _, err := v.Decrypt(vault.Prefix + base64.StdEncoding.EncodeToString(b)); !errors.Is(err, vault.ErrDecrypt) {
+			t.Errorf("byte %d flipped: err = %v, want ErrDecrypt", i, err)
+		}
+	}
+
+	for _, bad := range []string{"cisco123", vault.Prefix + "not base64!", vault.Prefix + base64.StdEncoding.EncodeToString(raw[:20])} {
+		if _, err := v.Decrypt(bad); !errors.Is(err, vault.ErrFormat) {
+			t.Errorf("Decrypt(%q) = %v, want ErrFormat", bad, err)
+		}
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	if err := os.WriteFile(keyFile, []byte("from file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	enc := mustEncrypt(t, vault.New([]byte("from file")), "cisco123")
+
+	t.Setenv(vault.PasswordEnv, "")
+	t.Setenv(vault.PasswordFileEnv, "")
+	if _, err := vault.FromEnv(); !errors.Is(err, vault.ErrNoKey) {
+		t.Errorf("FromEnv without a key = %v, want ErrNoKey", err)
+	}
+
+	// 키 파일이 패스프레이즈보다 우선하고, 끝의 줄바꿈은 키에 들어가지 않습니다.
+	t.Setenv(vault.PasswordEnv, "from env")
+	t.Setenv(vault.PasswordFileEnv, keyFile)
+	v, err := vault.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := v.Decrypt(enc); err != nil || got != "cisco123" {
+		t.Errorf("Decrypt with the key file = %q, %v", got, err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := vault.FromKeyFile(empty); err == nil {
+		t.Error("FromKeyFile accepted an empty key file")
+	}
+}
@@ -1,38 +1,26 @@
 package main
 
 import (
-	"encoding/json"
 	"encoding/xml"
 	"fmt"
-	"os"
 	"strings"
-)
-
-type Router struct {
-	Hostname string `json:"hostname" xml:"hostname"`
-	IP       string `json:"ip" xml:"ip"`
-	ASN      uint16 `json:"asn" xml:"asn"`
-}
 
-type Inventory struct {
-	Routers []Router `json:"router" xml:"router"`
-}
+	"tucker-study/01-Go-Start/inventory"
+)
 
 func main() {
-	file, err := os.Open("01-Go-Start/xml-encode/input.json")
+	inv, err := inventory.Load("01-Go-Start/xml-encode/input.json")
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
-	d := json.NewDecoder(file)
-
-	var inv Inventory
-	err = d.Decode(&inv)
 
 	var dest strings.Builder
-	fmt.Printf("%+v\n", inv)
+	fmt.Printf("%+v\n", inv.Routers)
 	e := xml.NewEncoder(&dest)
-	err = e.Encode(&inv)
+	err = e.Encode(inv)
+	if err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("%+v\n", dest.String())
 }
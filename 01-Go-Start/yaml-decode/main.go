@@ -2,31 +2,15 @@ package main
 
 import (
 	"fmt"
-	"gopkg.in/yaml.v3"
-	"os"
-)
-
-type Router struct {
-	Hostname string `yaml:"hostname"`
-	IP       string `yaml:"ip"`
-	ASN      uint16 `yaml:"asn"`
-}
 
-type Inventory struct {
-	Routers []Router `yaml:"router"`
-}
+	"tucker-study/01-Go-Start/inventory"
+)
 
 func main() {
-
-	file, err := os.Open("01-Go-Start/yaml-decode/input.yml")
+	inv, err := inventory.Load("01-Go-Start/yaml-decode/input.yml")
 	if err != nil {
 		panic(err)
 	}
-	d := yaml.NewDecoder(file)
-
-	var inv Inventory
-
-	err = d.Decode(&inv)
 
-	fmt.Println("%+v\n", inv)
+	fmt.Printf("%+v\n", inv.Routers)
 }